@@ -0,0 +1,152 @@
+/*
+Package actioncodec encodes payloads of buttons and modal private_metadata into signed strings.
+
+	v1.<base64url json>.<base64url hmac-sha256>
+
+The payload is signed with HMAC-SHA256, so tampered or expired payloads are rejected by Decode.
+*/
+package actioncodec
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Version = 1
+
+	_versionPrefix = "v1"
+)
+
+var (
+	ErrInvalidPayload     = errors.New("invalid action payload")
+	ErrUnsupportedVersion = errors.New("unsupported action payload version")
+	ErrExpiredPayload     = errors.New("expired action payload")
+)
+
+/*
+Payload is the data carried by buttons and modals.
+
+Args carries extra arguments of the action, e.g. the days of clearing notifications.
+*/
+type Payload struct {
+	Version   int               `json:"v"`
+	Service   string            `json:"s"`
+	Action    string            `json:"a"`
+	MentionID uint64            `json:"m,omitempty"`
+	Args      map[string]string `json:"x,omitempty"`
+	IssuedAt  int64             `json:"t"`
+}
+
+func (p Payload) Arg(key string) string {
+	if p.Args == nil {
+		return ""
+	}
+	return p.Args[key]
+}
+
+type Codec struct {
+	secret []byte
+	ttl    time.Duration
+	ttls   map[string]time.Duration /* ttl of actions which don't use the default */
+	now    func() time.Time
+}
+
+// New creates the codec, zero ttl means payloads never expire.
+func New(secret string, ttl time.Duration) Codec {
+	return Codec{
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// WithTTL returns the codec which expires payloads of the action after ttl, zero ttl means they never expire.
+func (c Codec) WithTTL(action string, ttl time.Duration) Codec {
+	ttls := make(map[string]time.Duration, len(c.ttls)+1)
+	for k, v := range c.ttls {
+		ttls[k] = v
+	}
+	ttls[action] = ttl
+	c.ttls = ttls
+	return c
+}
+
+func (c Codec) Encode(p Payload) (string, error) {
+	if len(c.secret) == 0 {
+		return "", errors.New("empty action codec secret")
+	}
+
+	p.Version = Version
+	if p.IssuedAt == 0 {
+		p.IssuedAt = c.now().Unix()
+	}
+
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal action payload")
+	}
+
+	body := _versionPrefix + "." + base64.RawURLEncoding.EncodeToString(buf)
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body)), nil
+}
+
+func (c Codec) Decode(s string) (Payload, error) {
+	if len(c.secret) == 0 {
+		return Payload{}, errors.New("empty action codec secret")
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	if parts[0] != _versionPrefix {
+		return Payload{}, ErrUnsupportedVersion
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	if !hmac.Equal(sig, c.sign(parts[0]+"."+parts[1])) {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	p := Payload{}
+	if err := json.Unmarshal(buf, &p); err != nil {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	if p.Version != Version {
+		return Payload{}, ErrUnsupportedVersion
+	}
+
+	ttl := c.ttl
+	if t, ok := c.ttls[p.Action]; ok {
+		ttl = t
+	}
+	if ttl > 0 && c.now().Sub(time.Unix(p.IssuedAt, 0)) > ttl {
+		return Payload{}, ErrExpiredPayload
+	}
+
+	return p, nil
+}
+
+func (c Codec) sign(body string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
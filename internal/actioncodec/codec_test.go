@@ -0,0 +1,115 @@
+package actioncodec
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// newTestCodec creates the codec whose clock is now.
+func newTestCodec(secret string, ttl time.Duration, now time.Time) Codec {
+	c := New(secret, ttl)
+	c.now = func() time.Time { return now }
+	return c
+}
+
+// signed returns the value of the body signed by the codec, the body may be anything the codec wouldn't encode.
+func signed(c Codec, prefix, body string) string {
+	b := prefix + "." + body
+	return b + "." + base64.RawURLEncoding.EncodeToString(c.sign(b))
+}
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newTestCodec("secret", time.Hour, now)
+
+	want := Payload{Service: "maid", Action: "mention.delete", MentionID: 12, Args: map[string]string{"days": "7"}}
+	s, err := c.Encode(want)
+	if err != nil {
+		t.Fatalf("encode: %+v", err)
+	}
+
+	got, err := c.Decode(s)
+	if err != nil {
+		t.Fatalf("decode: %+v", err)
+	}
+	want.Version, want.IssuedAt = Version, now.Unix()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expect %+v, got %+v", want, got)
+	}
+	if got.Arg("days") != "7" || got.Arg("none") != "" {
+		t.Fatalf("unexpected args: %+v", got.Args)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newTestCodec("secret", time.Hour, now).WithTTL("view.resend", 10*time.Minute)
+	encode := func(c Codec, p Payload) string {
+		s, err := c.Encode(p)
+		if err != nil {
+			t.Fatalf("encode: %+v", err)
+		}
+		return s
+	}
+
+	valid := encode(c, Payload{Service: "maid", Action: "mention.delete", MentionID: 12})
+	parts := strings.Split(valid, ".")
+	other := encode(c, Payload{Service: "maid", Action: "mention.delete", MentionID: 13})
+	otherParts := strings.Split(other, ".")
+
+	testCases := []struct {
+		name  string
+		codec Codec
+		value string
+		err   error
+	}{
+		{"tampered payload", c, parts[0] + "." + otherParts[1] + "." + parts[2], ErrInvalidPayload},
+		{"tampered signature", c, parts[0] + "." + parts[1] + "." + otherParts[2], ErrInvalidPayload},
+		{"wrong key", newTestCodec("other", time.Hour, now), valid, ErrInvalidPayload},
+		{"expired", newTestCodec("secret", time.Hour, now.Add(2*time.Hour)), valid, ErrExpiredPayload},
+		{"expired action ttl", newTestCodec("secret", time.Hour, now.Add(20*time.Minute)).WithTTL("view.resend", 10*time.Minute),
+			encode(c, Payload{Service: "maid", Action: "view.resend"}), ErrExpiredPayload},
+		{"unknown version prefix", c, "v2." + parts[1] + "." + parts[2], ErrUnsupportedVersion},
+		{"unknown version of signed payload", c, signed(c, "v1", base64.RawURLEncoding.EncodeToString([]byte(`{"v":2,"s":"maid"}`))), ErrUnsupportedVersion},
+		{"malformed signature base64", c, parts[0] + "." + parts[1] + ".!!!", ErrInvalidPayload},
+		{"malformed payload base64", c, signed(c, "v1", "!!!"), ErrInvalidPayload},
+		{"malformed payload json", c, signed(c, "v1", base64.RawURLEncoding.EncodeToString([]byte("{"))), ErrInvalidPayload},
+		{"missing parts", c, parts[0] + "." + parts[1], ErrInvalidPayload},
+		{"empty", c, "", ErrInvalidPayload},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.codec.Decode(tc.value); !errors.Is(err, tc.err) {
+				t.Fatalf("expect %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestZeroTTLNeverExpires(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s, err := newTestCodec("secret", 0, now).Encode(Payload{Service: "maid", Action: "set"})
+	if err != nil {
+		t.Fatalf("encode: %+v", err)
+	}
+
+	if _, err := newTestCodec("secret", 0, now.AddDate(1, 0, 0)).Decode(s); err != nil {
+		t.Fatalf("expect payload of zero ttl accepted, got %+v", err)
+	}
+}
+
+func TestEmptySecret(t *testing.T) {
+	c := New("", time.Hour)
+	if _, err := c.Encode(Payload{Service: "maid"}); err == nil {
+		t.Fatal("expect encode with empty secret failed")
+	}
+	if _, err := c.Decode("v1.e30.e30"); err == nil {
+		t.Fatal("expect decode with empty secret failed")
+	}
+}
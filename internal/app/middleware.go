@@ -1,6 +1,7 @@
 package app
 
 import (
+	"bitopi/internal/model"
 	"bitopi/internal/service"
 	"net/http"
 	"strings"
@@ -13,18 +14,37 @@ const (
 	_tokenHeaderKey = "TOKEN"
 )
 
+/*
+tokenValidator validates the request token and attaches the token name as the actor of audit logs.
+
+Named tokens are read from 'admin.tokens', and 'admin.token' is named 'admin'.
+*/
 func tokenValidator(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		valid := viper.GetString("admin.token")
-		token := c.Request().Header.Get(_tokenHeaderKey)
+		valid := viper.GetStringMapString("admin.tokens")
+		if t := viper.GetString("admin.token"); len(t) != 0 {
+			valid["admin"] = t
+		}
+
 		if len(valid) == 0 {
 			return service.ErrorResponse(c, http.StatusInternalServerError, "service token not set")
 		}
 
-		if !strings.EqualFold(valid, token) {
+		token := c.Request().Header.Get(_tokenHeaderKey)
+		name := ""
+		for n, t := range valid {
+			if len(t) != 0 && strings.EqualFold(t, token) {
+				name = n
+				break
+			}
+		}
+
+		if len(name) == 0 {
 			return service.ErrorResponse(c, http.StatusBadRequest, "invalid token")
 		}
 
+		ctx := model.WithActor(c.Request().Context(), model.Actor{Name: name, Source: model.AuditSourceREST})
+		c.SetRequest(c.Request().WithContext(ctx))
 		return next(c)
 	}
 }
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bitopi/internal/repository/mysql"
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/yanun0323/pkg/logs"
+)
+
+/*
+Migrate executes the migrate subcommand.
+
+	bitopi migrate up [steps]
+	bitopi migrate down [steps]
+	bitopi migrate status
+*/
+func Migrate(args []string) error {
+	l := logs.Get(context.Background())
+
+	migrator, err := mysql.NewMigrator()
+	if err != nil {
+		return errors.Wrap(err, "create migrator")
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		steps, err = strconv.Atoi(args[1])
+		if err != nil {
+			return errors.Wrapf(err, "parse steps '%s'", args[1])
+		}
+	}
+
+	switch command {
+	case "up":
+		count, err := migrator.Up(steps)
+		if err != nil {
+			return err
+		}
+		l.Infof("%d migration(s) applied", count)
+	case "down":
+		count, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		l.Infof("%d migration(s) rolled back", count)
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			if s.Applied {
+				l.Infof("%03d_%s applied at %s", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+				continue
+			}
+			l.Infof("%03d_%s pending", s.Version, s.Name)
+		}
+	default:
+		return errors.Errorf("unknown migrate command '%s'", command)
+	}
+
+	return nil
+}
@@ -1,25 +1,46 @@
 package app
 
 import (
+	"bitopi/internal/logging"
+	"bitopi/internal/metrics"
 	"bitopi/internal/model"
+	"bitopi/internal/platform"
 	"bitopi/internal/service"
 	"bitopi/internal/util"
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/viper"
 	"github.com/yanun0323/pkg/logs"
 )
 
+const (
+	_defaultShutdownTimeout = 30 * time.Second
+)
+
 func Run() {
-	ctx := context.Background()
-	l := logs.Get(ctx)
-	svc, err := service.New(ctx)
+	/* ctx is done by SIGTERM or interrupt, it stops socket mode and starts the shutdown */
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	l := logging.New("")
+
+	/* secrets of the config never show up in logs, bot tokens are redacted by the bots */
+	logging.Redact(viper.GetString("mysql.password"), viper.GetString("admin.token"))
+	for _, t := range viper.GetStringMapString("admin.tokens") {
+		logging.Redact(t)
+	}
+
+	/* background jobs keep the context of the service, they are drained after ctx is done */
+	svc, err := service.New(context.Background())
 	if err != nil {
 		l.Fatalf("create service, err: %+v", err)
 		return
@@ -29,7 +50,7 @@ func Run() {
 	e.Logger.SetLevel(4)
 
 	rateLimiter := middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(20))
-	m := []echo.MiddlewareFunc{rateLimiter}
+	m := []echo.MiddlewareFunc{middleware.RequestID(), rateLimiter}
 	router := e.Group("", m...)
 	router.GET("/debug", service.DebugHandler)
 	router.GET("/healthz", func(c echo.Context) error {
@@ -39,16 +60,64 @@ func Run() {
 			Msg: "OK",
 		})
 	})
+	router.GET("/metrics", echo.WrapHandler(metrics.Handler()))
 
-	if err := setupRouters(router, svc); err != nil {
+	c := cron.New(cron.WithSeconds())
+	ready := service.NewReadiness(svc)
+	ready.SetScheduler(c)
+	router.GET("/readyz", ready.Handler)
+
+	if err := setupRouters(ctx, router, svc, c, ready); err != nil {
 		panic(fmt.Sprintf("setup routers failed, err: %+v", err))
 	}
 
-	e.Start(":8001")
+	setupAdminRouters(router.Group("/api/bot", tokenValidator), svc)
+
+	c.Start()
+	go func() {
+		if err := e.Start(":8001"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.Errorf("start server failed, err: %+v", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	shutdown(l, e, c, svc)
+}
+
+/*
+shutdown stops accepting requests, stops the cron scheduler and drains the background jobs,
+it gives up after 'shutdown.timeout' and the unfinished jobs are persisted for the next start.
+*/
+func shutdown(l logs.Logger, e *echo.Echo, c *cron.Cron, svc service.Service) {
+	timeout := viper.GetDuration("shutdown.timeout")
+	if timeout <= 0 {
+		timeout = _defaultShutdownTimeout
+	}
+
+	l.Infof("shutting down, timeout %s", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		l.Errorf("shutdown server failed, err: %+v", err)
+	}
+
+	select {
+	case <-c.Stop().Done():
+	case <-ctx.Done():
+		l.Warn("cron jobs are still running")
+	}
+
+	if err := svc.Shutdown(ctx); err != nil {
+		l.Errorf("drain background jobs failed, err: %+v", err)
+		return
+	}
+	l.Info("shutdown completed")
 }
 
-func setupRouters(router *echo.Group, svc service.Service) error {
-	if err := setBot(router, svc, service.SlackBotOption{
+func setupRouters(ctx context.Context, router *echo.Group, svc service.Service, c *cron.Cron, ready *service.Readiness) error {
+	if err := setBot(ctx, router, svc, c, ready, service.SlackBotOption{
 		Name:                      "pm",
 		Token:                     viper.GetString("pm.token"),
 		DefaultStartDate:          util.NewDate(2022, 11, 27),
@@ -58,13 +127,13 @@ func setupRouters(router *echo.Group, svc service.Service) error {
 			{UserID: "U02223HG26L", UserName: "Rafeni"},
 			{UserID: "U01THK4U2MD", UserName: "Momo"},
 		},
-		DefaultReplyMessage:     "請稍候片刻，本週 Support PM %s 將盡快為您服務 :smiling_face_with_3_hearts:",
-		DefaultHomeReplyMessage: "*本週 Support PM*\n%s",
+		DefaultReplyMessage:     "請稍候片刻，本週 Support PM {{.Duty}} 將盡快為您服務 :smiling_face_with_3_hearts:",
+		DefaultHomeReplyMessage: "*本週 Support PM*\n{{.Duty}}",
 	}); err != nil {
 		return err
 	}
 
-	if err := setBot(router, svc, service.SlackBotOption{
+	if err := setBot(ctx, router, svc, c, ready, service.SlackBotOption{
 		Name:                      "rails",
 		Token:                     viper.GetString("rails.token"),
 		DefaultStartDate:          util.NewDate(2022, 11, 6),
@@ -78,13 +147,13 @@ func setupRouters(router *echo.Group, svc service.Service) error {
 			{UserID: "U041HD3AQ3D", UserName: "Eric"},
 			{UserID: "U01GTQ8K52P", UserName: "Yuan"},
 		},
-		DefaultReplyMessage:     "請稍候片刻，本週茅房廁紙 %s 會盡快為您服務 :smiling_face_with_3_hearts:",
-		DefaultHomeReplyMessage: "*本週茅房廁紙*\n%s",
+		DefaultReplyMessage:     "請稍候片刻，本週茅房廁紙 {{.Duty}} 會盡快為您服務 :smiling_face_with_3_hearts:",
+		DefaultHomeReplyMessage: "*本週茅房廁紙*\n{{.Duty}}",
 	}); err != nil {
 		return err
 	}
 
-	if err := setBot(router, svc, service.SlackBotOption{
+	if err := setBot(ctx, router, svc, c, ready, service.SlackBotOption{
 		Name:                      "devops",
 		Token:                     viper.GetString("devops.token"),
 		DefaultStartDate:          util.NewDate(2022, 10, 23),
@@ -95,14 +164,27 @@ func setupRouters(router *echo.Group, svc service.Service) error {
 			{UserID: "U03RQKWLG8Z", UserName: "Tina"},
 			{UserID: "U01A7LEG1CZ", UserName: "Harlan"},
 		},
-		DefaultReplyMessage:     "請稍候片刻，本週猛哥/猛姐會盡快為您服務 :smiling_face_with_3_hearts:\nBito EX/Pro: %s\nMeta: %s",
-		DefaultHomeReplyMessage: "*本週猛哥/猛姐*\n*Bito EX/Pro:* %s\n*Meta:* %s",
+		DefaultReplyMessage:     "請稍候片刻，本週猛哥/猛姐會盡快為您服務 :smiling_face_with_3_hearts:\nBito EX/Pro: {{.Rotation \"Bito EX/Pro\"}}\nMeta: {{.Rotation \"Meta\"}}",
+		DefaultHomeReplyMessage: "*本週猛哥/猛姐*\n*Bito EX/Pro:* {{.Rotation \"Bito EX/Pro\"}}\n*Meta:* {{.Rotation \"Meta\"}}",
 		DefaultMultiMember:      true,
+		Rotations: []service.RotationOption{
+			{
+				/* keeps the roster of devops */
+				Title:    "Bito EX/Pro",
+				Keywords: []string{"ex", "pro"},
+			},
+			{
+				Name:             "meta",
+				Title:            "Meta",
+				Keywords:         []string{"meta"},
+				DefaultStartDate: util.NewDate(2022, 10, 30),
+			},
+		},
 	}); err != nil {
 		return err
 	}
 
-	if err := setBot(router, svc, service.SlackBotOption{
+	if err := setBot(ctx, router, svc, c, ready, service.SlackBotOption{
 		Name:                      "maid",
 		Token:                     viper.GetString("maid.token"),
 		DefaultStartDate:          util.NewDate(2022, 9, 25),
@@ -116,13 +198,13 @@ func setupRouters(router *echo.Group, svc service.Service) error {
 			{UserID: "U036V8WPXDY", UserName: "Victor"},
 			{UserID: "U03MWAJDBV3", UserName: "Luki"},
 		},
-		DefaultReplyMessage:     "請稍候片刻，本週女僕 %s 會盡快為您服務 :smiling_face_with_3_hearts:",
-		DefaultHomeReplyMessage: "*本週女僕*\n%s",
+		DefaultReplyMessage:     "請稍候片刻，本週女僕 {{.Duty}} 會盡快為您服務 :smiling_face_with_3_hearts:",
+		DefaultHomeReplyMessage: "*本週女僕*\n{{.Duty}}",
 	}); err != nil {
 		return err
 	}
 
-	if err := setBot(router, svc, service.SlackBotOption{
+	if err := setBot(ctx, router, svc, c, ready, service.SlackBotOption{
 		Name:                      "test",
 		Token:                     viper.GetString("test.token"),
 		DefaultStartDate:          util.NewDate(2023, 1, 22),
@@ -133,8 +215,8 @@ func setupRouters(router *echo.Group, svc service.Service) error {
 			{UserID: "U032TJB1PE1", UserName: "Yanun"},
 			{UserID: "U032TJB1PE1", UserName: "Yanun"},
 		},
-		DefaultReplyMessage:     "測試訊息，今日值日生 %s :smiling_face_with_3_hearts:",
-		DefaultHomeReplyMessage: "*今日值日生*\n%s",
+		DefaultReplyMessage:     "測試訊息，今日值日生 {{.Duty}} :smiling_face_with_3_hearts:",
+		DefaultHomeReplyMessage: "*今日值日生*\n{{.Duty}}",
 	}); err != nil {
 		return err
 	}
@@ -142,26 +224,68 @@ func setupRouters(router *echo.Group, svc service.Service) error {
 	return nil
 }
 
-func setBot(router *echo.Group, svc service.Service, opt service.SlackBotOption) error {
-	bot := service.NewBot(svc, opt)
+func setupAdminRouters(router *echo.Group, svc service.Service) {
+	router.GET("/:service/member", svc.GetMemberList)
+	router.PUT("/:service/member", svc.SetMemberList)
+	router.GET("/:service/roster", svc.ListRosterVersions)
+	router.GET("/:service/message", svc.GetMentionMessage)
+	router.PUT("/:service/message", svc.SetMentionMessage)
+	router.POST("/:service/message/preview", svc.PreviewMentionMessage)
+	router.GET("/:service/channel", svc.ListChannelConfigs)
+	router.PUT("/:service/channel/:channel", svc.SetChannelConfig)
+	router.DELETE("/:service/channel/:channel", svc.DeleteChannelConfig)
+	router.GET("/:service/audit", svc.ListAuditLogs)
+	router.GET("/:service/workspace", svc.ListWorkspaces)
+	router.GET("/:service/log", svc.GetLogLevel)
+	router.PUT("/:service/log", svc.SetLogLevel)
+	router.GET("/:service/webhook", svc.ListWebhooks)
+	router.POST("/:service/webhook", svc.CreateWebhook)
+	router.PUT("/:service/webhook/:id", svc.UpdateWebhook)
+	router.DELETE("/:service/webhook/:id", svc.DeleteWebhook)
+	router.GET("/:service/webhook/:id/delivery", svc.ListWebhookDeliveries)
+	router.POST("/:service/webhook/delivery/:id/redeliver", svc.RedeliverWebhook)
+}
+
+func setBot(ctx context.Context, router *echo.Group, svc service.Service, c *cron.Cron, ready *service.Readiness, opt service.SlackBotOption) error {
+	bot, err := service.NewBot(svc, opt)
+	if err != nil {
+		return err
+	}
+
+	if err := bot.ResumePendingJobs(); err != nil {
+		logs.Get(ctx).Errorf("resume pending jobs of bot %s failed, err: %+v", bot.Name, err)
+	}
+	bot.StartOutbox(ctx)
+	ready.AddBot(bot)
+	metrics.AddDutySource(bot.Duties)
 	action := service.NewInteraction(bot)
 
 	router.POST(fmt.Sprintf("/%s", bot.Name), bot.Handler)
 	router.POST(fmt.Sprintf("/%s/action", bot.Name), action.Handler)
+	router.GET(fmt.Sprintf("/%s/install", bot.Name), bot.InstallHandler)
+	router.GET(fmt.Sprintf("/%s/oauth/callback", bot.Name), bot.OAuthRedirectHandler)
+	if bot.Platform == platform.Mattermost {
+		router.POST(fmt.Sprintf("/%s/mattermost", bot.Name), bot.MattermostHandler)
+		router.POST(fmt.Sprintf("/%s/mattermost/action", bot.Name), bot.MattermostActionHandler)
+	}
 
-	if err := setupCron(bot, service.WeeklyNotifierOpt{}); err != nil {
+	if len(bot.AppToken) != 0 {
+		go func() {
+			if err := bot.RunSocketMode(ctx); err != nil {
+				logs.Get(ctx).Errorf("socket mode of bot %s stopped, err: %+v", bot.Name, err)
+			}
+		}()
+	}
+
+	if err := setupCron(c, bot, service.WeeklyNotifierOpt{}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func setupCron(bot service.SlackBot, opt service.WeeklyNotifierOpt) error {
+func setupCron(c *cron.Cron, bot service.SlackBot, opt service.WeeklyNotifierOpt) error {
 	job := service.NewWeeklyJob(bot, opt)
-	c := cron.New(cron.WithSeconds())
 	_, err := c.AddJob("TZ=Asia/Taipei 0 0 9 ? * 0", job)
-	if err != nil {
-		return err
-	}
-	return nil
+	return err
 }
@@ -8,6 +8,10 @@ import (
 
 type Repository interface {
 	Tx(ctx context.Context, fn func(context.Context) error) error
+	/* Ping checks the connection to the database */
+	Ping(ctx context.Context) error
+	/* SchemaVersion returns the applied and the latest known migration versions */
+	SchemaVersion(ctx context.Context) (applied int, latest int, err error)
 
 	GetMember(ctx context.Context, service string, userID string) (model.Member, error)
 	UpdateMember(ctx context.Context, member model.Member) error
@@ -25,12 +29,25 @@ type Repository interface {
 	GetStartDate(ctx context.Context, service string) (time.Time, error)
 	UpdateStartDate(txCtx context.Context, service string, t time.Time) error
 
+	SnapshotRoster(txCtx context.Context, service string, effectiveFrom time.Time) (model.RosterVersion, error)
+	/* GetRosterVersion returns an empty version when there's no version in effect at the moment */
+	GetRosterVersion(ctx context.Context, service string, at time.Time) (model.RosterVersion, error)
+	ListRosterVersions(ctx context.Context, service string) ([]model.RosterVersion, error)
+
 	GetDutyDuration(ctx context.Context, service string) (time.Duration, error)
 	GetDutyMemberCountPerTime(ctx context.Context, service string) (int, error)
 
 	CountMentionRecord(ctx context.Context, service string) (int64, error)
 	GetMentionRecord(ctx context.Context, id uint64) (model.MentionRecord, error)
-	FindOrCreateMentionRecord(txCtx context.Context, service, channel, timestamp string) (id uint64, found bool, err error)
+	FindOrCreateMentionRecord(txCtx context.Context, service, channel, timestamp string, dutyMembers []string) (id uint64, found bool, err error)
+	/* FindMentionRecord returns gorm.ErrRecordNotFound when there's no record of the thread */
+	FindMentionRecord(ctx context.Context, service, channel, timestamp string) (model.MentionRecord, error)
+	UpdateMentionRecordStatus(ctx context.Context, id uint64, status string) error
+	ListMentionEvents(ctx context.Context, mentionRecordID uint64) ([]model.MentionEvent, error)
+	/* AddMentionEvent returns found as true when the event was already added */
+	AddMentionEvent(txCtx context.Context, event model.MentionEvent) (found bool, err error)
+	CreateMentionDirectMessage(ctx context.Context, dm model.MentionDirectMessage) error
+	ListMentionDirectMessages(ctx context.Context, mentionRecordID uint64) ([]model.MentionDirectMessage, error)
 
 	GetReplyMessage(ctx context.Context, service string) (model.BotMessage, error)
 	SetReplyMessage(txCtx context.Context, msg model.BotMessage) error
@@ -38,4 +55,50 @@ type Repository interface {
 	GetSubscriber(ctx context.Context) ([]model.Subscriber, error)
 	SetSubscriber(ctx context.Context, sub model.Subscriber) error
 	DeleteSubscriber(ctx context.Context, sub model.Subscriber) error
+
+	ListChannelConfigs(ctx context.Context, service string) ([]model.ChannelConfig, error)
+	SetChannelConfig(txCtx context.Context, cfg model.ChannelConfig) error
+	DeleteChannelConfig(txCtx context.Context, service, channel string) error
+
+	ListAuditLogs(ctx context.Context, service string, limit int) ([]model.AuditLog, error)
+
+	/* workspaces aren't scoped by the team in context, GetWorkspace returns gorm.ErrRecordNotFound when the bot isn't installed */
+	GetWorkspace(ctx context.Context, service, teamID string) (model.Workspace, error)
+	ListWorkspaces(ctx context.Context, service string) ([]model.Workspace, error)
+	/* SaveWorkspace installs the bot in the workspace, or replaces the token when it's reinstalled */
+	SaveWorkspace(txCtx context.Context, ws model.Workspace) error
+
+	ListWebhooks(ctx context.Context, service string) ([]model.Webhook, error)
+	GetWebhook(ctx context.Context, service string, id uint64) (model.Webhook, error)
+	/* SaveWebhook creates the webhook when the ID is zero, or replaces the webhook of the ID */
+	SaveWebhook(txCtx context.Context, hook model.Webhook) (uint64, error)
+	DeleteWebhook(txCtx context.Context, service string, id uint64) error
+
+	CreateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) (uint64, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+	/* ListDueWebhookDeliveries returns the pending deliveries of the service in every workspace to attempt at the moment, the oldest first */
+	ListDueWebhookDeliveries(ctx context.Context, service string, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	/* ClaimWebhookDelivery leases the delivery until the time, it returns false when another worker has claimed it */
+	ClaimWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery, until time.Time) (bool, error)
+	GetWebhookDelivery(ctx context.Context, service string, id uint64) (model.WebhookDelivery, error)
+	/* ListWebhookDeliveries returns the latest deliveries first, every delivery when limit isn't positive */
+	ListWebhookDeliveries(ctx context.Context, service string, webhookID uint64, limit int) ([]model.WebhookDelivery, error)
+
+	/* pending jobs aren't scoped by the team in context, every job carries its team */
+	SavePendingJobs(ctx context.Context, jobs []model.PendingJob) error
+	/* TakePendingJobs removes and returns the pending jobs of the service */
+	TakePendingJobs(txCtx context.Context, service string) ([]model.PendingJob, error)
+
+	/* outbox entries aren't scoped by the team in context either, CreateOutboxEntries skips entries whose key exists */
+	CreateOutboxEntries(txCtx context.Context, entries []model.OutboxEntry) error
+	/* ListDueOutboxEntries returns the pending entries of the service to run at the moment, the oldest first */
+	ListDueOutboxEntries(ctx context.Context, service string, now time.Time, limit int) ([]model.OutboxEntry, error)
+	/* ClaimOutboxEntry leases the entry until the time, it returns false when another worker has claimed it */
+	ClaimOutboxEntry(ctx context.Context, entry model.OutboxEntry, until time.Time) (bool, error)
+	UpdateOutboxEntry(ctx context.Context, entry model.OutboxEntry) error
+	/* GetOutboxEntry returns gorm.ErrRecordNotFound when there's no entry of the key */
+	GetOutboxEntry(ctx context.Context, key string) (model.OutboxEntry, error)
+	/* ListOutboxEntries returns the entries of the mention record in the order they were created */
+	ListOutboxEntries(ctx context.Context, mentionRecordID uint64) ([]model.OutboxEntry, error)
+	OutboxBacklog(ctx context.Context, service string) (model.OutboxBacklog, error)
 }
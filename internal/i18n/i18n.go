@@ -0,0 +1,61 @@
+/*
+Package i18n is the message catalog of every bot-facing string.
+
+	i18n.T(i18n.English, i18n.MsgMentionedTimes, 12) // "Mentioned 12 times"
+
+Missing messages fall back to the default locale, and to the key itself at last.
+*/
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Locale string
+
+const (
+	TraditionalChinese Locale = "zh-TW"
+	English            Locale = "en"
+
+	DefaultLocale = TraditionalChinese
+)
+
+var _bundles = map[Locale]map[string]string{
+	TraditionalChinese: _zhTW,
+	English:            _en,
+}
+
+// T translates the message of key, args are formatted by fmt.Sprintf.
+func T(locale Locale, key string, args ...interface{}) string {
+	msg, ok := _bundles[locale][key]
+	if !ok {
+		msg, ok = _bundles[DefaultLocale][key]
+	}
+	if !ok {
+		msg = key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+/*
+Parse converts locales like Slack's 'zh-TW' and 'en-US' to a supported locale,
+ok is false when the locale isn't supported.
+*/
+func Parse(s string) (Locale, bool) {
+	s = strings.ToLower(strings.ReplaceAll(s, "_", "-"))
+	switch {
+	case len(s) == 0:
+		return "", false
+	case strings.HasPrefix(s, "zh"):
+		return TraditionalChinese, true
+	case strings.HasPrefix(s, "en"):
+		return English, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,159 @@
+package i18n
+
+const (
+	MsgNewMention               = "dm.new_mention"
+	MsgResentFrom               = "dm.resent_from"
+	MsgSourceDeleted            = "dm.source_deleted"
+	MsgFollowUp                 = "dm.follow_up"
+	MsgButtonResend             = "dm.button.resend"
+	MsgButtonDelete             = "dm.button.delete"
+	MsgButtonDeleteReply        = "dm.button.delete_and_reply"
+	MsgDoneReply                = "reply.done"
+	MsgResendTitle              = "resend.title"
+	MsgResendSubmit             = "resend.submit"
+	MsgResendUsers              = "resend.users"
+	MsgResendUsersHolder        = "resend.users.placeholder"
+	MsgResendHint               = "resend.hint"
+	MsgSettingTitle             = "setting.title"
+	MsgSettingSubmit            = "setting.submit"
+	MsgSettingRoster            = "setting.roster"
+	MsgSettingMembers           = "setting.members"
+	MsgSettingUsersHolder       = "setting.members.placeholder"
+	MsgSettingStartDate         = "setting.start_date"
+	MsgSettingDateHolder        = "setting.start_date.placeholder"
+	MsgSettingMessage           = "setting.message"
+	MsgSettingReply             = "setting.reply"
+	MsgSettingNotAdmin          = "setting.not_admin"
+	MsgSettingInvalidDate       = "setting.start_date.invalid"
+	MsgSettingInvalidReply      = "setting.reply.invalid"
+	MsgSettingSaveFailed        = "setting.save_failed"
+	MsgCancel                   = "common.cancel"
+	MsgHomeRoster               = "home.roster"
+	MsgHomeRotationRoster       = "home.roster.rotation"
+	MsgHomeShift                = "home.shift"
+	MsgMentionedTimes           = "home.mentioned_times"
+	MsgHomeStatus               = "home.status"
+	MsgHomeSetting              = "home.button.setting"
+	MsgHomeClear                = "home.button.clear"
+	MsgHomeClearConfirm         = "home.clear.confirm"
+	MsgHomeClearSubmit          = "home.clear.submit"
+	MsgHomeClearResolved        = "home.button.clear_resolved"
+	MsgHomeClearResolvedConfirm = "home.clear_resolved.confirm"
+	MsgHomeClearOlder           = "home.button.clear_older"
+	MsgHomeClearOlderConfirm    = "home.clear_older.confirm"
+	MsgClearSummary             = "clear.summary"
+	MsgClearFailed              = "clear.failed"
+	MsgRecentChanges            = "home.recent_changes"
+	MsgRecentChangesError       = "home.recent_changes.error"
+	MsgRecentChangesEmpty       = "home.recent_changes.empty"
+	MsgHomeHistory              = "home.history"
+	MsgSlashCommandUnsupported  = "command.unsupported"
+)
+
+var _zhTW = map[string]string{
+	MsgNewMention:               "*<%s|新的提及> 來自 <@%s> <#%s>*",
+	MsgResentFrom:               " _轉傳自 <@%s>_",
+	MsgSourceDeleted:            "~%s~ _原訊息已被刪除_",
+	MsgFollowUp:                 "*<%s|追加訊息> 來自 <@%s>*",
+	MsgButtonResend:             "轉傳給...",
+	MsgButtonDelete:             "刪除",
+	MsgButtonDeleteReply:        "刪除並回覆",
+	MsgDoneReply:                "已處理，有需要再 Tag 我 ☺️",
+	MsgResendTitle:              "轉傳給...",
+	MsgResendSubmit:             "轉傳",
+	MsgResendUsers:              "選擇要轉傳通知的使用者(可複選)",
+	MsgResendUsersHolder:        "選擇人員",
+	MsgResendHint:               "＃通知將會透過機器人轉傳",
+	MsgSettingTitle:             "更改機器人設定",
+	MsgSettingSubmit:            "確認",
+	MsgSettingRoster:            "輪值設定",
+	MsgSettingMembers:           "輪值人員",
+	MsgSettingUsersHolder:       "選擇人員",
+	MsgSettingStartDate:         "開始輪值日期",
+	MsgSettingDateHolder:        "選擇日期",
+	MsgSettingMessage:           "訊息設定",
+	MsgSettingReply:             "機器人回覆",
+	MsgSettingNotAdmin:          "只有管理員可以更改設定",
+	MsgSettingInvalidDate:       "日期格式錯誤",
+	MsgSettingInvalidReply:      "回覆範本錯誤：%s",
+	MsgSettingSaveFailed:        "儲存設定失敗，請稍後再試",
+	MsgCancel:                   "取消",
+	MsgHomeRoster:               "*輪值人員順序*",
+	MsgHomeRotationRoster:       "*輪值人員順序 - %s*",
+	MsgHomeShift:                "%s每次 %d 人輪值，為期 %d 週",
+	MsgMentionedTimes:           "此機器人已被提及 %d 次",
+	MsgHomeStatus:               "值班中：%s",
+	MsgHomeSetting:              "更改設定",
+	MsgHomeClear:                "刪除所有通知",
+	MsgHomeClearConfirm:         "是否刪除此機器人傳送給您的所有通知訊息？",
+	MsgHomeClearSubmit:          "清除",
+	MsgHomeClearResolved:        "刪除已處理的通知",
+	MsgHomeClearResolvedConfirm: "是否刪除此機器人傳送給您、已處理或原訊息已刪除的通知訊息？",
+	MsgHomeClearOlder:           "刪除 %d 天前的通知",
+	MsgHomeClearOlderConfirm:    "是否刪除此機器人在 %d 天前傳送給您的通知訊息？",
+	MsgClearSummary:             "已刪除 %d 則通知",
+	MsgClearFailed:              "，%d 則刪除失敗",
+	MsgRecentChanges:            "*最近變更*",
+	MsgRecentChangesError:       "無法取得變更紀錄",
+	MsgRecentChangesEmpty:       "尚無變更紀錄",
+	MsgSlashCommandUnsupported:  "%s 不支援斜線指令，請在頻道中提及我",
+	MsgHomeHistory: `*更新歷史*
+- 2023.5 新增調整值班人數及時間、新增刪除並回覆按鈕
+- 2023.3 修改私訊的提及連結到對話串
+- 2023.1 新增私訊通知功能/新增首頁按鈕
+`,
+}
+
+var _en = map[string]string{
+	MsgNewMention:               "*<%s|New mention> from <@%s> in <#%s>*",
+	MsgResentFrom:               " _forwarded by <@%s>_",
+	MsgSourceDeleted:            "~%s~ _the original message was deleted_",
+	MsgFollowUp:                 "*<%s|Follow-up> from <@%s>*",
+	MsgButtonResend:             "Forward to...",
+	MsgButtonDelete:             "Delete",
+	MsgButtonDeleteReply:        "Delete and reply",
+	MsgDoneReply:                "Done, tag me again if you need anything ☺️",
+	MsgResendTitle:              "Forward to...",
+	MsgResendSubmit:             "Forward",
+	MsgResendUsers:              "Select users to forward the notification to",
+	MsgResendUsersHolder:        "Select users",
+	MsgResendHint:               "# The notification is forwarded by the bot",
+	MsgSettingTitle:             "Bot settings",
+	MsgSettingSubmit:            "Confirm",
+	MsgSettingRoster:            "Rotation",
+	MsgSettingMembers:           "Members",
+	MsgSettingUsersHolder:       "Select users",
+	MsgSettingStartDate:         "Start date",
+	MsgSettingDateHolder:        "Select a date",
+	MsgSettingMessage:           "Messages",
+	MsgSettingReply:             "Reply message",
+	MsgSettingNotAdmin:          "Only admins can change the settings",
+	MsgSettingInvalidDate:       "Invalid date",
+	MsgSettingInvalidReply:      "Invalid reply template: %s",
+	MsgSettingSaveFailed:        "Failed to save the settings, please try again later",
+	MsgCancel:                   "Cancel",
+	MsgHomeRoster:               "*Rotation order*",
+	MsgHomeRotationRoster:       "*Rotation order - %s*",
+	MsgHomeShift:                "%s%d member(s) per shift, %d week(s) each",
+	MsgMentionedTimes:           "Mentioned %d times",
+	MsgHomeStatus:               "On duty: %s",
+	MsgHomeSetting:              "Settings",
+	MsgHomeClear:                "Delete all notifications",
+	MsgHomeClearConfirm:         "Delete all notifications sent to you by this bot?",
+	MsgHomeClearSubmit:          "Delete",
+	MsgHomeClearResolved:        "Delete resolved notifications",
+	MsgHomeClearResolvedConfirm: "Delete notifications sent to you by this bot which are resolved or whose original message was deleted?",
+	MsgHomeClearOlder:           "Delete notifications older than %d days",
+	MsgHomeClearOlderConfirm:    "Delete notifications sent to you by this bot more than %d days ago?",
+	MsgClearSummary:             "Deleted %d notification(s)",
+	MsgClearFailed:              ", failed to delete %d",
+	MsgRecentChanges:            "*Recent changes*",
+	MsgRecentChangesError:       "Failed to get changes",
+	MsgRecentChangesEmpty:       "No changes yet",
+	MsgSlashCommandUnsupported:  "%s doesn't support slash commands, mention me in a channel instead.",
+	MsgHomeHistory: `*Release notes*
+- 2023.5 Adjustable shift size and duration, delete and reply button
+- 2023.3 Mention links in direct messages point to the thread
+- 2023.1 Direct message notifications and home tab buttons
+`,
+}
@@ -0,0 +1,331 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yanun0323/pkg/logs"
+)
+
+/*
+Fields of request-scoped loggers, see logs.Logger.WithFields.
+*/
+const (
+	FieldBot       = "bot"
+	FieldTeam      = "team_id"
+	FieldRequestID = "request_id"
+	FieldEventID   = "event_id"
+	FieldChannel   = "channel"
+	FieldUser      = "user"
+	FieldMentionID = "mention_id"
+	FieldAction    = "action"
+)
+
+const (
+	// Redacted takes the place of secrets in log lines.
+	Redacted = "[REDACTED]"
+	/* secrets shorter than this are too likely to appear in plain text to be redacted */
+	_minSecretLength = 8
+)
+
+var (
+	/* slack tokens, e.g. xoxb-, xoxp-, xoxe-, xapp-, and authorization headers */
+	_tokenRe = regexp.MustCompile(`\b(?:xox[a-z]|xapp)-[A-Za-z0-9-]+|(?i:bearer) [A-Za-z0-9._~+/=-]+`)
+
+	_order = map[string]int{
+		"trace":   0,
+		"debug":   1,
+		"info":    2,
+		"warning": 3,
+		"error":   4,
+		"fatal":   5,
+		"panic":   6,
+	}
+
+	_levels = &registry{
+		defaultLevel: logs.LevelInfo,
+		levels:       map[string]logs.Level{},
+		secrets:      map[string]bool{},
+	}
+)
+
+type registry struct {
+	mu           sync.RWMutex
+	defaultLevel logs.Level
+	levels       map[string]logs.Level
+	secrets      map[string]bool
+}
+
+/*
+ConfigLevel converts the 'log.level' of the config, 0 info, 1 trace, 2 debug, 3 warn, 4 error and 5 fatal.
+*/
+func ConfigLevel(n uint16) logs.Level {
+	switch n {
+	case 1:
+		return logs.LevelTrace
+	case 2:
+		return logs.LevelDebug
+	case 3:
+		return logs.LevelWarn
+	case 4:
+		return logs.LevelError
+	case 5:
+		return logs.LevelFatal
+	default:
+		return logs.LevelInfo
+	}
+}
+
+// ParseLevel parses the name of the level, e.g. 'debug' or 'warn'.
+func ParseLevel(s string) (logs.Level, error) {
+	level := logs.NewLevel(s)
+	if level == logs.LevelPanic && s != string(logs.LevelPanic) {
+		return "", errors.Errorf("invalid log level '%s'", s)
+	}
+	return level, nil
+}
+
+// SetDefaultLevel sets the level of loggers whose level isn't set.
+func SetDefaultLevel(level logs.Level) {
+	_levels.mu.Lock()
+	defer _levels.mu.Unlock()
+	_levels.defaultLevel = level
+}
+
+// SetLevel sets the level of the loggers of the name at runtime, empty level resets it to the default level.
+func SetLevel(name string, level logs.Level) {
+	_levels.mu.Lock()
+	defer _levels.mu.Unlock()
+	if len(level) == 0 {
+		delete(_levels.levels, name)
+		return
+	}
+	_levels.levels[name] = level
+}
+
+// Level returns the level of the loggers of the name.
+func Level(name string) logs.Level {
+	_levels.mu.RLock()
+	defer _levels.mu.RUnlock()
+	if level, ok := _levels.levels[name]; ok {
+		return level
+	}
+	return _levels.defaultLevel
+}
+
+/*
+Redact hides the secret in every log line, Slack tokens and bearer tokens are always hidden.
+*/
+func Redact(secrets ...string) {
+	_levels.mu.Lock()
+	defer _levels.mu.Unlock()
+	for _, s := range secrets {
+		if len(s) >= _minSecretLength {
+			_levels.secrets[s] = true
+		}
+	}
+}
+
+/*
+New creates the JSON logger of the name, e.g. the bot name, its level can be changed by SetLevel.
+
+	l := logging.New("maid")
+	l.WithFields(map[string]interface{}{logging.FieldEventID: id}).Infof("receive event")
+*/
+func New(name string) logs.Logger {
+	var l logs.Logger = &logger{Logger: logs.New(logs.LevelTrace, writer{}), name: name}
+	if len(name) == 0 {
+		return l
+	}
+	return l.WithField(FieldBot, name)
+}
+
+/*
+logger drops lines below the level of the loggers of its name before they are formatted,
+the underlying logger writes every level so the level can be changed at runtime.
+Fatal and panic lines are always written.
+*/
+type logger struct {
+	logs.Logger
+	name string
+}
+
+func (l *logger) enabled(level logs.Level) bool {
+	return _order[levelName(level)] >= _order[levelName(Level(l.name))]
+}
+
+func (l *logger) wrap(inner logs.Logger) logs.Logger {
+	return &logger{Logger: inner, name: l.name}
+}
+
+func (l *logger) Trace(args ...interface{}) {
+	if l.enabled(logs.LevelTrace) {
+		l.Logger.Trace(args...)
+	}
+}
+
+func (l *logger) Tracef(format string, args ...interface{}) {
+	if l.enabled(logs.LevelTrace) {
+		l.Logger.Tracef(format, args...)
+	}
+}
+
+func (l *logger) Debug(args ...interface{}) {
+	if l.enabled(logs.LevelDebug) {
+		l.Logger.Debug(args...)
+	}
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	if l.enabled(logs.LevelDebug) {
+		l.Logger.Debugf(format, args...)
+	}
+}
+
+func (l *logger) Info(args ...interface{}) {
+	if l.enabled(logs.LevelInfo) {
+		l.Logger.Info(args...)
+	}
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	if l.enabled(logs.LevelInfo) {
+		l.Logger.Infof(format, args...)
+	}
+}
+
+func (l *logger) Print(args ...interface{}) {
+	if l.enabled(logs.LevelInfo) {
+		l.Logger.Print(args...)
+	}
+}
+
+func (l *logger) Printf(format string, args ...interface{}) {
+	if l.enabled(logs.LevelInfo) {
+		l.Logger.Printf(format, args...)
+	}
+}
+
+func (l *logger) Warn(args ...interface{}) {
+	if l.enabled(logs.LevelWarn) {
+		l.Logger.Warn(args...)
+	}
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	if l.enabled(logs.LevelWarn) {
+		l.Logger.Warnf(format, args...)
+	}
+}
+
+func (l *logger) Warning(args ...interface{}) {
+	if l.enabled(logs.LevelWarn) {
+		l.Logger.Warning(args...)
+	}
+}
+
+func (l *logger) Warningf(format string, args ...interface{}) {
+	if l.enabled(logs.LevelWarn) {
+		l.Logger.Warningf(format, args...)
+	}
+}
+
+func (l *logger) Error(args ...interface{}) {
+	if l.enabled(logs.LevelError) {
+		l.Logger.Error(args...)
+	}
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	if l.enabled(logs.LevelError) {
+		l.Logger.Errorf(format, args...)
+	}
+}
+
+func (l *logger) Log(level logs.Level, args ...interface{}) {
+	if l.enabled(level) {
+		l.Logger.Log(level, args...)
+	}
+}
+
+func (l *logger) Logf(level logs.Level, format string, args ...interface{}) {
+	if l.enabled(level) {
+		l.Logger.Logf(level, format, args...)
+	}
+}
+
+func (l *logger) Copy() logs.Logger {
+	return l.wrap(l.Logger.Copy())
+}
+
+func (l *logger) WithContext(ctx context.Context) logs.Logger {
+	return l.wrap(l.Logger.WithContext(ctx))
+}
+
+func (l *logger) WithError(err error) logs.Logger {
+	return l.wrap(l.Logger.WithError(err))
+}
+
+func (l *logger) WithField(key string, value interface{}) logs.Logger {
+	return l.wrap(l.Logger.WithField(key, value))
+}
+
+func (l *logger) WithFields(fields map[string]interface{}) logs.Logger {
+	return l.wrap(l.Logger.WithFields(fields))
+}
+
+func (l *logger) WithTime(t time.Time) logs.Logger {
+	return l.wrap(l.Logger.WithTime(t))
+}
+
+/*
+Attach attaches the logger into the context, logs.Get of the context returns the underlying logger, which isn't gated by the level.
+*/
+func (l *logger) Attach(ctx context.Context) (context.Context, logs.Logger) {
+	ctx, inner := l.Logger.Attach(ctx)
+	return ctx, l.wrap(inner)
+}
+
+/*
+writer redacts secrets of the lines, which are JSON written by logs.
+*/
+type writer struct{}
+
+func (writer) Write(p []byte) (int, error) {
+	if _, err := os.Stdout.Write(redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func redact(p []byte) []byte {
+	p = _tokenRe.ReplaceAll(p, []byte(Redacted))
+
+	_levels.mu.RLock()
+	secrets := make([]string, 0, len(_levels.secrets))
+	for s := range _levels.secrets {
+		secrets = append(secrets, s)
+	}
+	_levels.mu.RUnlock()
+
+	/* longer secrets first, in case one contains another */
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, s := range secrets {
+		p = bytes.ReplaceAll(p, []byte(s), []byte(Redacted))
+	}
+	return p
+}
+
+// levelName returns the name of the level in log lines, which is 'warning' for logs.LevelWarn.
+func levelName(level logs.Level) string {
+	if level == logs.LevelWarn {
+		return "warning"
+	}
+	return string(level)
+}
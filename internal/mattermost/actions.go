@@ -0,0 +1,41 @@
+package mattermost
+
+const (
+	DialogSubmissionType = "dialog_submission"
+)
+
+/*
+ActionRequest is posted to the integration url when a button of a post is clicked,
+Context is the context of the integration of the button.
+
+https://developers.mattermost.com/integrate/plugins/interactive-messages/
+*/
+type ActionRequest struct {
+	UserID    string                 `json:"user_id"`
+	ChannelID string                 `json:"channel_id"`
+	TeamID    string                 `json:"team_id"`
+	PostID    string                 `json:"post_id"`
+	TriggerID string                 `json:"trigger_id"`
+	Context   map[string]interface{} `json:"context"`
+}
+
+// ActionResponse shows the ephemeral text to the user, empty text shows nothing.
+type ActionResponse struct {
+	EphemeralText string `json:"ephemeral_text,omitempty"`
+}
+
+/*
+DialogSubmission is posted to the url of the dialog, Submission is the value of every element by its name.
+
+https://developers.mattermost.com/integrate/plugins/interactive-dialogs/
+*/
+type DialogSubmission struct {
+	Type       string                 `json:"type"`
+	CallbackID string                 `json:"callback_id"`
+	State      string                 `json:"state"`
+	UserID     string                 `json:"user_id"`
+	ChannelID  string                 `json:"channel_id"`
+	TeamID     string                 `json:"team_id"`
+	Submission map[string]interface{} `json:"submission"`
+	Cancelled  bool                   `json:"cancelled"`
+}
@@ -0,0 +1,31 @@
+package mattermost
+
+import (
+	"context"
+	"net/http"
+)
+
+type Channel struct {
+	ID          string `json:"id"`
+	TeamID      string `json:"team_id"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// DirectChannel returns the direct channel between the two users, it's created when it doesn't exist.
+func (c *Client) DirectChannel(ctx context.Context, userID, otherUserID string) (Channel, error) {
+	res := Channel{}
+	if err := c.do(ctx, http.MethodPost, "/channels/direct", []string{userID, otherUserID}, &res); err != nil {
+		return Channel{}, err
+	}
+	return res, nil
+}
+
+func (c *Client) GetChannel(ctx context.Context, channelID string) (Channel, error) {
+	res := Channel{}
+	if err := c.do(ctx, http.MethodGet, "/channels/"+channelID, nil, &res); err != nil {
+		return Channel{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,146 @@
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	_apiPrefix = "/api/v4"
+)
+
+/*
+Client calls Mattermost REST API v4 with the access token of a bot account.
+
+	client := mattermost.New("https://chat.example.com", token)
+	post, err := client.CreatePost(ctx, mattermost.Post{ChannelID: "abc", Message: "hi"})
+*/
+type Client struct {
+	token      string
+	url        string
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// New creates the client of the server, url is the site url without '/api/v4', e.g. 'https://chat.example.com'.
+func New(url, token string, opts ...Option) *Client {
+	c := &Client{
+		token:      token,
+		url:        strings.TrimSuffix(url, "/"),
+		httpClient: &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// URL returns the site url of the server.
+func (c *Client) URL() string {
+	return c.url
+}
+
+/*
+Error is the error returned by Mattermost with unexpected http status.
+*/
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("mattermost %s %s: %d", e.Method, e.Path, e.StatusCode)
+	if len(e.ID) != 0 {
+		s += " " + e.ID
+	}
+	if len(e.Message) != 0 {
+		s += " (" + e.Message + ")"
+	}
+	return s
+}
+
+// IsNotFound reports whether err is a mattermost error of missing resource.
+func IsNotFound(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.StatusCode == http.StatusNotFound
+}
+
+/*
+do calls the api with a json body, res is skipped when it's nil.
+*/
+func (c *Client) do(ctx context.Context, method, path string, req, res interface{}) error {
+	if len(c.url) == 0 {
+		return errors.New("empty url")
+	}
+
+	if len(c.token) == 0 {
+		return errors.New("empty token")
+	}
+
+	var body io.Reader
+	if req != nil {
+		buf, err := json.Marshal(req)
+		if err != nil {
+			return errors.Wrapf(err, "marshal %s %s request", method, path)
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, c.url+_apiPrefix+path, body)
+	if err != nil {
+		return errors.Wrapf(err, "create %s %s request", method, path)
+	}
+	r.Header.Set("Authorization", "Bearer "+c.token)
+	if req != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(r)
+	if err != nil {
+		return errors.Wrapf(err, "call %s %s", method, path)
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "read %s %s response", method, path)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		e := &Error{}
+		_ = json.Unmarshal(buf, e)
+		e.Method, e.Path, e.StatusCode = method, path, resp.StatusCode
+		return e
+	}
+
+	if res == nil || len(buf) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(buf, res); err != nil {
+		return errors.Wrapf(err, "unmarshal %s %s response", method, path)
+	}
+	return nil
+}
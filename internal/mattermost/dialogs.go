@@ -0,0 +1,55 @@
+package mattermost
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	ElementText     = "text"
+	ElementTextarea = "textarea"
+	ElementSelect   = "select"
+
+	DataSourceUsers = "users"
+)
+
+/*
+Dialog is the interactive dialog, the submission is posted to the url of the request.
+
+https://developers.mattermost.com/integrate/plugins/interactive-dialogs/
+*/
+type Dialog struct {
+	CallbackID       string          `json:"callback_id,omitempty"`
+	Title            string          `json:"title"`
+	IntroductionText string          `json:"introduction_text,omitempty"`
+	SubmitLabel      string          `json:"submit_label,omitempty"`
+	State            string          `json:"state,omitempty"`
+	Elements         []DialogElement `json:"elements,omitempty"`
+}
+
+type DialogElement struct {
+	DisplayName string `json:"display_name"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	SubType     string `json:"subtype,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"`
+	HelpText    string `json:"help_text,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+	DataSource  string `json:"data_source,omitempty"`
+}
+
+type openDialogRequest struct {
+	TriggerID string `json:"trigger_id"`
+	URL       string `json:"url"`
+	Dialog    Dialog `json:"dialog"`
+}
+
+// OpenDialog opens the dialog with the trigger ID of an interaction, the trigger ID expires in a few seconds.
+func (c *Client) OpenDialog(ctx context.Context, triggerID, url string, dialog Dialog) error {
+	return c.do(ctx, http.MethodPost, "/actions/dialogs/open", openDialogRequest{
+		TriggerID: triggerID,
+		URL:       url,
+		Dialog:    dialog,
+	}, nil)
+}
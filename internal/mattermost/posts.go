@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"context"
+	"net/http"
+)
+
+type Post struct {
+	ID        string                 `json:"id,omitempty"`
+	ChannelID string                 `json:"channel_id"`
+	RootID    string                 `json:"root_id,omitempty"`
+	UserID    string                 `json:"user_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+	CreateAt  int64                  `json:"create_at,omitempty"`
+}
+
+// Attachment is the message attachment of posts, actions are only shown when the integration url is set.
+type Attachment struct {
+	Fallback string   `json:"fallback,omitempty"`
+	Text     string   `json:"text,omitempty"`
+	Footer   string   `json:"footer,omitempty"`
+	Actions  []Action `json:"actions,omitempty"`
+}
+
+type Action struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Style       string      `json:"style,omitempty"`
+	Integration Integration `json:"integration"`
+}
+
+type Integration struct {
+	URL     string                 `json:"url"`
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+func (c *Client) CreatePost(ctx context.Context, post Post) (Post, error) {
+	res := Post{}
+	if err := c.do(ctx, http.MethodPost, "/posts", post, &res); err != nil {
+		return Post{}, err
+	}
+	return res, nil
+}
+
+// UpdatePost replaces the message and props of the post.
+func (c *Client) UpdatePost(ctx context.Context, post Post) (Post, error) {
+	res := Post{}
+	if err := c.do(ctx, http.MethodPut, "/posts/"+post.ID, post, &res); err != nil {
+		return Post{}, err
+	}
+	return res, nil
+}
+
+func (c *Client) GetPost(ctx context.Context, postID string) (Post, error) {
+	res := Post{}
+	if err := c.do(ctx, http.MethodGet, "/posts/"+postID, nil, &res); err != nil {
+		return Post{}, err
+	}
+	return res, nil
+}
+
+func (c *Client) DeletePost(ctx context.Context, postID string) error {
+	return c.do(ctx, http.MethodDelete, "/posts/"+postID, nil, nil)
+}
+
+// Permalink returns the link to the post, which redirects to the team of the channel.
+func (c *Client) Permalink(postID string) string {
+	return c.url + "/_redirect/pl/" + postID
+}
@@ -0,0 +1,37 @@
+package mattermost
+
+import (
+	"context"
+	"net/http"
+)
+
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Locale   string `json:"locale"`
+	IsBot    bool   `json:"is_bot"`
+}
+
+type CustomStatus struct {
+	Emoji string `json:"emoji,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Me gets the user of the token.
+func (c *Client) Me(ctx context.Context) (User, error) {
+	return c.GetUser(ctx, "me")
+}
+
+func (c *Client) GetUser(ctx context.Context, userID string) (User, error) {
+	res := User{}
+	if err := c.do(ctx, http.MethodGet, "/users/"+userID, nil, &res); err != nil {
+		return User{}, err
+	}
+	return res, nil
+}
+
+// UpdateCustomStatus sets the custom status of the user of the token.
+func (c *Client) UpdateCustomStatus(ctx context.Context, status CustomStatus) error {
+	return c.do(ctx, http.MethodPut, "/users/me/status/custom", status, nil)
+}
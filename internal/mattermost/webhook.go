@@ -0,0 +1,26 @@
+package mattermost
+
+/*
+OutgoingWebhook is the request of outgoing webhooks, it's posted as a form or json by the content type of the webhook.
+
+https://developers.mattermost.com/integrate/webhooks/outgoing/
+*/
+type OutgoingWebhook struct {
+	Token       string `json:"token" form:"token"`
+	TeamID      string `json:"team_id" form:"team_id"`
+	TeamDomain  string `json:"team_domain" form:"team_domain"`
+	ChannelID   string `json:"channel_id" form:"channel_id"`
+	ChannelName string `json:"channel_name" form:"channel_name"`
+	Timestamp   int64  `json:"timestamp" form:"timestamp"`
+	UserID      string `json:"user_id" form:"user_id"`
+	UserName    string `json:"user_name" form:"user_name"`
+	PostID      string `json:"post_id" form:"post_id"`
+	Text        string `json:"text" form:"text"`
+	TriggerWord string `json:"trigger_word" form:"trigger_word"`
+}
+
+// OutgoingWebhookResponse posts the text as a reply of the trigger post, empty text posts nothing.
+type OutgoingWebhookResponse struct {
+	Text         string `json:"text,omitempty"`
+	ResponseType string `json:"response_type,omitempty"` /* 'comment' replies in the thread */
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"bitopi/internal/slack"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	_namespace = "bitopi"
+)
+
+const (
+	MentionReceived     = "received"
+	MentionReplied      = "replied"
+	MentionDeduplicated = "deduplicated"
+	MentionFailed       = "failed"
+)
+
+const (
+	CronSucceeded = "succeeded"
+	CronFailed    = "failed"
+)
+
+var (
+	_registry = prometheus.NewRegistry()
+
+	_mentions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "mentions_total",
+		Help:      "Mentions of the bots by result, received counts every mention including follow-ups.",
+	}, []string{"bot", "result"})
+
+	_slackCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "slack_api_calls_total",
+		Help:      "Attempts of Slack Web API calls by method and error code, the code is 'ok' for succeeded calls.",
+	}, []string{"method", "code"})
+
+	_slackLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: _namespace,
+		Name:      "slack_api_call_duration_seconds",
+		Help:      "Latency of attempts of Slack Web API calls by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	_cronRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "cron_runs_total",
+		Help:      "Runs of cron jobs by result.",
+	}, []string{"job", "result"})
+
+	_interactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Name:      "interaction_actions_total",
+		Help:      "Interaction actions and view submissions of the bots by action ID.",
+	}, []string{"bot", "action"})
+
+	_dutyMembers = &dutyCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "", "duty_member"),
+			"Current duty members of the rotations, 1 for every member on duty.",
+			[]string{"bot", "team", "rotation", "member"}, nil,
+		),
+	}
+)
+
+func init() {
+	_registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		_mentions,
+		_slackCalls,
+		_slackLatency,
+		_cronRuns,
+		_interactions,
+		_dutyMembers,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(_registry, promhttp.HandlerOpts{})
+}
+
+func Mention(bot, result string) {
+	_mentions.WithLabelValues(bot, result).Inc()
+}
+
+/*
+ObserveSlackCall records an attempt of the Slack method, it's the observer of slack clients.
+*/
+func ObserveSlackCall(method string, elapsed time.Duration, err error) {
+	code := "ok"
+	if err != nil {
+		code = "error"
+		var e *slack.Error
+		if errors.As(err, &e) && len(e.Code) != 0 {
+			code = e.Code
+		}
+	}
+
+	_slackCalls.WithLabelValues(method, code).Inc()
+	_slackLatency.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+func CronRun(job string, err error) {
+	result := CronSucceeded
+	if err != nil {
+		result = CronFailed
+	}
+	_cronRuns.WithLabelValues(job, result).Inc()
+}
+
+func Interaction(bot, action string) {
+	_interactions.WithLabelValues(bot, action).Inc()
+}
+
+// Duty is the duty members of a rotation at the moment.
+type Duty struct {
+	Bot      string
+	Team     string
+	Rotation string
+	Members  []string
+}
+
+/*
+AddDutySource adds the duties of a bot to the duty member gauge, the source is called on every scrape,
+so the gauge follows the rotation even when nothing happens to the bot.
+*/
+func AddDutySource(source func() []Duty) {
+	_dutyMembers.mu.Lock()
+	defer _dutyMembers.mu.Unlock()
+	_dutyMembers.sources = append(_dutyMembers.sources, source)
+}
+
+type dutyCollector struct {
+	desc    *prometheus.Desc
+	mu      sync.Mutex
+	sources []func() []Duty
+}
+
+func (c *dutyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *dutyCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	sources := append([]func() []Duty{}, c.sources...)
+	c.mu.Unlock()
+
+	for _, source := range sources {
+		for _, d := range source() {
+			for _, m := range d.Members {
+				ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, d.Bot, d.Team, d.Rotation, m)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 type Admin struct {
 	ID       uint64 `gorm:"column:id;autoIncrement"`
+	TeamID   string `gorm:"column:team_id;size:20;not null;default:''"`
 	UserID   string `gorm:"column:user_id;size:50"`
 	UserName string `gorm:"column:user_name;size:50"`
 	Service  string `gorm:"column:service;size:50"`
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AuditSourceREST       = "rest"
+	AuditSourceSlackModal = "slack_modal"
+	AuditSourceSystem     = "system"
+	AuditSourceOAuth      = "oauth"
+)
+
+const (
+	AuditActionUpdateMember     = "update_member"
+	AuditActionResetMembers     = "reset_members"
+	AuditActionAddAdmin         = "add_admin"
+	AuditActionDeleteAdmin      = "delete_admin"
+	AuditActionUpdateStartDate  = "update_start_date"
+	AuditActionSetReplyMessage  = "set_reply_message"
+	AuditActionSetSubscriber    = "set_subscriber"
+	AuditActionDeleteSubscriber = "delete_subscriber"
+	AuditActionSetChannel       = "set_channel"
+	AuditActionDeleteChannel    = "delete_channel"
+	AuditActionSaveWorkspace    = "save_workspace"
+	AuditActionSaveWebhook      = "save_webhook"
+	AuditActionDeleteWebhook    = "delete_webhook"
+)
+
+type AuditLog struct {
+	ID        uint64    `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID    string    `gorm:"column:team_id;size:20;not null;default:''" json:"team_id"`
+	Service   string    `gorm:"column:service;size:50;index:idx_audit_logs_service_created_at,priority:1" json:"service"`
+	Action    string    `gorm:"column:action;size:50;not null" json:"action"`
+	Actor     string    `gorm:"column:actor;size:50;not null" json:"actor"`
+	Source    string    `gorm:"column:source;size:20;not null" json:"source"`
+	Before    string    `gorm:"column:before;type:text" json:"before"`
+	After     string    `gorm:"column:after;type:text" json:"after"`
+	CreatedAt time.Time `gorm:"column:created_at;not null;index:idx_audit_logs_service_created_at,priority:2" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "slack_bot_audit_logs"
+}
+
+/*
+Actor is who made the change.
+
+Name is a slack user ID or an API token name.
+*/
+type Actor struct {
+	Name   string
+	Source string
+}
+
+type actorKey struct{}
+
+var _systemActor = Actor{Name: "system", Source: AuditSourceSystem}
+
+// WithActor attaches the actor into the context for audit logs.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom gets the actor from context, returns system actor when there's no actor in context.
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || len(actor.Name) == 0 {
+		return _systemActor
+	}
+	return actor
+}
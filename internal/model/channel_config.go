@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ChannelAccessDefault = ""
+	ChannelAccessAllow   = "allow"
+	ChannelAccessDeny    = "deny"
+)
+
+/*
+ChannelConfig customizes the bot in a channel.
+
+Mentions in denied channels are ignored. Once a channel of the bot is allowed,
+mentions in channels which aren't allowed are ignored too.
+Silent channels are recorded and notified by direct messages without replying in the channel.
+*/
+type ChannelConfig struct {
+	ID           uint64    `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID       string    `gorm:"column:team_id;size:20;not null;default:'';uniqueIndex:uk_channel_configs_team_service_channel,priority:1" json:"team_id"`
+	Service      string    `gorm:"column:service;size:50;not null;uniqueIndex:uk_channel_configs_team_service_channel,priority:2" json:"service"`
+	Channel      string    `gorm:"column:channel;size:50;not null;uniqueIndex:uk_channel_configs_team_service_channel,priority:3" json:"channel"`
+	Access       string    `gorm:"column:access;size:10;not null;default:''" json:"access"`
+	ReplyMessage string    `gorm:"column:reply_message;type:text" json:"reply_message"` /* reply template of the channel, the bot's by default */
+	Notify       string    `gorm:"column:notify;size:255" json:"notify"`                /* comma-joined user IDs notified besides duty members */
+	Silent       bool      `gorm:"column:silent;not null;default:false" json:"silent"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (ChannelConfig) TableName() string {
+	return "slack_bot_channel_configs"
+}
+
+func (c ChannelConfig) NotifyList() []string {
+	if len(c.Notify) == 0 {
+		return []string{}
+	}
+	return strings.Split(c.Notify, ",")
+}
+
+func (c *ChannelConfig) SetNotifyList(userIDs []string) {
+	c.Notify = strings.Join(userIDs, ",")
+}
@@ -2,6 +2,7 @@ package model
 
 type Member struct {
 	ID       uint64 `gorm:"column:id;autoIncrement;primaryKey" json:"-"`
+	TeamID   string `gorm:"column:team_id;size:20;not null;default:''" json:"-"`
 	UserID   string `gorm:"column:user_id;size:50" json:"user_id"`
 	UserName string `gorm:"column:user_name;size:50" json:"user_name"`
 	Order    int    `gorm:"column:order" json:"order"`
@@ -0,0 +1,19 @@
+package model
+
+/*
+MentionDirectMessage is a direct message notifying a member of the mention record,
+follow-ups of the thread are replied in the thread of it.
+*/
+type MentionDirectMessage struct {
+	ID              uint64 `gorm:"column:id;autoIncrement"`
+	MentionRecordID uint64 `gorm:"column:mention_record_id;not null;index"`
+	UserID          string `gorm:"column:user_id;size:50;not null"`
+	Channel         string `gorm:"column:channel;size:50;not null"`
+	Timestamp       string `gorm:"column:timestamp;size:50;not null"`
+	Header          string `gorm:"column:header;type:text"` /* header text of the message to rebuild it on updating */
+	CreateAtu       int64  `gorm:"column:create_atu;not null"`
+}
+
+func (MentionDirectMessage) TableName() string {
+	return "slack_bot_mention_direct_messages"
+}
@@ -0,0 +1,17 @@
+package model
+
+/*
+MentionEvent is a message mentioning the bot, every mention in the same thread belongs to one mention record.
+*/
+type MentionEvent struct {
+	ID              uint64 `gorm:"column:id;autoIncrement"`
+	MentionRecordID uint64 `gorm:"column:mention_record_id;not null;uniqueIndex:uk_mention_events_record_timestamp,priority:1"`
+	Timestamp       string `gorm:"column:timestamp;size:50;not null;uniqueIndex:uk_mention_events_record_timestamp,priority:2"`
+	User            string `gorm:"column:user;size:50"`
+	Text            string `gorm:"column:text;type:text"`
+	CreateAtu       int64  `gorm:"column:create_atu;not null"`
+}
+
+func (MentionEvent) TableName() string {
+	return "slack_bot_mention_events"
+}
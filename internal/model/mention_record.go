@@ -1,13 +1,32 @@
 package model
 
+import "strings"
+
+const (
+	MentionStatusOpen      = "open"
+	MentionStatusResolved  = "resolved"  /* replied by the delete and reply button */
+	MentionStatusCancelled = "cancelled" /* the source message was deleted */
+)
+
 type MentionRecord struct {
-	ID        uint64 `gorm:"column:id;autoIncrement"`
-	Service   string `gorm:"column:service;size:50;index;not null"`
-	Channel   string `gorm:"column:channel;size:50;index;not null"`
-	Timestamp string `gorm:"column:timestamp;size:50;index;not null"`
-	CreateAtu int64  `gorm:"column:create_atu;not null"`
+	ID          uint64 `gorm:"column:id;autoIncrement"`
+	TeamID      string `gorm:"column:team_id;size:20;not null;default:''"`
+	Service     string `gorm:"column:service;size:50;index;not null"`
+	Channel     string `gorm:"column:channel;size:50;index;not null"`
+	Timestamp   string `gorm:"column:timestamp;size:50;index;not null"`
+	DutyMembers string `gorm:"column:duty_members;size:255"`
+	Status      string `gorm:"column:status;size:20;not null;default:open"`
+	CreateAtu   int64  `gorm:"column:create_atu;not null"`
 }
 
 func (s MentionRecord) TableName() string {
 	return "slack_bot_mention_records"
 }
+
+// DutyMemberList returns the user IDs of duty members who were assigned to this mention.
+func (s MentionRecord) DutyMemberList() []string {
+	if len(s.DutyMembers) == 0 {
+		return []string{}
+	}
+	return strings.Split(s.DutyMembers, ",")
+}
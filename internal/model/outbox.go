@@ -0,0 +1,107 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	OutboxPostReply   = "post_reply"
+	OutboxSendDM      = "send_dm"
+	OutboxPublishHome = "publish_home"
+	OutboxFollowUp    = "follow_up"
+	OutboxResendDM    = "resend_dm"
+)
+
+const (
+	OutboxPending = "pending"
+	OutboxDone    = "done"
+	OutboxFailed  = "failed" /* gave up after the max attempts */
+)
+
+/*
+OutboxEntry is a side effect of a mention, it's written in the same transaction as the mention record
+and executed by the outbox worker of the bot until it's done.
+
+The idempotency key is unique, e.g. 'maid/12/dm/U032TJB1PE1', entries of the same key are created once.
+*/
+type OutboxEntry struct {
+	ID              uint64    `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID          string    `gorm:"column:team_id;size:20;not null;default:''" json:"team_id"`
+	Service         string    `gorm:"column:service;size:50;not null;index:idx_outbox_due,priority:1" json:"service"`
+	MentionRecordID uint64    `gorm:"column:mention_record_id;not null" json:"mention_record_id"`
+	Kind            string    `gorm:"column:kind;size:20;not null" json:"kind"`
+	IdempotencyKey  string    `gorm:"column:idempotency_key;size:191;not null;uniqueIndex" json:"idempotency_key"`
+	Payload         string    `gorm:"column:payload;type:text" json:"payload"`
+	Status          string    `gorm:"column:status;size:20;not null;index:idx_outbox_due,priority:2" json:"status"`
+	Attempts        int       `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	LastError       string    `gorm:"column:last_error;type:text" json:"last_error"`
+	Result          string    `gorm:"column:result;type:text" json:"result"`                                                  /* JSON result of done entries, e.g. the reply posted */
+	NextAttemptAt   time.Time `gorm:"column:next_attempt_at;not null;index:idx_outbox_due,priority:3" json:"next_attempt_at"` /* also leases the entry to the worker running it */
+	CreatedAt       time.Time `gorm:"column:created_at;not null" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (OutboxEntry) TableName() string {
+	return "slack_bot_outbox"
+}
+
+/*
+Retried reports whether the entry was claimed or deferred before, its side effect may have been done by the last attempt.
+*/
+func (e OutboxEntry) Retried() bool {
+	return e.Attempts > 0 || e.NextAttemptAt.After(e.CreatedAt)
+}
+
+func OutboxKey(service string, mentionID uint64, kind string, parts ...string) string {
+	key := service + "/" + strconv.FormatUint(mentionID, 10) + "/" + kind
+	for _, p := range parts {
+		key += "/" + p
+	}
+	return key
+}
+
+// OutboxReply is the payload of OutboxPostReply.
+type OutboxReply struct {
+	Channel  string `json:"channel"`
+	ThreadID string `json:"thread_id"`
+	Text     string `json:"text"`
+}
+
+/*
+OutboxDirectMessage is the payload of OutboxSendDM and OutboxResendDM, it links to the reply of ReplyKey when it's set,
+or to the message of LinkChannel and LinkTimestamp.
+*/
+type OutboxDirectMessage struct {
+	UserID        string `json:"user_id"`
+	User          string `json:"user"` /* tag of the user who mentioned the bot */
+	EventContent  string `json:"event_content"`
+	LinkChannel   string `json:"link_channel"`
+	LinkTimestamp string `json:"link_timestamp"`
+	ReplyKey      string `json:"reply_key"`
+	ResendUserID  string `json:"resend_user_id,omitempty"` /* user who resent the mention */
+}
+
+/*
+OutboxFollowUpMessage is the payload of OutboxFollowUp, it replies the follow-up mention in the threads of the direct messages
+after the direct messages of the mention are sent.
+*/
+type OutboxFollowUpMessage struct {
+	Channel   string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+	User      string `json:"user"`
+	Text      string `json:"text"`
+}
+
+// OutboxMessage is the result of entries which post a message.
+type OutboxMessage struct {
+	Channel string `json:"channel"`
+	ID      string `json:"id"`
+}
+
+// OutboxBacklog summarizes the entries of a bot which aren't done.
+type OutboxBacklog struct {
+	Pending       int64      `json:"pending"`
+	Failed        int64      `json:"failed"`
+	OldestPending *time.Time `json:"oldest_pending,omitempty"` /* created time of the oldest pending entry */
+}
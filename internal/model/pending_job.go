@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+/*
+PendingJob is background work of a bot which didn't finish before shutdown, it runs again on the next start.
+
+The payload is the JSON argument of the job of the kind.
+*/
+type PendingJob struct {
+	ID        uint64    `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID    string    `gorm:"column:team_id;size:20;not null;default:''" json:"team_id"`
+	Service   string    `gorm:"column:service;size:50;not null;index:idx_pending_jobs_service" json:"service"`
+	Kind      string    `gorm:"column:kind;size:50;not null" json:"kind"`
+	Payload   string    `gorm:"column:payload;type:text" json:"payload"`
+	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (PendingJob) TableName() string {
+	return "slack_bot_pending_jobs"
+}
@@ -1,7 +1,13 @@
 package model
 
+/*
+BotMessage holds reply templates with placeholders like {{.Duty}}, see package msgtemplate.
+
+MentionMultiMember only affects legacy positional templates, which are converted before rendering.
+*/
 type BotMessage struct {
 	ID                 uint64 `gorm:"column:id;autoIncrement;primaryKey" json:"-"`
+	TeamID             string `gorm:"column:team_id;size:20;not null;default:''" json:"-"`
 	Service            string `gorm:"column:service;size:50" json:"-"`
 	MentionMessage     string `gorm:"column:mention_message;size:255" json:"mention_message"`
 	MentionMultiMember bool   `gorm:"column:mention_multi_member;not null" json:"mention_multi_member"`
@@ -8,6 +8,63 @@ type GetMemberListResponse struct {
 }
 
 type SetMemberListRequest struct {
-	StartAt time.Time `json:"start_at"`
-	Members []Member  `json:"members"`
+	StartAt       time.Time `json:"start_at"`
+	Members       []Member  `json:"members"`
+	EffectiveFrom time.Time `json:"effective_from"` /* optional, now by default */
+}
+
+type PreviewMessageRequest struct {
+	Template string `json:"template"`
+}
+
+type PreviewMessageResponse struct {
+	Text string `json:"text"`
+}
+
+type SetChannelConfigRequest struct {
+	Access       string   `json:"access"` /* allow, deny or empty */
+	ReplyMessage string   `json:"reply_message"`
+	Notify       []string `json:"notify"` /* user IDs */
+	Silent       bool     `json:"silent"`
+}
+
+type SaveWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` /* optional, generated when creating without it, kept when updating without it */
+	Events []string `json:"events"` /* optional, every event by default */
+	Active *bool    `json:"active"` /* optional, true by default */
+}
+
+type SaveWebhookResponse struct {
+	Webhook
+	Secret string `json:"secret,omitempty"` /* only returned when it's generated */
+}
+
+type SetLogLevelRequest struct {
+	Level string `json:"level"` /* trace, debug, info, warn, error or fatal, the level of 'log.level' when it's empty */
+}
+
+type LogLevelResponse struct {
+	Service string `json:"service"`
+	Level   string `json:"level"`
+}
+
+const (
+	ReadinessOK          = "ok"
+	ReadinessUnavailable = "unavailable"
+	ReadinessSkipped     = "skipped"
+)
+
+/*
+ReadinessResponse is the result of every dependency check, the status is unavailable when any component is.
+*/
+type ReadinessResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type ComponentStatus struct {
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Detail interface{} `json:"detail,omitempty"`
 }
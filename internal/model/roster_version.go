@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+/*
+RosterVersion is an immutable snapshot of the duty rotation.
+
+The version with the latest EffectiveFrom before a moment is the one in effect at that moment.
+Zero Duration or MemberCountPerTime means using the default of the bot.
+*/
+type RosterVersion struct {
+	ID                 uint64        `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID             string        `gorm:"column:team_id;size:20;not null;default:''" json:"team_id"`
+	Service            string        `gorm:"column:service;size:50;not null;index:idx_roster_versions_service_effective_from,priority:1" json:"service"`
+	Members            string        `gorm:"column:members;type:text" json:"members"`
+	StartDate          time.Time     `gorm:"column:start_date;not null" json:"start_date"`
+	Duration           time.Duration `gorm:"column:duration;not null" json:"duration"`
+	MemberCountPerTime int           `gorm:"column:member_count_per_time;not null" json:"member_count_per_time"`
+	EffectiveFrom      time.Time     `gorm:"column:effective_from;not null;index:idx_roster_versions_service_effective_from,priority:2" json:"effective_from"`
+	CreatedAt          time.Time     `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (RosterVersion) TableName() string {
+	return "slack_bot_roster_versions"
+}
+
+func (v RosterVersion) MemberList() ([]Member, error) {
+	members := []Member{}
+	if len(v.Members) == 0 {
+		return members, nil
+	}
+
+	if err := json.Unmarshal([]byte(v.Members), &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (v *RosterVersion) SetMemberList(members []Member) error {
+	buf, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	v.Members = string(buf)
+	return nil
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type SchemaMigration struct {
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;size:100;not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
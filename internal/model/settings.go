@@ -1,9 +1,10 @@
 package model
 
 type BotSetting struct {
-	ID    uint64 `gorm:"column:id;autoIncrement"`
-	Key   string `gorm:"column:key"`
-	Value string `gorm:"column:value"`
+	ID     uint64 `gorm:"column:id;autoIncrement"`
+	TeamID string `gorm:"column:team_id;size:20;not null;default:''"`
+	Key    string `gorm:"column:key"`
+	Value  string `gorm:"column:value"`
 }
 
 func (BotSetting) TableName() string {
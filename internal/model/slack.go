@@ -1,18 +1,20 @@
 package model
 
+import "time"
+
 type SlackTextObject struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
 }
 
 type SlackDirectMsgOption struct {
-	IsUser          bool
-	MentionRecordID string
+	MentionRecordID uint64
 	ServiceName     string
 	User            string
 	EventContent    string
-	Members         []string
 	ResendUserID    string
 	LinkChannel     string
 	LinkTimestamp   string
+	IdempotencyKey  string    /* in the metadata of the direct message, retries find the message by it */
+	RetrySince      time.Time /* set by retries, the direct message sent after it by the last attempt isn't sent again */
 }
@@ -1,5 +1,14 @@
 package model
 
+const (
+	EventTypeAppMention = "app_mention"
+	EventTypeMessage    = "message"
+
+	EventSubTypeBotMessage     = "bot_message"
+	EventSubTypeMessageChanged = "message_changed"
+	EventSubTypeMessageDeleted = "message_deleted"
+)
+
 type SlackEventAPI struct {
 	Token       string   `json:"token"`
 	TeamID      string   `json:"team_id"`
@@ -12,10 +21,56 @@ type SlackEventAPI struct {
 }
 
 type Event struct {
-	Type           string `json:"type"`
-	User           string `json:"user"`
-	Text           string `json:"text"`
-	TimeStamp      string `json:"ts"`
-	Channel        string `json:"channel"`
-	EventTimeStamp string `json:"event_ts"`
+	Type             string        `json:"type"`
+	SubType          string        `json:"subtype"`
+	User             string        `json:"user"`
+	BotID            string        `json:"bot_id"`
+	Text             string        `json:"text"`
+	TimeStamp        string        `json:"ts"`
+	Channel          string        `json:"channel"`
+	EventTimeStamp   string        `json:"event_ts"`
+	ThreadTimeStamp  string        `json:"thread_ts"`
+	DeletedTimeStamp string        `json:"deleted_ts"`       /* message_deleted only */
+	Message          *EventMessage `json:"message"`          /* message_changed only */
+	PreviousMessage  *EventMessage `json:"previous_message"` /* message_changed and message_deleted only */
+}
+
+// ThreadRootTimeStamp returns the timestamp of the thread root, which is the message itself when it's not in a thread.
+func (e Event) ThreadRootTimeStamp() string {
+	if len(e.ThreadTimeStamp) != 0 {
+		return e.ThreadTimeStamp
+	}
+	if len(e.TimeStamp) != 0 {
+		return e.TimeStamp
+	}
+	return e.EventTimeStamp
+}
+
+// IsBot reports whether the event was posted by a bot.
+func (e Event) IsBot() bool {
+	return len(e.BotID) != 0 || e.SubType == EventSubTypeBotMessage
+}
+
+// EventMessage is the message carried by message_changed and message_deleted events.
+type EventMessage struct {
+	Type            string `json:"type"`
+	SubType         string `json:"subtype"`
+	User            string `json:"user"`
+	BotID           string `json:"bot_id"`
+	Text            string `json:"text"`
+	TimeStamp       string `json:"ts"`
+	ThreadTimeStamp string `json:"thread_ts"`
+}
+
+// ThreadRootTimeStamp returns the timestamp of the thread root, which is the message itself when it's not in a thread.
+func (m EventMessage) ThreadRootTimeStamp() string {
+	if len(m.ThreadTimeStamp) != 0 {
+		return m.ThreadTimeStamp
+	}
+	return m.TimeStamp
+}
+
+// IsBot reports whether the message was posted by a bot.
+func (m EventMessage) IsBot() bool {
+	return len(m.BotID) != 0 || m.SubType == EventSubTypeBotMessage
 }
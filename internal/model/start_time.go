@@ -4,6 +4,7 @@ import "time"
 
 type StartTime struct {
 	ID        uint64    `gorm:"column:id;autoIncrement"`
+	TeamID    string    `gorm:"column:team_id;size:20;not null;default:''"`
 	Service   string    `gorm:"column:service;size:50"`
 	StartTime time.Time `gorm:"start_time"`
 }
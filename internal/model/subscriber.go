@@ -2,6 +2,7 @@ package model
 
 type Subscriber struct {
 	UserID   string `gorm:"column:user_id;size:50;primaryKey"`
+	TeamID   string `gorm:"column:team_id;size:20;not null;default:''"`
 	UserName string `gorm:"column:user_name;size:50"`
 	Home     bool   `gorm:"column:home;size:50;not null;default:false"`
 }
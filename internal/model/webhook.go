@@ -0,0 +1,131 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	WebhookEventDutyRotated      = "duty.rotated"
+	WebhookEventMentionOpened    = "mention.opened"
+	WebhookEventMentionResolved  = "mention.resolved"
+	WebhookEventMentionCancelled = "mention.cancelled"
+)
+
+var (
+	WebhookEvents = []string{
+		WebhookEventDutyRotated,
+		WebhookEventMentionOpened,
+		WebhookEventMentionResolved,
+		WebhookEventMentionCancelled,
+	}
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+/*
+Webhook posts events of the bot to the url, the payload is signed by the secret.
+*/
+type Webhook struct {
+	ID        uint64    `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID    string    `gorm:"column:team_id;size:20;not null;default:'';index:idx_webhooks_team_service,priority:1" json:"team_id"`
+	Service   string    `gorm:"column:service;size:50;not null;index:idx_webhooks_team_service,priority:2" json:"service"`
+	URL       string    `gorm:"column:url;size:1024;not null" json:"url"`
+	Secret    string    `gorm:"column:secret;size:255;not null" json:"-"`
+	Events    string    `gorm:"column:events;size:255;not null;default:''" json:"events"` /* comma-joined events, every event when it's empty */
+	Active    bool      `gorm:"column:active;not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "slack_bot_webhooks"
+}
+
+func (w Webhook) EventList() []string {
+	if len(w.Events) == 0 {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+func (w *Webhook) SetEventList(events []string) {
+	w.Events = strings.Join(events, ",")
+}
+
+// Subscribes reports whether the webhook is active and receives the event.
+func (w Webhook) Subscribes(event string) bool {
+	if !w.Active {
+		return false
+	}
+
+	events := w.EventList()
+	if len(events) == 0 {
+		return true
+	}
+
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+WebhookDelivery is a delivery of an event to the webhook, redelivering creates a new delivery of the same payload.
+Pending deliveries are attempted by the worker of the service at the next attempt time, like outbox entries.
+*/
+type WebhookDelivery struct {
+	ID             uint64     `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	TeamID         string     `gorm:"column:team_id;size:20;not null;default:''" json:"team_id"`
+	Service        string     `gorm:"column:service;size:50;not null;index:idx_webhook_deliveries_due,priority:1" json:"service"`
+	WebhookID      uint64     `gorm:"column:webhook_id;not null;index:idx_webhook_deliveries_webhook_id" json:"webhook_id"`
+	Event          string     `gorm:"column:event;size:50;not null" json:"event"`
+	Payload        string     `gorm:"column:payload;type:text" json:"payload"`
+	Status         string     `gorm:"column:status;size:20;not null;default:'pending';index:idx_webhook_deliveries_due,priority:2" json:"status"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	ResponseStatus int        `gorm:"column:response_status;not null;default:0" json:"response_status"`
+	Error          string     `gorm:"column:error;type:text" json:"error"`
+	RedeliveryOf   uint64     `gorm:"column:redelivery_of;not null;default:0" json:"redelivery_of,omitempty"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;not null;index:idx_webhook_deliveries_due,priority:3" json:"next_attempt_at"` /* also leases the delivery to the worker attempting it */
+	CreatedAt      time.Time  `gorm:"column:created_at;not null" json:"created_at"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at" json:"delivered_at,omitempty"` /* time of the last attempt */
+}
+
+func (WebhookDelivery) TableName() string {
+	return "slack_bot_webhook_deliveries"
+}
+
+/*
+WebhookPayload is the JSON body posted to webhooks, data depends on the event.
+*/
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	Service   string      `json:"service"`
+	TeamID    string      `json:"team_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type MentionWebhookData struct {
+	MentionID   uint64   `json:"mention_id"`
+	Channel     string   `json:"channel"`
+	Timestamp   string   `json:"timestamp"` /* timestamp or post ID of the thread root */
+	User        string   `json:"user,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	DutyMembers []string `json:"duty_members"` /* user IDs */
+	Status      string   `json:"status"`
+	Actor       string   `json:"actor,omitempty"` /* user ID who resolved the mention */
+}
+
+type DutyRotatedWebhookData struct {
+	Rotation string    `json:"rotation"` /* empty for the bot without rotations */
+	Previous []string  `json:"previous"` /* user IDs */
+	Current  []string  `json:"current"`  /* user IDs */
+	ShiftEnd time.Time `json:"shift_end"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Workspace is a Slack workspace where the bot was installed by OAuth.
+
+The workspace of the static '<name>.token' has no record, its data is scoped by empty team ID.
+*/
+type Workspace struct {
+	ID          uint64    `gorm:"column:id;autoIncrement;primaryKey" json:"id"`
+	Service     string    `gorm:"column:service;size:50;not null;uniqueIndex:uk_workspaces_service_team_id,priority:1" json:"service"`
+	TeamID      string    `gorm:"column:team_id;size:20;not null;uniqueIndex:uk_workspaces_service_team_id,priority:2" json:"team_id"`
+	TeamName    string    `gorm:"column:team_name;size:255" json:"team_name"`
+	BotToken    string    `gorm:"column:bot_token;size:255;not null" json:"-"`
+	BotUserID   string    `gorm:"column:bot_user_id;size:50" json:"bot_user_id"`
+	Scope       string    `gorm:"column:scope;type:text" json:"scope"`
+	InstalledBy string    `gorm:"column:installed_by;size:50" json:"installed_by"`
+	InstalledAt time.Time `gorm:"column:installed_at;not null" json:"installed_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (Workspace) TableName() string {
+	return "slack_bot_workspaces"
+}
+
+type teamKey struct{}
+
+// WithTeam attaches the team ID of the workspace into the context, repository data is scoped by it.
+func WithTeam(ctx context.Context, teamID string) context.Context {
+	return context.WithValue(ctx, teamKey{}, teamID)
+}
+
+// TeamFrom gets the team ID from context, returns empty team ID of the static token workspace when there's no team in context.
+func TeamFrom(ctx context.Context) string {
+	teamID, _ := ctx.Value(teamKey{}).(string)
+	return teamID
+}
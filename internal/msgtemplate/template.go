@@ -0,0 +1,142 @@
+/*
+Package msgtemplate renders reply messages with named placeholders.
+
+	請稍候片刻，本週 {{.Duty}} 將盡快為您服務，下一輪是 {{.NextDuty}}
+
+Templates only access fields and methods of Data, unknown placeholders and rotations are rejected by Validate.
+*/
+package msgtemplate
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Data is the data of placeholders, every field is rendered Slack text, e.g. '<@U123> <@U456>'.
+*/
+type Data struct {
+	Duty      string
+	Backup    string /* the other members of the roster */
+	Requester string
+	Channel   string
+	ShiftEnd  string
+	NextDuty  string
+	Rotations map[string]string /* rotation name or title -> duty members */
+
+	known map[string]bool /* rotations of the bot when validating, unknown rotations fail the rendering */
+}
+
+/*
+Rotation returns the duty members of the rotation, or '-' when the rotation wasn't picked.
+Rotations the bot doesn't have are rejected when validating.
+*/
+func (d Data) Rotation(name string) (string, error) {
+	if s, ok := d.Rotations[name]; ok {
+		return s, nil
+	}
+	if d.known != nil && !d.known[name] {
+		return "", errors.Errorf("unknown rotation '%s'", name)
+	}
+	return "-", nil
+}
+
+// SampleData is the data of previewing and validating templates.
+var SampleData = Data{
+	Duty:      "<@UDUTY>",
+	Backup:    "<@UBACKUP1> <@UBACKUP2>",
+	Requester: "<@UREQUESTER>",
+	Channel:   "<#CCHANNEL>",
+	ShiftEnd:  "2023/05/28",
+	NextDuty:  "<@UNEXT>",
+	Rotations: map[string]string{},
+}
+
+// sampleData is SampleData whose rotations are the rotations of the bot, by name or title.
+func sampleData(rotations []string) Data {
+	data := SampleData
+	data.Rotations = make(map[string]string, len(rotations))
+	data.known = make(map[string]bool, len(rotations))
+	for _, name := range rotations {
+		data.Rotations[name] = "<@UROTATION>"
+		data.known[name] = true
+	}
+	return data
+}
+
+func parse(text string) (*template.Template, error) {
+	t, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+	return t, nil
+}
+
+func execute(t *template.Template, data Data) (string, error) {
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "execute template")
+	}
+	return buf.String(), nil
+}
+
+// Validate checks the syntax and placeholders of the template, rotations are the names and titles of the rotations of the bot.
+func Validate(text string, rotations []string) error {
+	_, err := Preview(text, rotations)
+	return err
+}
+
+// Preview renders the template with the sample data, rotations are the names and titles of the rotations of the bot.
+func Preview(text string, rotations []string) (string, error) {
+	t, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	return execute(t, sampleData(rotations))
+}
+
+func Render(text string, data Data) (string, error) {
+	t, err := parse(text)
+	if err != nil {
+		return "", err
+	}
+	return execute(t, data)
+}
+
+// IsLegacy reports whether the text is a positional fmt template, e.g. '本週 %s'.
+func IsLegacy(text string) bool {
+	return !strings.Contains(text, "{{") && strings.Contains(text, "%s")
+}
+
+/*
+FromLegacy converts a positional fmt template by replacing each '%s' with the placeholder in order,
+'%s' without a placeholder is removed and '%%' becomes '%'.
+*/
+func FromLegacy(text string, placeholders ...string) string {
+	b := strings.Builder{}
+	next := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '%' || i+1 >= len(text) {
+			b.WriteByte(text[i])
+			continue
+		}
+
+		switch text[i+1] {
+		case 's':
+			if next < len(placeholders) {
+				b.WriteString(placeholders[next])
+			}
+			next++
+			i++
+		case '%':
+			b.WriteByte('%')
+			i++
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
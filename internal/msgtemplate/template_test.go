@@ -0,0 +1,36 @@
+package msgtemplate
+
+import "testing"
+
+func TestValidateRotations(t *testing.T) {
+	rotations := []string{"backend", "Backend team"}
+	testCases := []struct {
+		name  string
+		text  string
+		valid bool
+	}{
+		{"rotation name", `{{.Rotation "backend"}} is on duty`, true},
+		{"rotation title", `{{.Rotation "Backend team"}} is on duty`, true},
+		{"misspelled rotation", `{{.Rotation "backedn"}} is on duty`, false},
+		{"unknown field", `{{.Dutty}} is on duty`, false},
+		{"no rotation", `{{.Duty}} is on duty`, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Validate(tc.text, rotations); (err == nil) != tc.valid {
+				t.Fatalf("expect valid %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestRenderUnpickedRotation(t *testing.T) {
+	out, err := Render(`{{.Rotation "backend"}}`, Data{Rotations: map[string]string{}})
+	if err != nil {
+		t.Fatalf("render: %+v", err)
+	}
+	if out != "-" {
+		t.Fatalf("expect '-' for the rotation which wasn't picked, got %s", out)
+	}
+}
@@ -0,0 +1,348 @@
+package platform
+
+import (
+	"bitopi/internal/mattermost"
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	/* props key of the metadata of posts */
+	_mattermostMetadataKey = "bitopi_metadata"
+	/* custom status longer than this is rejected by Mattermost */
+	_mattermostStatusLimit = 100
+)
+
+var (
+	_slackUserRe    = regexp.MustCompile(`<@([A-Za-z0-9]+)>`)
+	_slackChannelRe = regexp.MustCompile(`<#([A-Za-z0-9]+)(?:\|([^>]*))?>`)
+	_slackLinkRe    = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)(?:\|([^>]*))?>`)
+	_slackBoldRe    = regexp.MustCompile(`(^|[^*\w])\*([^*\n]+)\*($|[^*\w])`)
+)
+
+type MattermostOption struct {
+	ActionURL string /* optional, receives button actions and form submissions, buttons and forms are dropped without it */
+}
+
+type mattermostPlatform struct {
+	client *mattermost.Client
+	opt    MattermostOption
+
+	mu       sync.Mutex
+	botID    string
+	users    map[string]mattermost.User
+	channels map[string]mattermost.Channel
+	status   string
+}
+
+// NewMattermost creates the platform of the Mattermost server of the client, the client uses the token of a bot account.
+func NewMattermost(client *mattermost.Client, opt MattermostOption) Platform {
+	return &mattermostPlatform{
+		client:   client,
+		opt:      opt,
+		users:    map[string]mattermost.User{},
+		channels: map[string]mattermost.Channel{},
+	}
+}
+
+func (p *mattermostPlatform) Name() string {
+	return Mattermost
+}
+
+/*
+PostReply replies in the thread of the root post, Mattermost rejects replies whose root is a reply itself.
+*/
+func (p *mattermostPlatform) PostReply(ctx context.Context, msg Message) (MessageRef, error) {
+	rootID := msg.ThreadID
+	if len(rootID) != 0 {
+		root, err := p.RootID(ctx, rootID)
+		if err != nil {
+			return MessageRef{}, err
+		}
+		rootID = root
+	}
+
+	post, err := p.client.CreatePost(ctx, mattermost.Post{
+		ChannelID: msg.Channel,
+		RootID:    rootID,
+		Message:   p.message(ctx, msg),
+		Props:     p.props(msg),
+	})
+	if err != nil {
+		return MessageRef{}, err
+	}
+	return MessageRef{Channel: post.ChannelID, ID: post.ID}, nil
+}
+
+func (p *mattermostPlatform) SendDirectMessage(ctx context.Context, userID string, msg Message) (MessageRef, error) {
+	botID, err := p.self(ctx)
+	if err != nil {
+		return MessageRef{}, err
+	}
+
+	channel, err := p.client.DirectChannel(ctx, botID, userID)
+	if err != nil {
+		return MessageRef{}, err
+	}
+
+	msg.Channel = channel.ID
+	return p.PostReply(ctx, msg)
+}
+
+func (p *mattermostPlatform) UpdateMessage(ctx context.Context, ref MessageRef, msg Message) error {
+	_, err := p.client.UpdatePost(ctx, mattermost.Post{
+		ID:        ref.ID,
+		ChannelID: ref.Channel,
+		Message:   p.message(ctx, msg),
+		Props:     p.props(msg),
+	})
+	return err
+}
+
+func (p *mattermostPlatform) DeleteMessage(ctx context.Context, ref MessageRef) error {
+	return p.client.DeletePost(ctx, ref.ID)
+}
+
+func (p *mattermostPlatform) Permalink(_ context.Context, ref MessageRef) (string, error) {
+	return p.client.Permalink(ref.ID), nil
+}
+
+/*
+PublishHome sets the custom status of the bot, Mattermost has no home tab.
+The status is shared by every user, so it's only updated when it changed.
+*/
+func (p *mattermostPlatform) PublishHome(ctx context.Context, _ string, home Home) error {
+	status := []rune(strings.TrimSpace(p.markup(ctx, home.Status)))
+	if len(status) > _mattermostStatusLimit {
+		status = append(status[:_mattermostStatusLimit-1], '…')
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if string(status) == p.status {
+		return nil
+	}
+
+	if err := p.client.UpdateCustomStatus(ctx, mattermost.CustomStatus{Text: string(status)}); err != nil {
+		return err
+	}
+	p.status = string(status)
+	return nil
+}
+
+/*
+OpenForm opens an interactive dialog, Mattermost selects only one user in a field.
+*/
+func (p *mattermostPlatform) OpenForm(ctx context.Context, triggerID string, form Form) error {
+	if len(p.opt.ActionURL) == 0 {
+		return ErrUnsupported
+	}
+
+	elements := make([]mattermost.DialogElement, 0, len(form.Fields))
+	for _, f := range form.Fields {
+		e := mattermost.DialogElement{
+			DisplayName: f.Label,
+			Name:        f.ID,
+			Type:        mattermost.ElementText,
+			Placeholder: f.Placeholder,
+		}
+		if len(f.Group) != 0 {
+			e.DisplayName = f.Group + ": " + f.Label
+		}
+		if len(f.Values) != 0 {
+			e.Default = f.Values[0]
+		}
+
+		switch {
+		case f.Type == FieldUsers:
+			e.Type, e.DataSource = mattermost.ElementSelect, mattermost.DataSourceUsers
+		case f.Type == FieldText && f.Multiline:
+			e.Type = mattermost.ElementTextarea
+		}
+		elements = append(elements, e)
+	}
+
+	return p.client.OpenDialog(ctx, triggerID, p.opt.ActionURL, mattermost.Dialog{
+		CallbackID:       form.ID,
+		Title:            form.Title,
+		IntroductionText: form.Footnote,
+		SubmitLabel:      form.Submit,
+		State:            form.State,
+		Elements:         elements,
+	})
+}
+
+func (p *mattermostPlatform) UserLocale(ctx context.Context, userID string) (string, error) {
+	user, err := p.user(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Locale, nil
+}
+
+// RootID returns the root of the thread of the post, which is the post itself when it's not a reply.
+func (p *mattermostPlatform) RootID(ctx context.Context, postID string) (string, error) {
+	post, err := p.client.GetPost(ctx, postID)
+	if err != nil {
+		return "", err
+	}
+	if len(post.RootID) != 0 {
+		return post.RootID, nil
+	}
+	return post.ID, nil
+}
+
+func (p *mattermostPlatform) self(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	botID := p.botID
+	p.mu.Unlock()
+	if len(botID) != 0 {
+		return botID, nil
+	}
+
+	me, err := p.client.Me(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	p.botID = me.ID
+	p.mu.Unlock()
+	return me.ID, nil
+}
+
+func (p *mattermostPlatform) user(ctx context.Context, userID string) (mattermost.User, error) {
+	p.mu.Lock()
+	user, ok := p.users[userID]
+	p.mu.Unlock()
+	if ok {
+		return user, nil
+	}
+
+	user, err := p.client.GetUser(ctx, userID)
+	if err != nil {
+		return mattermost.User{}, err
+	}
+
+	p.mu.Lock()
+	p.users[userID] = user
+	p.mu.Unlock()
+	return user, nil
+}
+
+func (p *mattermostPlatform) channel(ctx context.Context, channelID string) (mattermost.Channel, error) {
+	p.mu.Lock()
+	channel, ok := p.channels[channelID]
+	p.mu.Unlock()
+	if ok {
+		return channel, nil
+	}
+
+	channel, err := p.client.GetChannel(ctx, channelID)
+	if err != nil {
+		return mattermost.Channel{}, err
+	}
+
+	p.mu.Lock()
+	p.channels[channelID] = channel
+	p.mu.Unlock()
+	return channel, nil
+}
+
+/*
+message joins the sections into markdown, the text of the message is only used without sections.
+*/
+func (p *mattermostPlatform) message(ctx context.Context, msg Message) string {
+	if len(msg.Sections) == 0 {
+		return p.markup(ctx, msg.Text)
+	}
+
+	parts := []string{}
+	for _, s := range msg.Sections {
+		if len(s.Text) != 0 {
+			parts = append(parts, p.markup(ctx, s.Text))
+		}
+		for _, note := range s.Notes {
+			parts = append(parts, p.markup(ctx, note))
+		}
+		if len(s.Quote) != 0 {
+			parts = append(parts, "> "+strings.ReplaceAll(s.Quote, "\n", "\n> "))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+/*
+props carries the metadata and the buttons of the message, buttons post the action ID and value to the action url.
+*/
+func (p *mattermostPlatform) props(msg Message) map[string]interface{} {
+	props := map[string]interface{}{}
+	if msg.Metadata != nil {
+		props[_mattermostMetadataKey] = map[string]interface{}{
+			"event_type":    msg.Metadata.EventType,
+			"event_payload": msg.Metadata.EventPayload,
+		}
+	}
+
+	if len(p.opt.ActionURL) != 0 {
+		actions := []mattermost.Action{}
+		for _, s := range msg.Sections {
+			for _, a := range s.Actions {
+				actions = append(actions, mattermost.Action{
+					ID:    strings.NewReplacer(".", "", "_", "").Replace(a.ID),
+					Name:  a.Text,
+					Style: a.Style,
+					Integration: mattermost.Integration{
+						URL:     p.opt.ActionURL,
+						Context: map[string]interface{}{"action_id": a.ID, "value": a.Value},
+					},
+				})
+			}
+		}
+		if len(actions) != 0 {
+			props["attachments"] = []mattermost.Attachment{{Actions: actions}}
+		}
+	}
+
+	if len(props) == 0 {
+		return nil
+	}
+	return props
+}
+
+/*
+markup converts the markup of Slack to markdown of Mattermost,
+users and channels which can't be found are left as their IDs.
+*/
+func (p *mattermostPlatform) markup(ctx context.Context, text string) string {
+	text = _slackUserRe.ReplaceAllStringFunc(text, func(s string) string {
+		id := _slackUserRe.FindStringSubmatch(s)[1]
+		if user, err := p.user(ctx, id); err == nil {
+			return "@" + user.Username
+		}
+		return "@" + id
+	})
+
+	text = _slackChannelRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := _slackChannelRe.FindStringSubmatch(s)
+		if channel, err := p.channel(ctx, m[1]); err == nil {
+			return "~" + channel.Name
+		}
+		if len(m[2]) != 0 {
+			return "~" + m[2]
+		}
+		return "~" + m[1]
+	})
+
+	text = _slackLinkRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := _slackLinkRe.FindStringSubmatch(s)
+		if len(m[2]) == 0 {
+			return m[1]
+		}
+		return "[" + m[2] + "](" + m[1] + ")"
+	})
+
+	return _slackBoldRe.ReplaceAllString(text, "$1**$2**$3")
+}
@@ -0,0 +1,152 @@
+package platform
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Slack      = "slack"
+	Mattermost = "mattermost"
+)
+
+var (
+	// ErrUnsupported is returned when the platform can't do the operation, e.g. forms without an interactive endpoint.
+	ErrUnsupported = errors.New("unsupported by the platform")
+)
+
+/*
+Platform is the chat tool a bot serves, the duty rotation and mention records don't depend on it.
+
+Text of messages uses the markup of Slack, e.g. '<@user>', '<#channel>', '<url|label>' and '*bold*',
+platforms with other markup convert it before posting.
+*/
+type Platform interface {
+	Name() string
+
+	// PostReply posts the message in the thread of msg.ThreadID, or in the channel when it's empty.
+	PostReply(ctx context.Context, msg Message) (MessageRef, error)
+
+	// SendDirectMessage posts the message in the direct channel between the bot and the user.
+	SendDirectMessage(ctx context.Context, userID string, msg Message) (MessageRef, error)
+
+	// UpdateMessage replaces the content of the message posted by the bot.
+	UpdateMessage(ctx context.Context, ref MessageRef, msg Message) error
+
+	// DeleteMessage deletes the message posted by the bot.
+	DeleteMessage(ctx context.Context, ref MessageRef) error
+
+	// Permalink returns the link to the message.
+	Permalink(ctx context.Context, ref MessageRef) (string, error)
+
+	// PublishHome shows the home of the bot to the user, platforms without a home tab show the status instead.
+	PublishHome(ctx context.Context, userID string, home Home) error
+
+	// OpenForm opens the form for the user who triggered the interaction.
+	OpenForm(ctx context.Context, triggerID string, form Form) error
+
+	// UserLocale returns the locale of the user's setting, e.g. 'en-US'.
+	UserLocale(ctx context.Context, userID string) (string, error)
+}
+
+// ThreadResolver is implemented by platforms whose events don't carry the root of the thread, e.g. Mattermost webhooks.
+type ThreadResolver interface {
+	RootID(ctx context.Context, messageID string) (string, error)
+}
+
+/*
+ReplyFinder is implemented by platforms which can find the messages of the bot by their metadata,
+it's how retries of posting a reply or sending a direct message find out the message was posted.
+*/
+type ReplyFinder interface {
+	FindReply(ctx context.Context, channel, threadID string, metadata Metadata) (MessageRef, bool, error)
+
+	// FindDirectMessage looks for the message in the direct channel with the user, which was sent after since.
+	FindDirectMessage(ctx context.Context, userID string, since time.Time, metadata Metadata) (MessageRef, bool, error)
+}
+
+// MessageRef locates a posted message, ID is the timestamp on Slack or the post ID on Mattermost.
+type MessageRef struct {
+	Channel string
+	ID      string
+}
+
+type Message struct {
+	Channel  string
+	ThreadID string
+	Text     string    /* fallback text of notifications, and the whole message without sections */
+	Sections []Section /* optional, rich layout of the message */
+	Metadata *Metadata /* optional, invisible data to find the message later */
+}
+
+/*
+Section is a part of a message or a home, every field is optional.
+*/
+type Section struct {
+	ID      string   /* identifies the actions, e.g. block ID on Slack */
+	Text    string   /* markup text */
+	Notes   []string /* small markup text below the text */
+	Quote   string   /* small plain text, e.g. content of the source message */
+	Actions []Action
+}
+
+const (
+	StylePrimary = "primary"
+	StyleDanger  = "danger"
+)
+
+type Action struct {
+	ID      string
+	Text    string
+	Value   string
+	Style   string   /* optional, StylePrimary or StyleDanger */
+	Confirm *Confirm /* optional, asks before triggering the action */
+}
+
+type Confirm struct {
+	Title  string
+	Text   string
+	Submit string
+	Cancel string
+	Style  string
+}
+
+type Metadata struct {
+	EventType    string
+	EventPayload map[string]interface{}
+}
+
+type Home struct {
+	Status   string /* one-line summary of the home */
+	Sections []Section
+}
+
+type FieldType string
+
+const (
+	FieldUsers FieldType = "users"
+	FieldDate  FieldType = "date"
+	FieldText  FieldType = "text"
+)
+
+type Form struct {
+	ID       string /* routes the submission, e.g. callback ID on Slack */
+	Title    string
+	Submit   string
+	Cancel   string
+	State    string /* returned with the submission */
+	Fields   []Field
+	Footnote string /* optional, small plain text below the fields */
+}
+
+type Field struct {
+	ID          string
+	Type        FieldType
+	Label       string
+	Placeholder string
+	Group       string   /* optional, heading of the fields from this one */
+	Values      []string /* initial values, dates are formatted as '2006-01-02' */
+	Multiline   bool     /* FieldText only */
+}
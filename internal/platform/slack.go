@@ -0,0 +1,245 @@
+package platform
+
+import (
+	"bitopi/internal/slack"
+	"bitopi/internal/slack/blocks"
+	"context"
+	"strconv"
+	"time"
+)
+
+type slackPlatform struct {
+	client *slack.Client
+}
+
+// NewSlack creates the platform of the Slack workspace of the client, messages are built with Block Kit.
+func NewSlack(client *slack.Client) Platform {
+	return &slackPlatform{client: client}
+}
+
+func (p *slackPlatform) Name() string {
+	return Slack
+}
+
+func (p *slackPlatform) PostReply(ctx context.Context, msg Message) (MessageRef, error) {
+	res, err := p.client.PostMessage(ctx, slack.PostMessageRequest{
+		Channel:  msg.Channel,
+		Text:     msg.Text,
+		ThreadTS: msg.ThreadID,
+		Blocks:   slackBlocks(msg.Sections),
+		Metadata: slackMetadata(msg.Metadata),
+	})
+	if err != nil {
+		return MessageRef{}, err
+	}
+	return MessageRef{Channel: res.Channel, ID: res.TS}, nil
+}
+
+func (p *slackPlatform) SendDirectMessage(ctx context.Context, userID string, msg Message) (MessageRef, error) {
+	channel, err := p.client.OpenConversation(ctx, userID)
+	if err != nil {
+		return MessageRef{}, err
+	}
+
+	msg.Channel = channel
+	return p.PostReply(ctx, msg)
+}
+
+/*
+FindReply looks for the reply of the bot whose metadata has the event type and every payload of the metadata.
+*/
+func (p *slackPlatform) FindReply(ctx context.Context, channel, threadID string, metadata Metadata) (MessageRef, bool, error) {
+	cursor := ""
+	for {
+		res, err := p.client.ConversationReplies(ctx, slack.RepliesRequest{
+			Channel:            channel,
+			TS:                 threadID,
+			Cursor:             cursor,
+			IncludeAllMetadata: true,
+		})
+		if err != nil {
+			return MessageRef{}, false, err
+		}
+
+		for _, msg := range res.Messages {
+			if matchMetadata(msg, metadata) {
+				return MessageRef{Channel: channel, ID: msg.TS}, true, nil
+			}
+		}
+
+		if cursor = res.NextCursor(); len(cursor) == 0 {
+			return MessageRef{}, false, nil
+		}
+	}
+}
+
+/*
+FindDirectMessage looks for the direct message of the bot like FindReply, only the history after since is paged through.
+*/
+func (p *slackPlatform) FindDirectMessage(ctx context.Context, userID string, since time.Time, metadata Metadata) (MessageRef, bool, error) {
+	channel, err := p.client.OpenConversation(ctx, userID)
+	if err != nil {
+		return MessageRef{}, false, err
+	}
+
+	cursor := ""
+	for {
+		res, err := p.client.ConversationHistory(ctx, slack.HistoryRequest{
+			Channel:            channel,
+			Cursor:             cursor,
+			Oldest:             strconv.FormatInt(since.Unix(), 10),
+			IncludeAllMetadata: true,
+		})
+		if err != nil {
+			return MessageRef{}, false, err
+		}
+
+		for _, msg := range res.Messages {
+			if matchMetadata(msg, metadata) {
+				return MessageRef{Channel: channel, ID: msg.TS}, true, nil
+			}
+		}
+
+		if cursor = res.NextCursor(); len(cursor) == 0 {
+			return MessageRef{}, false, nil
+		}
+	}
+}
+
+// matchMetadata reports whether the metadata of the message has the event type and every payload of the metadata.
+func matchMetadata(msg slack.Message, metadata Metadata) bool {
+	if msg.Metadata == nil || msg.Metadata.EventType != metadata.EventType {
+		return false
+	}
+
+	for k, v := range metadata.EventPayload {
+		if msg.Metadata.EventPayload[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *slackPlatform) UpdateMessage(ctx context.Context, ref MessageRef, msg Message) error {
+	_, err := p.client.UpdateMessage(ctx, slack.UpdateMessageRequest{
+		Channel: ref.Channel,
+		TS:      ref.ID,
+		Text:    msg.Text,
+		Blocks:  slackBlocks(msg.Sections),
+	})
+	return err
+}
+
+func (p *slackPlatform) DeleteMessage(ctx context.Context, ref MessageRef) error {
+	return p.client.DeleteMessage(ctx, ref.Channel, ref.ID)
+}
+
+func (p *slackPlatform) Permalink(ctx context.Context, ref MessageRef) (string, error) {
+	return p.client.GetPermalink(ctx, ref.Channel, ref.ID)
+}
+
+func (p *slackPlatform) PublishHome(ctx context.Context, userID string, home Home) error {
+	_, err := p.client.PublishView(ctx, userID, blocks.NewHomeView(slackBlocks(home.Sections)...))
+	return err
+}
+
+func (p *slackPlatform) OpenForm(ctx context.Context, triggerID string, form Form) error {
+	_, err := p.client.OpenView(ctx, triggerID, slackModal(form))
+	return err
+}
+
+func (p *slackPlatform) UserLocale(ctx context.Context, userID string) (string, error) {
+	user, err := p.client.UserInfo(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Locale, nil
+}
+
+/*
+slackBlocks converts sections to blocks in the order of text, notes, quote and actions.
+*/
+func slackBlocks(sections []Section) []blocks.Block {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	bs := []blocks.Block{}
+	for _, s := range sections {
+		if len(s.Text) != 0 {
+			bs = append(bs, blocks.NewSection(blocks.Markdown(s.Text)))
+		}
+
+		if len(s.Notes) != 0 {
+			elements := make([]blocks.ContextElement, 0, len(s.Notes))
+			for _, note := range s.Notes {
+				elements = append(elements, blocks.Markdown(note))
+			}
+			bs = append(bs, blocks.NewContext(elements...))
+		}
+
+		if len(s.Quote) != 0 {
+			bs = append(bs, blocks.NewContext(blocks.PlainText(s.Quote)))
+		}
+
+		if len(s.Actions) != 0 {
+			elements := make([]blocks.Element, 0, len(s.Actions))
+			for _, a := range s.Actions {
+				elements = append(elements, slackButton(a))
+			}
+			bs = append(bs, blocks.NewActions(elements...).WithBlockID(s.ID))
+		}
+	}
+	return bs
+}
+
+func slackButton(a Action) *blocks.Button {
+	button := blocks.NewButton(a.ID, a.Text, a.Value).WithStyle(a.Style)
+	if a.Confirm != nil {
+		button.WithConfirm(blocks.NewConfirm(a.Confirm.Title, a.Confirm.Text, a.Confirm.Submit, a.Confirm.Cancel).WithStyle(a.Confirm.Style))
+	}
+	return button
+}
+
+func slackModal(form Form) *blocks.View {
+	bs := []blocks.Block{}
+	for _, f := range form.Fields {
+		if len(f.Group) != 0 {
+			bs = append(bs, blocks.NewHeader(f.Group), blocks.NewDivider())
+		}
+		/* errors of the submission are keyed by the block ID */
+		bs = append(bs, blocks.NewInput(f.Label, slackElement(f)).WithBlockID(f.ID))
+	}
+
+	if len(form.Footnote) != 0 {
+		bs = append(bs, blocks.NewContext(blocks.PlainText(form.Footnote)))
+	}
+
+	return blocks.NewModal(form.Title, form.Submit, form.Cancel, bs...).WithPrivateMetadata(form.State).WithCallbackID(form.ID)
+}
+
+func slackElement(f Field) blocks.Element {
+	switch f.Type {
+	case FieldUsers:
+		return blocks.NewMultiUsersSelect(f.ID, f.Placeholder).WithInitialUsers(f.Values...)
+	case FieldDate:
+		picker := blocks.NewDatePicker(f.ID, f.Placeholder)
+		if len(f.Values) != 0 {
+			picker.WithInitialDate(f.Values[0])
+		}
+		return picker
+	default:
+		input := blocks.NewPlainTextInput(f.ID).WithMultiline(f.Multiline)
+		if len(f.Values) != 0 {
+			input.WithInitialValue(f.Values[0])
+		}
+		return input
+	}
+}
+
+func slackMetadata(m *Metadata) *slack.MessageMetadata {
+	if m == nil {
+		return nil
+	}
+	return &slack.MessageMetadata{EventType: m.EventType, EventPayload: m.EventPayload}
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"bitopi/internal/domain"
+	"bitopi/internal/repository/memory"
 	"bitopi/internal/repository/mysql"
+
+	"github.com/spf13/viper"
 )
 
 type Repo struct {
@@ -10,6 +13,10 @@ type Repo struct {
 }
 
 func NewRepo() (domain.Repository, error) {
+	if viper.GetString("repository.driver") == "memory" {
+		return NewMemoryRepo(), nil
+	}
+
 	mysqlDao, err := mysql.New()
 	if err != nil {
 		return nil, err
@@ -18,3 +25,8 @@ func NewRepo() (domain.Repository, error) {
 		MysqlDao: mysqlDao,
 	}, nil
 }
+
+// NewMemoryRepo creates an in-memory repository, all data is gone when the process exits.
+func NewMemoryRepo() domain.Repository {
+	return memory.New()
+}
@@ -0,0 +1,987 @@
+package memory
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type state struct {
+	members        []model.Member
+	admins         []model.Admin
+	startTimes     map[string]time.Time
+	settings       map[string]string
+	mentionRecords []model.MentionRecord
+	messages       map[string]model.BotMessage
+	subscribers    map[string]model.Subscriber
+	rosterVersions []model.RosterVersion
+	mentionEvents  []model.MentionEvent
+	directMessages []model.MentionDirectMessage
+	channelConfigs []model.ChannelConfig
+	auditLogs      []model.AuditLog
+	workspaces     []model.Workspace
+	webhooks       []model.Webhook
+	deliveries     []model.WebhookDelivery
+	pendingJobs    []model.PendingJob
+	outbox         []model.OutboxEntry
+	lastID         uint64
+}
+
+func (s state) copy() state {
+	c := state{
+		members:        append([]model.Member{}, s.members...),
+		admins:         append([]model.Admin{}, s.admins...),
+		startTimes:     make(map[string]time.Time, len(s.startTimes)),
+		settings:       make(map[string]string, len(s.settings)),
+		mentionRecords: append([]model.MentionRecord{}, s.mentionRecords...),
+		messages:       make(map[string]model.BotMessage, len(s.messages)),
+		subscribers:    make(map[string]model.Subscriber, len(s.subscribers)),
+		rosterVersions: append([]model.RosterVersion{}, s.rosterVersions...),
+		mentionEvents:  append([]model.MentionEvent{}, s.mentionEvents...),
+		directMessages: append([]model.MentionDirectMessage{}, s.directMessages...),
+		channelConfigs: append([]model.ChannelConfig{}, s.channelConfigs...),
+		auditLogs:      append([]model.AuditLog{}, s.auditLogs...),
+		workspaces:     append([]model.Workspace{}, s.workspaces...),
+		webhooks:       append([]model.Webhook{}, s.webhooks...),
+		deliveries:     append([]model.WebhookDelivery{}, s.deliveries...),
+		pendingJobs:    append([]model.PendingJob{}, s.pendingJobs...),
+		outbox:         append([]model.OutboxEntry{}, s.outbox...),
+		lastID:         s.lastID,
+	}
+	for k, v := range s.startTimes {
+		c.startTimes[k] = v
+	}
+	for k, v := range s.settings {
+		c.settings[k] = v
+	}
+	for k, v := range s.messages {
+		c.messages[k] = v
+	}
+	for k, v := range s.subscribers {
+		c.subscribers[k] = v
+	}
+	return c
+}
+
+/*
+MemoryDao is an in-memory repository for testing and local development.
+
+Tx runs exclusively and rolls back every change when fn returns an error.
+*/
+type MemoryDao struct {
+	mu *sync.Mutex
+	s  *state
+}
+
+func New() MemoryDao {
+	return MemoryDao{
+		mu: &sync.Mutex{},
+		s: &state{
+			startTimes:  map[string]time.Time{},
+			settings:    map[string]string{},
+			messages:    map[string]model.BotMessage{},
+			subscribers: map[string]model.Subscriber{},
+		},
+	}
+}
+
+// lock locks the dao when the context isn't in a transaction, which has locked the dao already.
+func (dao MemoryDao) lock(ctx context.Context) func() {
+	if inTx, _ := ctx.Value(txKey{}).(bool); inTx {
+		return func() {}
+	}
+	dao.mu.Lock()
+	return dao.mu.Unlock
+}
+
+/*
+scoped prefixes the key of maps with the team of the context, see model.WithTeam.
+*/
+func scoped(ctx context.Context, key string) string {
+	if teamID := model.TeamFrom(ctx); len(teamID) != 0 {
+		return teamID + "/" + key
+	}
+	return key
+}
+
+func (dao MemoryDao) nextID() uint64 {
+	dao.s.lastID++
+	return dao.s.lastID
+}
+
+// Ping always succeeds, there's no connection.
+func (dao MemoryDao) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SchemaVersion returns zeros, the memory repository has no migrations.
+func (dao MemoryDao) SchemaVersion(ctx context.Context) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (dao MemoryDao) Tx(ctx context.Context, fn func(context.Context) error) error {
+	if inTx, _ := ctx.Value(txKey{}).(bool); inTx {
+		return errors.New("multiple transaction")
+	}
+
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	backup := dao.s.copy()
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		*dao.s = backup
+		return err
+	}
+	return nil
+}
+
+func (dao MemoryDao) audit(ctx context.Context, service, action string, before, after interface{}) {
+	marshal := func(v interface{}) string {
+		if v == nil {
+			return ""
+		}
+		buf, _ := json.Marshal(v)
+		return string(buf)
+	}
+
+	actor := model.ActorFrom(ctx)
+	dao.s.auditLogs = append(dao.s.auditLogs, model.AuditLog{
+		ID:        dao.nextID(),
+		TeamID:    model.TeamFrom(ctx),
+		Service:   service,
+		Action:    action,
+		Actor:     actor.Name,
+		Source:    actor.Source,
+		Before:    marshal(before),
+		After:     marshal(after),
+		CreatedAt: time.Now(),
+	})
+}
+
+func (dao MemoryDao) GetMember(ctx context.Context, service string, userID string) (model.Member, error) {
+	defer dao.lock(ctx)()
+
+	for _, m := range dao.s.members {
+		if m.TeamID == model.TeamFrom(ctx) && m.Service == service && m.UserID == userID {
+			return m, nil
+		}
+	}
+	return model.Member{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) UpdateMember(ctx context.Context, member model.Member) error {
+	defer dao.lock(ctx)()
+
+	for i, m := range dao.s.members {
+		if m.TeamID == model.TeamFrom(ctx) && m.Service == member.Service && m.UserID == member.UserID {
+			member.ID = m.ID
+			member.TeamID = m.TeamID
+			dao.s.members[i] = member
+			dao.audit(ctx, member.Service, model.AuditActionUpdateMember, m, member)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) listMembers(teamID, service string) []model.Member {
+	members := []model.Member{}
+	for _, m := range dao.s.members {
+		if m.TeamID == teamID && m.Service == service {
+			members = append(members, m)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Order < members[j].Order
+	})
+	return members
+}
+
+func (dao MemoryDao) ListMembers(ctx context.Context, service string) ([]model.Member, error) {
+	defer dao.lock(ctx)()
+	return dao.listMembers(model.TeamFrom(ctx), service), nil
+}
+
+func (dao MemoryDao) ResetMembers(txCtx context.Context, service string, member []model.Member) error {
+	defer dao.lock(txCtx)()
+
+	teamID := model.TeamFrom(txCtx)
+	before := dao.listMembers(teamID, service)
+	kept := make([]model.Member, 0, len(dao.s.members))
+	for _, m := range dao.s.members {
+		if m.TeamID != teamID || m.Service != service {
+			kept = append(kept, m)
+		}
+	}
+
+	members := make([]model.Member, 0, len(member))
+	for i, m := range member {
+		members = append(members, model.Member{
+			ID:       dao.nextID(),
+			TeamID:   teamID,
+			UserID:   m.UserID,
+			UserName: m.UserName,
+			Order:    i,
+			Service:  service,
+		})
+	}
+
+	dao.s.members = append(kept, members...)
+	dao.audit(txCtx, service, model.AuditActionResetMembers, before, members)
+	return nil
+}
+
+func (dao MemoryDao) ListAllMembers(ctx context.Context) ([]model.Member, error) {
+	defer dao.lock(ctx)()
+
+	members := []model.Member{}
+	for _, m := range dao.s.members {
+		if m.TeamID == model.TeamFrom(ctx) {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+func (dao MemoryDao) IsAdmin(ctx context.Context, service, userID string) (bool, error) {
+	defer dao.lock(ctx)()
+
+	for _, a := range dao.s.admins {
+		if a.TeamID == model.TeamFrom(ctx) && a.Service == service && a.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (dao MemoryDao) ListAdmin(ctx context.Context, service string) ([]model.Admin, error) {
+	defer dao.lock(ctx)()
+
+	admins := []model.Admin{}
+	for _, a := range dao.s.admins {
+		if a.TeamID == model.TeamFrom(ctx) && a.Service == service {
+			admins = append(admins, a)
+		}
+	}
+	return admins, nil
+}
+
+func (dao MemoryDao) AddAdmin(ctx context.Context, admin model.Admin) error {
+	if admin.IsEmpty() {
+		return errors.New(fmt.Sprintf("empty admin, %+v", admin))
+	}
+
+	defer dao.lock(ctx)()
+	admin.ID = dao.nextID()
+	admin.TeamID = model.TeamFrom(ctx)
+	dao.s.admins = append(dao.s.admins, admin)
+	dao.audit(ctx, admin.Service, model.AuditActionAddAdmin, nil, admin)
+	return nil
+}
+
+func (dao MemoryDao) DeleteAdmin(ctx context.Context, service, userID string) error {
+	defer dao.lock(ctx)()
+
+	kept := make([]model.Admin, 0, len(dao.s.admins))
+	deleted := []model.Admin{}
+	for _, a := range dao.s.admins {
+		if a.TeamID == model.TeamFrom(ctx) && a.Service == service && a.UserID == userID {
+			deleted = append(deleted, a)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	dao.s.admins = kept
+	dao.audit(ctx, service, model.AuditActionDeleteAdmin, deleted, nil)
+	return nil
+}
+
+func (dao MemoryDao) GetStartDate(ctx context.Context, service string) (time.Time, error) {
+	defer dao.lock(ctx)()
+
+	t, ok := dao.s.startTimes[scoped(ctx, service)]
+	if !ok {
+		return time.Time{}, gorm.ErrRecordNotFound
+	}
+	return t, nil
+}
+
+func (dao MemoryDao) UpdateStartDate(txCtx context.Context, service string, t time.Time) error {
+	defer dao.lock(txCtx)()
+
+	var before interface{}
+	key := scoped(txCtx, service)
+	if b, ok := dao.s.startTimes[key]; ok {
+		before = b
+	}
+	dao.s.startTimes[key] = t
+	dao.audit(txCtx, service, model.AuditActionUpdateStartDate, before, t)
+	return nil
+}
+
+func (dao MemoryDao) SnapshotRoster(txCtx context.Context, service string, effectiveFrom time.Time) (model.RosterVersion, error) {
+	defer dao.lock(txCtx)()
+
+	duration, _ := time.ParseDuration(dao.s.settings[scoped(txCtx, strings.ToLower(service)+".duty.duration")])
+	count, _ := strconv.Atoi(dao.s.settings[scoped(txCtx, strings.ToLower(service)+".duty.member.count.per.time")])
+
+	version := model.RosterVersion{
+		ID:                 dao.nextID(),
+		TeamID:             model.TeamFrom(txCtx),
+		Service:            service,
+		StartDate:          dao.s.startTimes[scoped(txCtx, service)],
+		Duration:           duration,
+		MemberCountPerTime: count,
+		EffectiveFrom:      effectiveFrom,
+		CreatedAt:          time.Now(),
+	}
+	if err := version.SetMemberList(dao.listMembers(version.TeamID, service)); err != nil {
+		return model.RosterVersion{}, err
+	}
+
+	dao.s.rosterVersions = append(dao.s.rosterVersions, version)
+	return version, nil
+}
+
+func (dao MemoryDao) GetRosterVersion(ctx context.Context, service string, at time.Time) (model.RosterVersion, error) {
+	defer dao.lock(ctx)()
+
+	found := model.RosterVersion{}
+	for _, v := range dao.s.rosterVersions {
+		if v.TeamID != model.TeamFrom(ctx) || v.Service != service || v.EffectiveFrom.After(at) {
+			continue
+		}
+		if found.ID == 0 || v.EffectiveFrom.After(found.EffectiveFrom) ||
+			(v.EffectiveFrom.Equal(found.EffectiveFrom) && v.ID > found.ID) {
+			found = v
+		}
+	}
+	return found, nil
+}
+
+func (dao MemoryDao) ListRosterVersions(ctx context.Context, service string) ([]model.RosterVersion, error) {
+	defer dao.lock(ctx)()
+
+	versions := []model.RosterVersion{}
+	for _, v := range dao.s.rosterVersions {
+		if v.TeamID == model.TeamFrom(ctx) && v.Service == service {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].EffectiveFrom.Equal(versions[j].EffectiveFrom) {
+			return versions[i].ID > versions[j].ID
+		}
+		return versions[i].EffectiveFrom.After(versions[j].EffectiveFrom)
+	})
+	return versions, nil
+}
+
+// SetSetting sets the bot setting of the static token workspace, e.g. "maid.duty.duration".
+func (dao MemoryDao) SetSetting(key, value string) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+	dao.s.settings[key] = value
+}
+
+func (dao MemoryDao) GetDutyDuration(ctx context.Context, service string) (time.Duration, error) {
+	defer dao.lock(ctx)()
+
+	value, ok := dao.s.settings[scoped(ctx, strings.ToLower(service)+".duty.duration")]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return time.ParseDuration(value)
+}
+
+func (dao MemoryDao) GetDutyMemberCountPerTime(ctx context.Context, service string) (int, error) {
+	defer dao.lock(ctx)()
+
+	value, ok := dao.s.settings[scoped(ctx, strings.ToLower(service)+".duty.member.count.per.time")]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return strconv.Atoi(value)
+}
+
+func (dao MemoryDao) CountMentionRecord(ctx context.Context, service string) (int64, error) {
+	defer dao.lock(ctx)()
+
+	var count int64
+	for _, r := range dao.s.mentionRecords {
+		if r.TeamID == model.TeamFrom(ctx) && r.Service == service {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (dao MemoryDao) GetMentionRecord(ctx context.Context, id uint64) (model.MentionRecord, error) {
+	defer dao.lock(ctx)()
+
+	for _, r := range dao.s.mentionRecords {
+		if r.TeamID == model.TeamFrom(ctx) && r.ID == id {
+			return r, nil
+		}
+	}
+	return model.MentionRecord{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) FindOrCreateMentionRecord(txCtx context.Context, service, channel, timestamp string, dutyMembers []string) (uint64, bool, error) {
+	defer dao.lock(txCtx)()
+
+	for _, r := range dao.s.mentionRecords {
+		if r.TeamID == model.TeamFrom(txCtx) && r.Service == service && r.Channel == channel && r.Timestamp == timestamp {
+			return r.ID, true, nil
+		}
+	}
+
+	record := model.MentionRecord{
+		ID:          dao.nextID(),
+		TeamID:      model.TeamFrom(txCtx),
+		Service:     service,
+		Channel:     channel,
+		Timestamp:   timestamp,
+		DutyMembers: strings.Join(dutyMembers, ","),
+		Status:      model.MentionStatusOpen,
+		CreateAtu:   time.Now().Unix(),
+	}
+	dao.s.mentionRecords = append(dao.s.mentionRecords, record)
+	return record.ID, false, nil
+}
+
+func (dao MemoryDao) FindMentionRecord(ctx context.Context, service, channel, timestamp string) (model.MentionRecord, error) {
+	defer dao.lock(ctx)()
+
+	for _, r := range dao.s.mentionRecords {
+		if r.TeamID == model.TeamFrom(ctx) && r.Service == service && r.Channel == channel && r.Timestamp == timestamp {
+			return r, nil
+		}
+	}
+	return model.MentionRecord{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) UpdateMentionRecordStatus(ctx context.Context, id uint64, status string) error {
+	defer dao.lock(ctx)()
+
+	for i, r := range dao.s.mentionRecords {
+		if r.TeamID == model.TeamFrom(ctx) && r.ID == id {
+			dao.s.mentionRecords[i].Status = status
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) ListMentionEvents(ctx context.Context, mentionRecordID uint64) ([]model.MentionEvent, error) {
+	defer dao.lock(ctx)()
+
+	events := []model.MentionEvent{}
+	for _, e := range dao.s.mentionEvents {
+		if e.MentionRecordID == mentionRecordID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (dao MemoryDao) AddMentionEvent(txCtx context.Context, event model.MentionEvent) (bool, error) {
+	defer dao.lock(txCtx)()
+
+	for _, e := range dao.s.mentionEvents {
+		if e.MentionRecordID == event.MentionRecordID && e.Timestamp == event.Timestamp {
+			return true, nil
+		}
+	}
+
+	event.ID = dao.nextID()
+	event.CreateAtu = time.Now().Unix()
+	dao.s.mentionEvents = append(dao.s.mentionEvents, event)
+	return false, nil
+}
+
+func (dao MemoryDao) CreateMentionDirectMessage(ctx context.Context, dm model.MentionDirectMessage) error {
+	defer dao.lock(ctx)()
+
+	dm.ID = dao.nextID()
+	dm.CreateAtu = time.Now().Unix()
+	dao.s.directMessages = append(dao.s.directMessages, dm)
+	return nil
+}
+
+func (dao MemoryDao) ListMentionDirectMessages(ctx context.Context, mentionRecordID uint64) ([]model.MentionDirectMessage, error) {
+	defer dao.lock(ctx)()
+
+	dms := []model.MentionDirectMessage{}
+	for _, dm := range dao.s.directMessages {
+		if dm.MentionRecordID == mentionRecordID {
+			dms = append(dms, dm)
+		}
+	}
+	return dms, nil
+}
+
+func (dao MemoryDao) GetReplyMessage(ctx context.Context, service string) (model.BotMessage, error) {
+	defer dao.lock(ctx)()
+	return dao.s.messages[scoped(ctx, service)], nil
+}
+
+func (dao MemoryDao) SetReplyMessage(txCtx context.Context, msg model.BotMessage) error {
+	defer dao.lock(txCtx)()
+
+	key := scoped(txCtx, msg.Service)
+	before, ok := dao.s.messages[key]
+	if ok {
+		msg.ID = before.ID
+	} else {
+		msg.ID = dao.nextID()
+	}
+	msg.TeamID = model.TeamFrom(txCtx)
+	dao.s.messages[key] = msg
+
+	if ok {
+		dao.audit(txCtx, msg.Service, model.AuditActionSetReplyMessage, before, msg)
+	} else {
+		dao.audit(txCtx, msg.Service, model.AuditActionSetReplyMessage, nil, msg)
+	}
+	return nil
+}
+
+func (dao MemoryDao) GetSubscriber(ctx context.Context) ([]model.Subscriber, error) {
+	defer dao.lock(ctx)()
+
+	subscribers := make([]model.Subscriber, 0, len(dao.s.subscribers))
+	for _, sub := range dao.s.subscribers {
+		if sub.TeamID == model.TeamFrom(ctx) {
+			subscribers = append(subscribers, sub)
+		}
+	}
+	return subscribers, nil
+}
+
+func (dao MemoryDao) SetSubscriber(ctx context.Context, sub model.Subscriber) error {
+	defer dao.lock(ctx)()
+
+	sub.TeamID = model.TeamFrom(ctx)
+	dao.s.subscribers[scoped(ctx, sub.UserID)] = sub
+	dao.audit(ctx, "", model.AuditActionSetSubscriber, nil, sub)
+	return nil
+}
+
+func (dao MemoryDao) DeleteSubscriber(ctx context.Context, sub model.Subscriber) error {
+	defer dao.lock(ctx)()
+
+	delete(dao.s.subscribers, scoped(ctx, sub.UserID))
+	dao.audit(ctx, "", model.AuditActionDeleteSubscriber, sub, nil)
+	return nil
+}
+
+func (dao MemoryDao) ListChannelConfigs(ctx context.Context, service string) ([]model.ChannelConfig, error) {
+	defer dao.lock(ctx)()
+
+	cfgs := []model.ChannelConfig{}
+	for _, cfg := range dao.s.channelConfigs {
+		if cfg.TeamID == model.TeamFrom(ctx) && cfg.Service == service {
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	return cfgs, nil
+}
+
+func (dao MemoryDao) SetChannelConfig(txCtx context.Context, cfg model.ChannelConfig) error {
+	defer dao.lock(txCtx)()
+
+	cfg.TeamID = model.TeamFrom(txCtx)
+	cfg.UpdatedAt = time.Now()
+	for i, c := range dao.s.channelConfigs {
+		if c.TeamID == cfg.TeamID && c.Service == cfg.Service && c.Channel == cfg.Channel {
+			cfg.ID = c.ID
+			dao.s.channelConfigs[i] = cfg
+			dao.audit(txCtx, cfg.Service, model.AuditActionSetChannel, c, cfg)
+			return nil
+		}
+	}
+
+	cfg.ID = dao.nextID()
+	dao.s.channelConfigs = append(dao.s.channelConfigs, cfg)
+	dao.audit(txCtx, cfg.Service, model.AuditActionSetChannel, nil, cfg)
+	return nil
+}
+
+func (dao MemoryDao) DeleteChannelConfig(txCtx context.Context, service, channel string) error {
+	defer dao.lock(txCtx)()
+
+	for i, c := range dao.s.channelConfigs {
+		if c.TeamID == model.TeamFrom(txCtx) && c.Service == service && c.Channel == channel {
+			dao.s.channelConfigs = append(dao.s.channelConfigs[:i], dao.s.channelConfigs[i+1:]...)
+			dao.audit(txCtx, service, model.AuditActionDeleteChannel, c, nil)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (dao MemoryDao) ListAuditLogs(ctx context.Context, service string, limit int) ([]model.AuditLog, error) {
+	defer dao.lock(ctx)()
+
+	logs := []model.AuditLog{}
+	for i := len(dao.s.auditLogs) - 1; i >= 0; i-- {
+		if limit > 0 && len(logs) >= limit {
+			break
+		}
+		if dao.s.auditLogs[i].TeamID == model.TeamFrom(ctx) && dao.s.auditLogs[i].Service == service {
+			logs = append(logs, dao.s.auditLogs[i])
+		}
+	}
+	return logs, nil
+}
+
+func (dao MemoryDao) GetWorkspace(ctx context.Context, service, teamID string) (model.Workspace, error) {
+	defer dao.lock(ctx)()
+
+	for _, ws := range dao.s.workspaces {
+		if ws.Service == service && ws.TeamID == teamID {
+			return ws, nil
+		}
+	}
+	return model.Workspace{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) ListWorkspaces(ctx context.Context, service string) ([]model.Workspace, error) {
+	defer dao.lock(ctx)()
+
+	workspaces := []model.Workspace{}
+	for _, ws := range dao.s.workspaces {
+		if ws.Service == service {
+			workspaces = append(workspaces, ws)
+		}
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].TeamID < workspaces[j].TeamID
+	})
+	return workspaces, nil
+}
+
+func (dao MemoryDao) SaveWorkspace(txCtx context.Context, ws model.Workspace) error {
+	defer dao.lock(txCtx)()
+
+	ws.UpdatedAt = time.Now()
+	for i, w := range dao.s.workspaces {
+		if w.Service == ws.Service && w.TeamID == ws.TeamID {
+			ws.ID = w.ID
+			ws.InstalledAt = w.InstalledAt
+			dao.s.workspaces[i] = ws
+			dao.audit(txCtx, ws.Service, model.AuditActionSaveWorkspace, w, ws)
+			return nil
+		}
+	}
+
+	ws.ID = dao.nextID()
+	ws.InstalledAt = ws.UpdatedAt
+	dao.s.workspaces = append(dao.s.workspaces, ws)
+	dao.audit(txCtx, ws.Service, model.AuditActionSaveWorkspace, nil, ws)
+	return nil
+}
+
+func (dao MemoryDao) ListWebhooks(ctx context.Context, service string) ([]model.Webhook, error) {
+	defer dao.lock(ctx)()
+
+	hooks := []model.Webhook{}
+	for _, h := range dao.s.webhooks {
+		if h.TeamID == model.TeamFrom(ctx) && h.Service == service {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks, nil
+}
+
+func (dao MemoryDao) GetWebhook(ctx context.Context, service string, id uint64) (model.Webhook, error) {
+	defer dao.lock(ctx)()
+
+	for _, h := range dao.s.webhooks {
+		if h.TeamID == model.TeamFrom(ctx) && h.Service == service && h.ID == id {
+			return h, nil
+		}
+	}
+	return model.Webhook{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) SaveWebhook(txCtx context.Context, hook model.Webhook) (uint64, error) {
+	defer dao.lock(txCtx)()
+
+	hook.TeamID = model.TeamFrom(txCtx)
+	hook.UpdatedAt = time.Now()
+	if hook.ID == 0 {
+		hook.ID = dao.nextID()
+		hook.CreatedAt = hook.UpdatedAt
+		dao.s.webhooks = append(dao.s.webhooks, hook)
+		dao.audit(txCtx, hook.Service, model.AuditActionSaveWebhook, nil, hook)
+		return hook.ID, nil
+	}
+
+	for i, h := range dao.s.webhooks {
+		if h.TeamID == hook.TeamID && h.Service == hook.Service && h.ID == hook.ID {
+			hook.CreatedAt = h.CreatedAt
+			dao.s.webhooks[i] = hook
+			dao.audit(txCtx, hook.Service, model.AuditActionSaveWebhook, h, hook)
+			return hook.ID, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) DeleteWebhook(txCtx context.Context, service string, id uint64) error {
+	defer dao.lock(txCtx)()
+
+	for i, h := range dao.s.webhooks {
+		if h.TeamID == model.TeamFrom(txCtx) && h.Service == service && h.ID == id {
+			dao.s.webhooks = append(dao.s.webhooks[:i], dao.s.webhooks[i+1:]...)
+			dao.audit(txCtx, service, model.AuditActionDeleteWebhook, h, nil)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (dao MemoryDao) CreateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) (uint64, error) {
+	defer dao.lock(ctx)()
+
+	delivery.ID = dao.nextID()
+	delivery.TeamID = model.TeamFrom(ctx)
+	delivery.CreatedAt = time.Now()
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = delivery.CreatedAt
+	}
+	dao.s.deliveries = append(dao.s.deliveries, delivery)
+	return delivery.ID, nil
+}
+
+func (dao MemoryDao) UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	defer dao.lock(ctx)()
+
+	for i, d := range dao.s.deliveries {
+		if d.TeamID == model.TeamFrom(ctx) && d.ID == delivery.ID {
+			d.Status = delivery.Status
+			d.Attempts = delivery.Attempts
+			d.ResponseStatus = delivery.ResponseStatus
+			d.Error = delivery.Error
+			d.DeliveredAt = delivery.DeliveredAt
+			d.NextAttemptAt = delivery.NextAttemptAt
+			dao.s.deliveries[i] = d
+			return nil
+		}
+	}
+	return nil
+}
+
+func (dao MemoryDao) ListDueWebhookDeliveries(ctx context.Context, service string, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	defer dao.lock(ctx)()
+
+	deliveries := []model.WebhookDelivery{}
+	for _, d := range dao.s.deliveries {
+		if limit > 0 && len(deliveries) >= limit {
+			break
+		}
+		if d.Service == service && d.Status == model.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (dao MemoryDao) ClaimWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery, until time.Time) (bool, error) {
+	defer dao.lock(ctx)()
+
+	for i, d := range dao.s.deliveries {
+		if d.ID == delivery.ID {
+			if d.Status != model.WebhookDeliveryPending || !d.NextAttemptAt.Equal(delivery.NextAttemptAt) {
+				return false, nil
+			}
+			dao.s.deliveries[i].NextAttemptAt = until
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (dao MemoryDao) GetWebhookDelivery(ctx context.Context, service string, id uint64) (model.WebhookDelivery, error) {
+	defer dao.lock(ctx)()
+
+	for _, d := range dao.s.deliveries {
+		if d.TeamID == model.TeamFrom(ctx) && d.Service == service && d.ID == id {
+			return d, nil
+		}
+	}
+	return model.WebhookDelivery{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) ListWebhookDeliveries(ctx context.Context, service string, webhookID uint64, limit int) ([]model.WebhookDelivery, error) {
+	defer dao.lock(ctx)()
+
+	deliveries := []model.WebhookDelivery{}
+	for i := len(dao.s.deliveries) - 1; i >= 0; i-- {
+		if limit > 0 && len(deliveries) >= limit {
+			break
+		}
+		d := dao.s.deliveries[i]
+		if d.TeamID == model.TeamFrom(ctx) && d.Service == service && d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (dao MemoryDao) SavePendingJobs(ctx context.Context, jobs []model.PendingJob) error {
+	defer dao.lock(ctx)()
+
+	for _, job := range jobs {
+		job.ID = dao.nextID()
+		job.CreatedAt = time.Now()
+		dao.s.pendingJobs = append(dao.s.pendingJobs, job)
+	}
+	return nil
+}
+
+func (dao MemoryDao) TakePendingJobs(txCtx context.Context, service string) ([]model.PendingJob, error) {
+	defer dao.lock(txCtx)()
+
+	jobs, rest := []model.PendingJob{}, []model.PendingJob{}
+	for _, job := range dao.s.pendingJobs {
+		if job.Service == service {
+			jobs = append(jobs, job)
+		} else {
+			rest = append(rest, job)
+		}
+	}
+	dao.s.pendingJobs = rest
+	return jobs, nil
+}
+
+func (dao MemoryDao) CreateOutboxEntries(txCtx context.Context, entries []model.OutboxEntry) error {
+	defer dao.lock(txCtx)()
+
+	now := time.Now()
+	for _, entry := range entries {
+		if _, ok := dao.outboxEntry(entry.IdempotencyKey); ok {
+			continue
+		}
+		entry.ID = dao.nextID()
+		entry.Status = model.OutboxPending
+		entry.NextAttemptAt = now
+		entry.CreatedAt = now
+		entry.UpdatedAt = now
+		dao.s.outbox = append(dao.s.outbox, entry)
+	}
+	return nil
+}
+
+func (dao MemoryDao) ListDueOutboxEntries(ctx context.Context, service string, now time.Time, limit int) ([]model.OutboxEntry, error) {
+	defer dao.lock(ctx)()
+
+	entries := []model.OutboxEntry{}
+	for _, e := range dao.s.outbox {
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		if e.Service == service && e.Status == model.OutboxPending && !e.NextAttemptAt.After(now) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (dao MemoryDao) ClaimOutboxEntry(ctx context.Context, entry model.OutboxEntry, until time.Time) (bool, error) {
+	defer dao.lock(ctx)()
+
+	i, ok := dao.outboxEntry(entry.IdempotencyKey)
+	if !ok || dao.s.outbox[i].Status != model.OutboxPending || !dao.s.outbox[i].NextAttemptAt.Equal(entry.NextAttemptAt) {
+		return false, nil
+	}
+	dao.s.outbox[i].NextAttemptAt = until
+	dao.s.outbox[i].UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (dao MemoryDao) UpdateOutboxEntry(ctx context.Context, entry model.OutboxEntry) error {
+	defer dao.lock(ctx)()
+
+	for i, e := range dao.s.outbox {
+		if e.ID == entry.ID {
+			e.Status = entry.Status
+			e.Attempts = entry.Attempts
+			e.LastError = entry.LastError
+			e.Result = entry.Result
+			e.NextAttemptAt = entry.NextAttemptAt
+			e.UpdatedAt = time.Now()
+			dao.s.outbox[i] = e
+			return nil
+		}
+	}
+	return nil
+}
+
+func (dao MemoryDao) GetOutboxEntry(ctx context.Context, key string) (model.OutboxEntry, error) {
+	defer dao.lock(ctx)()
+
+	if i, ok := dao.outboxEntry(key); ok {
+		return dao.s.outbox[i], nil
+	}
+	return model.OutboxEntry{}, gorm.ErrRecordNotFound
+}
+
+func (dao MemoryDao) outboxEntry(key string) (int, bool) {
+	for i, e := range dao.s.outbox {
+		if e.IdempotencyKey == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (dao MemoryDao) ListOutboxEntries(ctx context.Context, mentionRecordID uint64) ([]model.OutboxEntry, error) {
+	defer dao.lock(ctx)()
+
+	entries := []model.OutboxEntry{}
+	for _, e := range dao.s.outbox {
+		if e.MentionRecordID == mentionRecordID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (dao MemoryDao) OutboxBacklog(ctx context.Context, service string) (model.OutboxBacklog, error) {
+	defer dao.lock(ctx)()
+
+	backlog := model.OutboxBacklog{}
+	for _, e := range dao.s.outbox {
+		if e.Service != service {
+			continue
+		}
+		switch e.Status {
+		case model.OutboxPending:
+			backlog.Pending++
+			if backlog.OldestPending == nil || e.CreatedAt.Before(*backlog.OldestPending) {
+				createdAt := e.CreatedAt
+				backlog.OldestPending = &createdAt
+			}
+		case model.OutboxFailed:
+			backlog.Failed++
+		}
+	}
+	return backlog, nil
+}
@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	_defaultAuditLogLimit = 50
+)
+
+/*
+audit runs fn and writes an audit log with the actor from context in the same transaction.
+
+fn returns the snapshot after the change, and before is the snapshot before the change.
+*/
+func (dao MysqlDao) audit(ctx context.Context, service, action string, fn func(tx *gorm.DB) (before, after interface{}, err error)) error {
+	exec := func(tx *gorm.DB) error {
+		before, after, err := fn(tx)
+		if err != nil {
+			return err
+		}
+
+		beforeJSON, err := marshalSnapshot(before)
+		if err != nil {
+			return errors.Wrap(err, "marshal before snapshot")
+		}
+
+		afterJSON, err := marshalSnapshot(after)
+		if err != nil {
+			return errors.Wrap(err, "marshal after snapshot")
+		}
+
+		actor := model.ActorFrom(ctx)
+		return tx.Create(&model.AuditLog{
+			TeamID:    model.TeamFrom(ctx),
+			Service:   service,
+			Action:    action,
+			Actor:     actor.Name,
+			Source:    actor.Source,
+			Before:    beforeJSON,
+			After:     afterJSON,
+			CreatedAt: time.Now(),
+		}).Error
+	}
+
+	if tx, ok := ctx.Value(_driverKey).(*gorm.DB); ok && tx != nil {
+		return exec(tx)
+	}
+
+	return dao.db.Transaction(exec)
+}
+
+func marshalSnapshot(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (dao MysqlDao) ListAuditLogs(ctx context.Context, service string, limit int) ([]model.AuditLog, error) {
+	if limit <= 0 {
+		limit = _defaultAuditLogLimit
+	}
+
+	logs := []model.AuditLog{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Order("`created_at` DESC").
+		Order("`id` DESC").
+		Limit(limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
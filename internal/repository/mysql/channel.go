@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (dao MysqlDao) ListChannelConfigs(ctx context.Context, service string) ([]model.ChannelConfig, error) {
+	cfgs := []model.ChannelConfig{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Order("`channel`").
+		Find(&cfgs).Error
+	if err != nil {
+		return nil, err
+	}
+	return cfgs, nil
+}
+
+func (dao MysqlDao) SetChannelConfig(txCtx context.Context, cfg model.ChannelConfig) error {
+	return dao.audit(txCtx, cfg.Service, model.AuditActionSetChannel, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		cfg.TeamID = model.TeamFrom(txCtx)
+		cfg.UpdatedAt = time.Now()
+
+		before := model.ChannelConfig{}
+		err := tx.Scopes(team(txCtx)).Where("`service` = ?", cfg.Service).
+			Where("`channel` = ?", cfg.Channel).
+			First(&before).Error
+		if notFound(err) {
+			if err := tx.Create(&cfg).Error; err != nil {
+				return nil, nil, err
+			}
+			return nil, cfg, nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cfg.ID = before.ID
+		if err := tx.Save(&cfg).Error; err != nil {
+			return nil, nil, err
+		}
+		return before, cfg, nil
+	})
+}
+
+func (dao MysqlDao) DeleteChannelConfig(txCtx context.Context, service, channel string) error {
+	return dao.audit(txCtx, service, model.AuditActionDeleteChannel, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		before := []model.ChannelConfig{}
+		if err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			Where("`channel` = ?", channel).
+			Find(&before).Error; err != nil {
+			return nil, nil, err
+		}
+
+		err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			Where("`channel` = ?", channel).
+			Delete(&model.ChannelConfig{}).Error
+		if err != nil && !notFound(err) {
+			return nil, nil, err
+		}
+		return before, nil, nil
+	})
+}
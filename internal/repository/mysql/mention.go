@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (dao MysqlDao) FindMentionRecord(ctx context.Context, service, channel, timestamp string) (model.MentionRecord, error) {
+	record := model.MentionRecord{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Where("`channel` = ?", channel).
+		Where("`timestamp` = ?", timestamp).
+		First(&record).Error
+	if err != nil {
+		return model.MentionRecord{}, err
+	}
+	return record, nil
+}
+
+func (dao MysqlDao) UpdateMentionRecordStatus(ctx context.Context, id uint64, status string) error {
+	return dao.GetDriver(ctx).Scopes(team(ctx)).
+		Model(&model.MentionRecord{}).
+		Where("`id` = ?", id).
+		Update("status", status).Error
+}
+
+func (dao MysqlDao) ListMentionEvents(ctx context.Context, mentionRecordID uint64) ([]model.MentionEvent, error) {
+	events := []model.MentionEvent{}
+	err := dao.GetDriver(ctx).
+		Where("`mention_record_id` = ?", mentionRecordID).
+		Order("`id`").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (dao MysqlDao) AddMentionEvent(txCtx context.Context, event model.MentionEvent) (bool, error) {
+	tx := dao.GetDriver(txCtx)
+
+	count := int64(0)
+	err := tx.Model(&model.MentionEvent{}).
+		Where("`mention_record_id` = ?", event.MentionRecordID).
+		Where("`timestamp` = ?", event.Timestamp).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, "query")
+	}
+
+	if count != 0 {
+		return true, nil
+	}
+
+	event.CreateAtu = time.Now().Unix()
+	if err := tx.Create(&event).Error; err != nil {
+		return false, errors.Wrap(err, "create")
+	}
+	return false, nil
+}
+
+func (dao MysqlDao) CreateMentionDirectMessage(ctx context.Context, dm model.MentionDirectMessage) error {
+	dm.CreateAtu = time.Now().Unix()
+	return dao.GetDriver(ctx).Create(&dm).Error
+}
+
+func (dao MysqlDao) ListMentionDirectMessages(ctx context.Context, mentionRecordID uint64) ([]model.MentionDirectMessage, error) {
+	dms := []model.MentionDirectMessage{}
+	err := dao.GetDriver(ctx).
+		Where("`mention_record_id` = ?", mentionRecordID).
+		Order("`id`").
+		Find(&dms).Error
+	if err != nil {
+		return nil, err
+	}
+	return dms, nil
+}
@@ -0,0 +1,722 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type migration struct {
+	Version int
+	Name    string
+	Up      func(db *gorm.DB) error
+	Down    func(db *gorm.DB) error
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+/*
+_migrations lists every schema change in order.
+
+Never edit a released migration, append a new one instead. Migrations declare the schema of their version
+instead of using the models, which keep changing after the migration is released.
+*/
+var _migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create_baseline_tables",
+		Up: func(db *gorm.DB) error {
+			type member struct {
+				ID       uint64 `gorm:"column:id;autoIncrement;primaryKey"`
+				UserID   string `gorm:"column:user_id;size:50"`
+				UserName string `gorm:"column:user_name;size:50"`
+				Order    int    `gorm:"column:order"`
+				Service  string `gorm:"column:service;size:50"`
+			}
+			type startTime struct {
+				ID        uint64    `gorm:"column:id;autoIncrement"`
+				Service   string    `gorm:"column:service;size:50"`
+				StartTime time.Time `gorm:"column:start_time"`
+			}
+			type mentionRecord struct {
+				ID        uint64 `gorm:"column:id;autoIncrement"`
+				Service   string `gorm:"column:service;size:50;index;not null"`
+				Channel   string `gorm:"column:channel;size:50;index;not null"`
+				Timestamp string `gorm:"column:timestamp;size:50;index;not null"`
+				CreateAtu int64  `gorm:"column:create_atu;not null"`
+			}
+			type botMessage struct {
+				ID                 uint64 `gorm:"column:id;autoIncrement;primaryKey"`
+				Service            string `gorm:"column:service;size:50"`
+				MentionMessage     string `gorm:"column:mention_message;size:255"`
+				MentionMultiMember bool   `gorm:"column:mention_multi_member;not null"`
+				HomeMentionMessage string `gorm:"column:home_mention_message;size:255"`
+				DoneReplyMessage   string `gorm:"column:done_reply_message;size:255"`
+			}
+			type admin struct {
+				ID       uint64 `gorm:"column:id;autoIncrement"`
+				UserID   string `gorm:"column:user_id;size:50"`
+				UserName string `gorm:"column:user_name;size:50"`
+				Service  string `gorm:"column:service;size:50"`
+			}
+			type subscriber struct {
+				UserID   string `gorm:"column:user_id;size:50;primaryKey"`
+				UserName string `gorm:"column:user_name;size:50"`
+				Home     bool   `gorm:"column:home;size:50;not null;default:false"`
+			}
+			type botSetting struct {
+				ID    uint64 `gorm:"column:id;autoIncrement"`
+				Key   string `gorm:"column:key"`
+				Value string `gorm:"column:value"`
+			}
+			return createTables(db, map[string]interface{}{
+				"slack_bot_members":         &member{},
+				"slack_bot_start_time":      &startTime{},
+				"slack_bot_mention_records": &mentionRecord{},
+				"slack_bot_messages":        &botMessage{},
+				"slack_bot_admins":          &admin{},
+				"slack_bot_subscribers":     &subscriber{},
+				"slack_bot_settings":        &botSetting{},
+			})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(
+				"slack_bot_members",
+				"slack_bot_start_time",
+				"slack_bot_mention_records",
+				"slack_bot_messages",
+				"slack_bot_admins",
+				"slack_bot_subscribers",
+				"slack_bot_settings",
+			)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_mention_record_unique_key",
+		Up: func(db *gorm.DB) error {
+			/* keep the earliest record of duplicated mentions before adding the unique key */
+			if err := db.Exec("DELETE `r1` FROM `slack_bot_mention_records` `r1` " +
+				"JOIN `slack_bot_mention_records` `r2` " +
+				"ON `r1`.`service` = `r2`.`service` AND `r1`.`channel` = `r2`.`channel` AND `r1`.`timestamp` = `r2`.`timestamp` " +
+				"AND `r1`.`id` > `r2`.`id`").Error; err != nil {
+				return errors.Wrap(err, "delete duplicated mention records")
+			}
+			return createIndex(db, "slack_bot_mention_records", "uk_mention_records_service_channel_timestamp", true, "service", "channel", "timestamp")
+		},
+		Down: func(db *gorm.DB) error {
+			return dropIndex(db, "slack_bot_mention_records", "uk_mention_records_service_channel_timestamp")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add_service_indexes",
+		Up: func(db *gorm.DB) error {
+			if err := createIndex(db, "slack_bot_members", "idx_members_service_order", false, "service", "order"); err != nil {
+				return err
+			}
+			if err := createIndex(db, "slack_bot_admins", "idx_admins_service_user_id", false, "service", "user_id"); err != nil {
+				return err
+			}
+			if err := createIndex(db, "slack_bot_start_time", "idx_start_time_service", false, "service"); err != nil {
+				return err
+			}
+			if err := createIndex(db, "slack_bot_messages", "idx_messages_service", false, "service"); err != nil {
+				return err
+			}
+			return createIndex(db, "slack_bot_settings", "idx_settings_key", false, "key(100)")
+		},
+		Down: func(db *gorm.DB) error {
+			if err := dropIndex(db, "slack_bot_settings", "idx_settings_key"); err != nil {
+				return err
+			}
+			if err := dropIndex(db, "slack_bot_messages", "idx_messages_service"); err != nil {
+				return err
+			}
+			if err := dropIndex(db, "slack_bot_start_time", "idx_start_time_service"); err != nil {
+				return err
+			}
+			if err := dropIndex(db, "slack_bot_admins", "idx_admins_service_user_id"); err != nil {
+				return err
+			}
+			return dropIndex(db, "slack_bot_members", "idx_members_service_order")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_audit_logs",
+		Up: func(db *gorm.DB) error {
+			type auditLog struct {
+				ID        uint64    `gorm:"column:id;autoIncrement;primaryKey"`
+				Service   string    `gorm:"column:service;size:50;index:idx_audit_logs_service_created_at,priority:1"`
+				Action    string    `gorm:"column:action;size:50;not null"`
+				Actor     string    `gorm:"column:actor;size:50;not null"`
+				Source    string    `gorm:"column:source;size:20;not null"`
+				Before    string    `gorm:"column:before;type:text"`
+				After     string    `gorm:"column:after;type:text"`
+				CreatedAt time.Time `gorm:"column:created_at;not null;index:idx_audit_logs_service_created_at,priority:2"`
+			}
+			return createTables(db, map[string]interface{}{"slack_bot_audit_logs": &auditLog{}})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("slack_bot_audit_logs")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create_roster_versions",
+		Up: func(db *gorm.DB) error {
+			type rosterVersion struct {
+				ID                 uint64        `gorm:"column:id;autoIncrement;primaryKey"`
+				Service            string        `gorm:"column:service;size:50;not null;index:idx_roster_versions_service_effective_from,priority:1"`
+				Members            string        `gorm:"column:members;type:text"`
+				StartDate          time.Time     `gorm:"column:start_date;not null"`
+				Duration           time.Duration `gorm:"column:duration;not null"`
+				MemberCountPerTime int           `gorm:"column:member_count_per_time;not null"`
+				EffectiveFrom      time.Time     `gorm:"column:effective_from;not null;index:idx_roster_versions_service_effective_from,priority:2"`
+				CreatedAt          time.Time     `gorm:"column:created_at;not null"`
+			}
+			type mentionRecord struct {
+				DutyMembers string `gorm:"column:duty_members;size:255"`
+			}
+			return createTables(db, map[string]interface{}{
+				"slack_bot_roster_versions": &rosterVersion{},
+				"slack_bot_mention_records": &mentionRecord{},
+			})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := dropColumn(db, "slack_bot_mention_records", "duty_members"); err != nil {
+				return err
+			}
+			return db.Migrator().DropTable("slack_bot_roster_versions")
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_mention_threads",
+		Up: func(db *gorm.DB) error {
+			type mentionEvent struct {
+				ID              uint64 `gorm:"column:id;autoIncrement"`
+				MentionRecordID uint64 `gorm:"column:mention_record_id;not null;uniqueIndex:uk_mention_events_record_timestamp,priority:1"`
+				Timestamp       string `gorm:"column:timestamp;size:50;not null;uniqueIndex:uk_mention_events_record_timestamp,priority:2"`
+				User            string `gorm:"column:user;size:50"`
+				Text            string `gorm:"column:text;type:text"`
+				CreateAtu       int64  `gorm:"column:create_atu;not null"`
+			}
+			type mentionDirectMessage struct {
+				ID              uint64 `gorm:"column:id;autoIncrement"`
+				MentionRecordID uint64 `gorm:"column:mention_record_id;not null;index"`
+				UserID          string `gorm:"column:user_id;size:50;not null"`
+				Channel         string `gorm:"column:channel;size:50;not null"`
+				Timestamp       string `gorm:"column:timestamp;size:50;not null"`
+				CreateAtu       int64  `gorm:"column:create_atu;not null"`
+			}
+			return createTables(db, map[string]interface{}{
+				"slack_bot_mention_events":          &mentionEvent{},
+				"slack_bot_mention_direct_messages": &mentionDirectMessage{},
+			})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("slack_bot_mention_events", "slack_bot_mention_direct_messages")
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add_mention_status",
+		Up: func(db *gorm.DB) error {
+			type mentionRecord struct {
+				Status string `gorm:"column:status;size:20;not null;default:open"`
+			}
+			type mentionDirectMessage struct {
+				Header string `gorm:"column:header;type:text"`
+			}
+			return createTables(db, map[string]interface{}{
+				"slack_bot_mention_records":         &mentionRecord{},
+				"slack_bot_mention_direct_messages": &mentionDirectMessage{},
+			})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := dropColumn(db, "slack_bot_mention_direct_messages", "header"); err != nil {
+				return err
+			}
+			return dropColumn(db, "slack_bot_mention_records", "status")
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create_channel_configs",
+		Up: func(db *gorm.DB) error {
+			type channelConfig struct {
+				ID           uint64    `gorm:"column:id;autoIncrement;primaryKey"`
+				Service      string    `gorm:"column:service;size:50;not null;uniqueIndex:uk_channel_configs_service_channel,priority:1"`
+				Channel      string    `gorm:"column:channel;size:50;not null;uniqueIndex:uk_channel_configs_service_channel,priority:2"`
+				Access       string    `gorm:"column:access;size:10;not null;default:''"`
+				ReplyMessage string    `gorm:"column:reply_message;type:text"`
+				Notify       string    `gorm:"column:notify;size:255"`
+				Silent       bool      `gorm:"column:silent;not null;default:false"`
+				UpdatedAt    time.Time `gorm:"column:updated_at;not null"`
+			}
+			return createTables(db, map[string]interface{}{"slack_bot_channel_configs": &channelConfig{}})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("slack_bot_channel_configs")
+		},
+	},
+	{
+		Version: 9,
+		Name:    "scope_by_workspace",
+		Up: func(db *gorm.DB) error {
+			type teamScoped struct {
+				TeamID string `gorm:"column:team_id;size:20;not null;default:''"`
+			}
+			type workspace struct {
+				ID          uint64    `gorm:"column:id;autoIncrement;primaryKey"`
+				Service     string    `gorm:"column:service;size:50;not null;uniqueIndex:uk_workspaces_service_team_id,priority:1"`
+				TeamID      string    `gorm:"column:team_id;size:20;not null;uniqueIndex:uk_workspaces_service_team_id,priority:2"`
+				TeamName    string    `gorm:"column:team_name;size:255"`
+				BotToken    string    `gorm:"column:bot_token;size:255;not null"`
+				BotUserID   string    `gorm:"column:bot_user_id;size:50"`
+				Scope       string    `gorm:"column:scope;type:text"`
+				InstalledBy string    `gorm:"column:installed_by;size:50"`
+				InstalledAt time.Time `gorm:"column:installed_at;not null"`
+				UpdatedAt   time.Time `gorm:"column:updated_at;not null"`
+			}
+
+			/* existing rows belong to the workspace of the static token, which has empty team ID */
+			tables := map[string]interface{}{"slack_bot_workspaces": &workspace{}}
+			for _, table := range _teamScopedTables {
+				tables[table] = &teamScoped{}
+			}
+			if err := createTables(db, tables); err != nil {
+				return err
+			}
+			if err := createIndex(db, "slack_bot_channel_configs", "uk_channel_configs_team_service_channel", true, "team_id", "service", "channel"); err != nil {
+				return err
+			}
+			if err := dropIndex(db, "slack_bot_channel_configs", "uk_channel_configs_service_channel"); err != nil {
+				return err
+			}
+			if err := createIndex(db, "slack_bot_mention_records", "uk_mention_records_team_service_channel_timestamp", true, "team_id", "service", "channel", "timestamp"); err != nil {
+				return err
+			}
+			return dropIndex(db, "slack_bot_mention_records", "uk_mention_records_service_channel_timestamp")
+		},
+		Down: func(db *gorm.DB) error {
+			/*
+				the schema before has only the workspace of the static token, rows of the installed workspaces
+				are removed with the workspaces, or they break the unique keys of service and channel.
+			*/
+			for _, table := range []string{"slack_bot_mention_events", "slack_bot_mention_direct_messages"} {
+				if err := db.Exec("DELETE `t` FROM `" + table + "` `t` " +
+					"JOIN `slack_bot_mention_records` `r` ON `t`.`mention_record_id` = `r`.`id` " +
+					"WHERE `r`.`team_id` <> ''").Error; err != nil {
+					return errors.Wrapf(err, "delete %s of installed workspaces", table)
+				}
+			}
+			for _, table := range _teamScopedTables {
+				if !db.Migrator().HasColumn(table, "team_id") {
+					continue
+				}
+				if err := db.Exec("DELETE FROM `" + table + "` WHERE `team_id` <> ''").Error; err != nil {
+					return errors.Wrapf(err, "delete %s of installed workspaces", table)
+				}
+			}
+
+			if err := createIndex(db, "slack_bot_mention_records", "uk_mention_records_service_channel_timestamp", true, "service", "channel", "timestamp"); err != nil {
+				return err
+			}
+			if err := dropIndex(db, "slack_bot_mention_records", "uk_mention_records_team_service_channel_timestamp"); err != nil {
+				return err
+			}
+			if err := createIndex(db, "slack_bot_channel_configs", "uk_channel_configs_service_channel", true, "service", "channel"); err != nil {
+				return err
+			}
+			if err := dropIndex(db, "slack_bot_channel_configs", "uk_channel_configs_team_service_channel"); err != nil {
+				return err
+			}
+			for _, table := range _teamScopedTables {
+				if err := dropColumn(db, table, "team_id"); err != nil {
+					return err
+				}
+			}
+			return db.Migrator().DropTable("slack_bot_workspaces")
+		},
+	},
+	{
+		Version: 10,
+		Name:    "create_webhooks",
+		Up: func(db *gorm.DB) error {
+			type webhook struct {
+				ID        uint64    `gorm:"column:id;autoIncrement;primaryKey"`
+				TeamID    string    `gorm:"column:team_id;size:20;not null;default:'';index:idx_webhooks_team_service,priority:1"`
+				Service   string    `gorm:"column:service;size:50;not null;index:idx_webhooks_team_service,priority:2"`
+				URL       string    `gorm:"column:url;size:1024;not null"`
+				Secret    string    `gorm:"column:secret;size:255;not null"`
+				Events    string    `gorm:"column:events;size:255;not null;default:''"`
+				Active    bool      `gorm:"column:active;not null;default:true"`
+				CreatedAt time.Time `gorm:"column:created_at;not null"`
+				UpdatedAt time.Time `gorm:"column:updated_at;not null"`
+			}
+			type webhookDelivery struct {
+				ID             uint64     `gorm:"column:id;autoIncrement;primaryKey"`
+				TeamID         string     `gorm:"column:team_id;size:20;not null;default:''"`
+				Service        string     `gorm:"column:service;size:50;not null"`
+				WebhookID      uint64     `gorm:"column:webhook_id;not null;index:idx_webhook_deliveries_webhook_id"`
+				Event          string     `gorm:"column:event;size:50;not null"`
+				Payload        string     `gorm:"column:payload;type:text"`
+				Status         string     `gorm:"column:status;size:20;not null;default:'pending'"`
+				Attempts       int        `gorm:"column:attempts;not null;default:0"`
+				ResponseStatus int        `gorm:"column:response_status;not null;default:0"`
+				Error          string     `gorm:"column:error;type:text"`
+				RedeliveryOf   uint64     `gorm:"column:redelivery_of;not null;default:0"`
+				CreatedAt      time.Time  `gorm:"column:created_at;not null"`
+				DeliveredAt    *time.Time `gorm:"column:delivered_at"`
+			}
+			return createTables(db, map[string]interface{}{
+				"slack_bot_webhooks":           &webhook{},
+				"slack_bot_webhook_deliveries": &webhookDelivery{},
+			})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("slack_bot_webhook_deliveries", "slack_bot_webhooks")
+		},
+	},
+	{
+		Version: 11,
+		Name:    "create_pending_jobs",
+		Up: func(db *gorm.DB) error {
+			type pendingJob struct {
+				ID        uint64    `gorm:"column:id;autoIncrement;primaryKey"`
+				TeamID    string    `gorm:"column:team_id;size:20;not null;default:''"`
+				Service   string    `gorm:"column:service;size:50;not null;index:idx_pending_jobs_service"`
+				Kind      string    `gorm:"column:kind;size:50;not null"`
+				Payload   string    `gorm:"column:payload;type:text"`
+				CreatedAt time.Time `gorm:"column:created_at;not null"`
+			}
+			return createTables(db, map[string]interface{}{"slack_bot_pending_jobs": &pendingJob{}})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("slack_bot_pending_jobs")
+		},
+	},
+	{
+		Version: 12,
+		Name:    "create_outbox",
+		Up: func(db *gorm.DB) error {
+			type outboxEntry struct {
+				ID              uint64    `gorm:"column:id;autoIncrement;primaryKey"`
+				TeamID          string    `gorm:"column:team_id;size:20;not null;default:''"`
+				Service         string    `gorm:"column:service;size:50;not null;index:idx_outbox_due,priority:1"`
+				MentionRecordID uint64    `gorm:"column:mention_record_id;not null"`
+				Kind            string    `gorm:"column:kind;size:20;not null"`
+				IdempotencyKey  string    `gorm:"column:idempotency_key;size:191;not null;uniqueIndex"`
+				Payload         string    `gorm:"column:payload;type:text"`
+				Status          string    `gorm:"column:status;size:20;not null;index:idx_outbox_due,priority:2"`
+				Attempts        int       `gorm:"column:attempts;not null;default:0"`
+				LastError       string    `gorm:"column:last_error;type:text"`
+				Result          string    `gorm:"column:result;type:text"`
+				NextAttemptAt   time.Time `gorm:"column:next_attempt_at;not null;index:idx_outbox_due,priority:3"`
+				CreatedAt       time.Time `gorm:"column:created_at;not null"`
+				UpdatedAt       time.Time `gorm:"column:updated_at;not null"`
+			}
+			return createTables(db, map[string]interface{}{"slack_bot_outbox": &outboxEntry{}})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("slack_bot_outbox")
+		},
+	},
+	{
+		Version: 13,
+		Name:    "schedule_webhook_deliveries",
+		Up: func(db *gorm.DB) error {
+			const table = "slack_bot_webhook_deliveries"
+			/* the column is filled before it's not null, deliveries pending before are attempted at once */
+			if !db.Migrator().HasColumn(table, "next_attempt_at") {
+				if err := db.Exec("ALTER TABLE `" + table + "` ADD COLUMN `next_attempt_at` datetime(3) NULL").Error; err != nil {
+					return errors.Wrap(err, "add column next_attempt_at")
+				}
+			}
+			if err := db.Exec("UPDATE `" + table + "` SET `next_attempt_at` = `created_at` WHERE `next_attempt_at` IS NULL").Error; err != nil {
+				return errors.Wrap(err, "fill next_attempt_at")
+			}
+			if err := db.Exec("ALTER TABLE `" + table + "` MODIFY COLUMN `next_attempt_at` datetime(3) NOT NULL").Error; err != nil {
+				return errors.Wrap(err, "set next_attempt_at not null")
+			}
+			return createIndex(db, table, "idx_webhook_deliveries_due", false, "service", "status", "next_attempt_at")
+		},
+		Down: func(db *gorm.DB) error {
+			const table = "slack_bot_webhook_deliveries"
+			if err := dropIndex(db, table, "idx_webhook_deliveries_due"); err != nil {
+				return err
+			}
+			return dropColumn(db, table, "next_attempt_at")
+		},
+	},
+}
+
+/*
+_teamScopedTables are the tables scoped by the team ID of the workspace,
+mention events and direct messages are scoped by their mention records.
+*/
+var _teamScopedTables = []string{
+	"slack_bot_members",
+	"slack_bot_start_time",
+	"slack_bot_mention_records",
+	"slack_bot_messages",
+	"slack_bot_admins",
+	"slack_bot_subscribers",
+	"slack_bot_settings",
+	"slack_bot_audit_logs",
+	"slack_bot_roster_versions",
+	"slack_bot_channel_configs",
+}
+
+/*
+createTables creates the tables by the schemas of the migration, and adds the missing columns to the existing tables.
+Indexes without a name are named by the table, e.g. idx_slack_bot_mention_records_service.
+*/
+func createTables(db *gorm.DB, tables map[string]interface{}) error {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := db.Table(name).AutoMigrate(tables[name]); err != nil {
+			return errors.Wrapf(err, "migrate table %s", name)
+		}
+	}
+	return nil
+}
+
+func dropColumn(db *gorm.DB, table, column string) error {
+	if !db.Migrator().HasColumn(table, column) {
+		return nil
+	}
+
+	if err := db.Exec("ALTER TABLE `" + table + "` DROP COLUMN `" + column + "`").Error; err != nil {
+		return errors.Wrapf(err, "drop column %s.%s", table, column)
+	}
+	return nil
+}
+
+func createIndex(db *gorm.DB, table, name string, unique bool, columns ...string) error {
+	if db.Migrator().HasIndex(table, name) {
+		return nil
+	}
+
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, quoteIndexColumn(column))
+	}
+
+	kind := "INDEX"
+	if unique {
+		kind = "UNIQUE INDEX"
+	}
+
+	sql := "CREATE " + kind + " `" + name + "` ON `" + table + "` (" + strings.Join(quoted, ", ") + ")"
+	if err := db.Exec(sql).Error; err != nil {
+		return errors.Wrapf(err, "create index %s", name)
+	}
+	return nil
+}
+
+func dropIndex(db *gorm.DB, table, name string) error {
+	if !db.Migrator().HasIndex(table, name) {
+		return nil
+	}
+
+	if err := db.Exec("DROP INDEX `" + name + "` ON `" + table + "`").Error; err != nil {
+		return errors.Wrapf(err, "drop index %s", name)
+	}
+	return nil
+}
+
+/*
+quoteIndexColumn quotes the column name and keeps the prefix length.
+
+	key(100) -> `key`(100)
+*/
+func quoteIndexColumn(column string) string {
+	for i := range column {
+		if column[i] == '(' {
+			return "`" + column[:i] + "`" + column[i:]
+		}
+	}
+	return "`" + column + "`"
+}
+
+type Migrator struct {
+	db *gorm.DB
+}
+
+func NewMigrator() (Migrator, error) {
+	db, err := open()
+	if err != nil {
+		return Migrator{}, err
+	}
+	return Migrator{db: db}, nil
+}
+
+func (m Migrator) prepare() error {
+	return m.db.AutoMigrate(&model.SchemaMigration{})
+}
+
+func (m Migrator) applied() (map[int]model.SchemaMigration, error) {
+	if err := m.prepare(); err != nil {
+		return nil, errors.Wrap(err, "prepare schema migrations table")
+	}
+
+	var records []model.SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, errors.Wrap(err, "list applied migrations")
+	}
+
+	applied := make(map[int]model.SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Version returns the latest applied migration version, 0 means nothing applied.
+func (m Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// LatestVersion returns the version of the last known migration.
+func (m Migrator) LatestVersion() int {
+	return _migrations[len(_migrations)-1].Version
+}
+
+/*
+SchemaVersion returns the applied and the latest known migration versions, it doesn't create the table of migrations.
+*/
+func (dao MysqlDao) SchemaVersion(ctx context.Context) (int, int, error) {
+	latest := Migrator{}.LatestVersion()
+	var version int
+	err := dao.GetDriver(ctx).
+		Model(&model.SchemaMigration{}).
+		Select("COALESCE(MAX(`version`), 0)").
+		Scan(&version).Error
+	if err != nil {
+		return 0, latest, err
+	}
+	return version, latest, nil
+}
+
+func (m Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(_migrations))
+	for _, mig := range _migrations {
+		record, ok := applied[mig.Version]
+		status = append(status, MigrationStatus{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Applied:   ok,
+			AppliedAt: record.AppliedAt,
+		})
+	}
+	return status, nil
+}
+
+// Up applies pending migrations, steps <= 0 applies all of them.
+func (m Migrator) Up(steps int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mig := range _migrations {
+		if steps > 0 && count >= steps {
+			break
+		}
+
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+
+		if err := mig.Up(m.db); err != nil {
+			return count, errors.Wrapf(err, "migrate up %d_%s", mig.Version, mig.Name)
+		}
+
+		if err := m.db.Create(&model.SchemaMigration{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			AppliedAt: time.Now(),
+		}).Error; err != nil {
+			return count, errors.Wrapf(err, "record migration %d_%s", mig.Version, mig.Name)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Down rolls back applied migrations from the latest one, steps <= 0 rolls back only one.
+func (m Migrator) Down(steps int) (int, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	migrations := make([]migration, len(_migrations))
+	copy(migrations, _migrations)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version > migrations[j].Version
+	})
+
+	count := 0
+	for _, mig := range migrations {
+		if count >= steps {
+			break
+		}
+
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+
+		if err := mig.Down(m.db); err != nil {
+			return count, errors.Wrapf(err, "migrate down %d_%s", mig.Version, mig.Name)
+		}
+
+		if err := m.db.Where("`version` = ?", mig.Version).Delete(&model.SchemaMigration{}).Error; err != nil {
+			return count, errors.Wrapf(err, "remove migration record %d_%s", mig.Version, mig.Name)
+		}
+		count++
+	}
+
+	return count, nil
+}
@@ -25,20 +25,16 @@ type MysqlDao struct {
 }
 
 func New() (MysqlDao, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		viper.GetString("mysql.username"),
-		viper.GetString("mysql.password"),
-		viper.GetString("mysql.host"),
-		viper.GetInt("mysql.port"),
-		viper.GetString("mysql.database"))
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := open()
 	if err != nil {
 		return MysqlDao{}, err
 	}
 
-	if err := initMigration(db); err != nil {
-		return MysqlDao{}, err
+	viper.SetDefault("mysql.migration.auto", true)
+	if viper.GetBool("mysql.migration.auto") {
+		if _, err := (Migrator{db: db}).Up(0); err != nil {
+			return MysqlDao{}, err
+		}
 	}
 
 	return MysqlDao{
@@ -46,32 +42,25 @@ func New() (MysqlDao, error) {
 	}, nil
 }
 
-func initMigration(db *gorm.DB) error {
-	tables := []interface{}{
-		&model.Member{},
-		&model.StartTime{},
-		&model.MentionRecord{},
-		&model.BotMessage{},
-		&model.Admin{},
-		&model.Subscriber{},
-		&model.BotSetting{},
-	}
-
-	for _, table := range tables {
-		if err := migrate(db, table); err != nil {
-			return err
-		}
-	}
+func open() (*gorm.DB, error) {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		viper.GetString("mysql.username"),
+		viper.GetString("mysql.password"),
+		viper.GetString("mysql.host"),
+		viper.GetInt("mysql.port"),
+		viper.GetString("mysql.database"))
 
-	return nil
+	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
 }
 
-func migrate(db *gorm.DB, p interface{}) error {
-	if db.Migrator().HasTable(p) {
-		return nil
+/*
+team scopes the query to the workspace of the context, see model.WithTeam.
+*/
+func team(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	teamID := model.TeamFrom(ctx)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("`team_id` = ?", teamID)
 	}
-
-	return db.AutoMigrate(p)
 }
 
 func notFound(err error) bool {
@@ -89,6 +78,14 @@ func (dao MysqlDao) Tx(ctx context.Context, fn func(context.Context) error) erro
 	})
 }
 
+func (dao MysqlDao) Ping(ctx context.Context) error {
+	db, err := dao.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
 func (dao MysqlDao) GetDriver(ctx context.Context) (db *gorm.DB) {
 	db, ok := ctx.Value(_driverKey).(*gorm.DB)
 	if ok && db != nil {
@@ -99,7 +96,7 @@ func (dao MysqlDao) GetDriver(ctx context.Context) (db *gorm.DB) {
 
 func (dao MysqlDao) GetMember(ctx context.Context, service string, userID string) (model.Member, error) {
 	var member model.Member
-	err := dao.GetDriver(ctx).
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
 		Where("`service` = ?", service).
 		Where("`user_id` = ?", userID).
 		First(&member).Error
@@ -110,23 +107,27 @@ func (dao MysqlDao) GetMember(ctx context.Context, service string, userID string
 }
 
 func (dao MysqlDao) UpdateMember(ctx context.Context, member model.Member) error {
-	var id uint64
-	err := dao.GetDriver(ctx).
-		Model(&model.Member{}).
-		Select("`id`").
-		Where("`service` = ?", member.Service).
-		Where("`user_id` = ?", member.UserID).
-		First(&id).Error
-	if err != nil {
-		return err
-	}
-	member.ID = id
-	return dao.GetDriver(ctx).Save(&member).Error
+	return dao.audit(ctx, member.Service, model.AuditActionUpdateMember, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		before := model.Member{}
+		err := tx.Scopes(team(ctx)).
+			Where("`service` = ?", member.Service).
+			Where("`user_id` = ?", member.UserID).
+			First(&before).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		member.ID = before.ID
+		member.TeamID = before.TeamID
+		if err := tx.Save(&member).Error; err != nil {
+			return nil, nil, err
+		}
+		return before, member, nil
+	})
 }
 
 func (dao MysqlDao) ListMembers(ctx context.Context, service string) ([]model.Member, error) {
 	var members []model.Member
-	err := dao.GetDriver(ctx).Where("`service` = ?", service).
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).Where("`service` = ?", service).
 		Order("`order`").
 		Find(&members).Error
 	if err != nil {
@@ -141,33 +142,41 @@ func (dao MysqlDao) ListMembers(ctx context.Context, service string) ([]model.Me
 }
 
 func (dao MysqlDao) ResetMembers(txCtx context.Context, service string, member []model.Member) error {
-	tx := dao.GetDriver(txCtx)
+	return dao.audit(txCtx, service, model.AuditActionResetMembers, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		before := []model.Member{}
+		if err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			Order("`order`").
+			Find(&before).Error; err != nil {
+			return nil, nil, err
+		}
 
-	if err := tx.Where("`service` = ?", service).
-		Delete(&model.Member{}).Error; err != nil && !notFound(err) {
-		return err
-	}
+		if err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			Delete(&model.Member{}).Error; err != nil && !notFound(err) {
+			return nil, nil, err
+		}
 
-	members := make([]model.Member, 0, len(member))
-	for i, m := range member {
-		members = append(members, model.Member{
-			UserID:   m.UserID,
-			UserName: m.UserName,
-			Order:    i,
-			Service:  service,
-		})
-	}
+		members := make([]model.Member, 0, len(member))
+		for i, m := range member {
+			members = append(members, model.Member{
+				TeamID:   model.TeamFrom(txCtx),
+				UserID:   m.UserID,
+				UserName: m.UserName,
+				Order:    i,
+				Service:  service,
+			})
+		}
 
-	if err := tx.Create(&members).Error; err != nil {
-		return err
-	}
+		if err := tx.Create(&members).Error; err != nil {
+			return nil, nil, err
+		}
 
-	return nil
+		return before, members, nil
+	})
 }
 
 func (dao MysqlDao) ListAllMembers(ctx context.Context) ([]model.Member, error) {
 	var members []model.Member
-	err := dao.GetDriver(ctx).Find(&members).Error
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).Find(&members).Error
 	if err != nil {
 		return nil, err
 	}
@@ -176,24 +185,20 @@ func (dao MysqlDao) ListAllMembers(ctx context.Context) ([]model.Member, error)
 }
 
 func (dao MysqlDao) IsAdmin(ctx context.Context, service, userID string) (bool, error) {
-	err := dao.GetDriver(ctx).
+	var count int64
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).
 		Model(&model.Admin{}).
 		Where("`user_id` = ?", userID).
-		Where("`service` = ?", service).Error
-	if err == nil {
-		return true, nil
-	}
-
-	if notFound(err) {
-		return false, nil
+		Where("`service` = ?", service).
+		Count(&count).Error; err != nil {
+		return false, err
 	}
-
-	return false, err
+	return count != 0, nil
 }
 
 func (dao MysqlDao) ListAdmin(ctx context.Context, service string) ([]model.Admin, error) {
 	var admins []model.Admin
-	if err := dao.GetDriver(ctx).Find(&admins).Error; err != nil {
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).Where("`service` = ?", service).Find(&admins).Error; err != nil {
 		return nil, err
 	}
 	return admins, nil
@@ -203,23 +208,39 @@ func (dao MysqlDao) AddAdmin(ctx context.Context, admin model.Admin) error {
 	if admin.IsEmpty() {
 		return errors.New(fmt.Sprintf("empty admin, %+v", admin))
 	}
-	return dao.GetDriver(ctx).Save(&admin).Error
+	admin.TeamID = model.TeamFrom(ctx)
+	return dao.audit(ctx, admin.Service, model.AuditActionAddAdmin, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		if err := tx.Save(&admin).Error; err != nil {
+			return nil, nil, err
+		}
+		return nil, admin, nil
+	})
 }
 
 func (dao MysqlDao) DeleteAdmin(ctx context.Context, service, userID string) error {
-	err := dao.GetDriver(ctx).
-		Where("`service` = ?", service).
-		Where("`user_id` = ?", userID).
-		Delete(&model.Admin{}).Error
-	if err != nil && !notFound(err) {
-		return err
-	}
-	return nil
+	return dao.audit(ctx, service, model.AuditActionDeleteAdmin, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		before := []model.Admin{}
+		if err := tx.Scopes(team(ctx)).
+			Where("`service` = ?", service).
+			Where("`user_id` = ?", userID).
+			Find(&before).Error; err != nil {
+			return nil, nil, err
+		}
+
+		err := tx.Scopes(team(ctx)).
+			Where("`service` = ?", service).
+			Where("`user_id` = ?", userID).
+			Delete(&model.Admin{}).Error
+		if err != nil && !notFound(err) {
+			return nil, nil, err
+		}
+		return before, nil, nil
+	})
 }
 
 func (dao MysqlDao) GetStartDate(ctx context.Context, service string) (time.Time, error) {
 	elem := model.StartTime{}
-	err := dao.GetDriver(ctx).Where("`service` = ?", service).
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).Where("`service` = ?", service).
 		First(&elem).Error
 	if err != nil {
 		return time.Time{}, err
@@ -229,33 +250,38 @@ func (dao MysqlDao) GetStartDate(ctx context.Context, service string) (time.Time
 }
 
 func (dao MysqlDao) UpdateStartDate(txCtx context.Context, service string, t time.Time) error {
-	tx := dao.GetDriver(txCtx)
-	elem := model.StartTime{}
-	err := tx.Where("`service` = ?", service).
-		First(&elem).Error
-	if notFound(err) {
-		elem.Service = service
-		elem.StartTime = t
-		return tx.Create(&elem).Error
-	}
+	return dao.audit(txCtx, service, model.AuditActionUpdateStartDate, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		elem := model.StartTime{}
+		err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			First(&elem).Error
+		if notFound(err) {
+			elem.TeamID = model.TeamFrom(txCtx)
+			elem.Service = service
+			elem.StartTime = t
+			if err := tx.Create(&elem).Error; err != nil {
+				return nil, nil, err
+			}
+			return nil, t, nil
+		}
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return nil, nil, err
+		}
 
-	elem.StartTime = t
-	err = tx.Save(&elem).Error
-	if err != nil {
-		return err
-	}
+		before := elem.StartTime
+		elem.StartTime = t
+		if err := tx.Save(&elem).Error; err != nil {
+			return nil, nil, err
+		}
 
-	return nil
+		return before, t, nil
+	})
 }
 
 func (dao MysqlDao) GetDutyDuration(ctx context.Context, service string) (time.Duration, error) {
 	var setting model.BotSetting
 	key := strings.ToLower(service) + ".duty.duration"
-	if err := dao.GetDriver(ctx).Model(&setting).Where("`key` = ?", key).Limit(1).First(&setting).Error; err != nil {
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).Model(&setting).Where("`key` = ?", key).Limit(1).First(&setting).Error; err != nil {
 		return 0, err
 	}
 	d, err := time.ParseDuration(setting.Value)
@@ -269,7 +295,7 @@ func (dao MysqlDao) GetDutyDuration(ctx context.Context, service string) (time.D
 func (dao MysqlDao) GetDutyMemberCountPerTime(ctx context.Context, service string) (int, error) {
 	var setting model.BotSetting
 	key := strings.ToLower(service) + ".duty.member.count.per.time"
-	if err := dao.GetDriver(ctx).Model(&setting).Where("`key` = ?", key).Limit(1).First(&setting).Error; err != nil {
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).Model(&setting).Where("`key` = ?", key).Limit(1).First(&setting).Error; err != nil {
 		return 0, err
 	}
 	count, err := strconv.Atoi(setting.Value)
@@ -282,7 +308,7 @@ func (dao MysqlDao) GetDutyMemberCountPerTime(ctx context.Context, service strin
 
 func (dao MysqlDao) CountMentionRecord(ctx context.Context, service string) (int64, error) {
 	var count int64
-	if err := dao.GetDriver(ctx).Model(&model.MentionRecord{}).Where("`service` = ?", service).Count(&count).Error; err != nil {
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).Model(&model.MentionRecord{}).Where("`service` = ?", service).Count(&count).Error; err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -290,19 +316,19 @@ func (dao MysqlDao) CountMentionRecord(ctx context.Context, service string) (int
 
 func (dao MysqlDao) GetMentionRecord(ctx context.Context, id uint64) (model.MentionRecord, error) {
 	record := model.MentionRecord{}
-	if err := dao.GetDriver(ctx).Where("`id`= ?", id).First(&record).Error; err != nil {
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).Where("`id`= ?", id).First(&record).Error; err != nil {
 		return model.MentionRecord{}, err
 	}
 	return record, nil
 }
 
-func (dao MysqlDao) FindOrCreateMentionRecord(txCtx context.Context, service, channel, timestamp string) (uint64, bool, error) {
+func (dao MysqlDao) FindOrCreateMentionRecord(txCtx context.Context, service, channel, timestamp string, dutyMembers []string) (uint64, bool, error) {
 	found := false
 	var id uint64
 	tx := dao.GetDriver(txCtx)
 
 	record := model.MentionRecord{}
-	err := tx.Where("`service` = ?", service).
+	err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
 		Where("`channel` = ?", channel).
 		Where("`timestamp` = ?", timestamp).
 		First(&record).Error
@@ -316,9 +342,12 @@ func (dao MysqlDao) FindOrCreateMentionRecord(txCtx context.Context, service, ch
 		return id, found, errors.Wrap(err, "query")
 	}
 
+	record.TeamID = model.TeamFrom(txCtx)
 	record.Service = service
 	record.Channel = channel
 	record.Timestamp = timestamp
+	record.DutyMembers = strings.Join(dutyMembers, ",")
+	record.Status = model.MentionStatusOpen
 	record.CreateAtu = time.Now().Unix()
 	if err := tx.Create(&record).Error; err != nil {
 		return id, found, errors.Wrap(err, "create")
@@ -329,31 +358,39 @@ func (dao MysqlDao) FindOrCreateMentionRecord(txCtx context.Context, service, ch
 
 func (dao MysqlDao) GetReplyMessage(ctx context.Context, service string) (model.BotMessage, error) {
 	msg := model.BotMessage{}
-	if err := dao.GetDriver(ctx).Where("`service` = ?", service).First(&msg).Error; err != nil && !notFound(err) {
+	if err := dao.GetDriver(ctx).Scopes(team(ctx)).Where("`service` = ?", service).First(&msg).Error; err != nil && !notFound(err) {
 		return model.BotMessage{}, err
 	}
 	return msg, nil
 }
 
 func (dao MysqlDao) SetReplyMessage(txCtx context.Context, msg model.BotMessage) error {
-	tx := dao.GetDriver(txCtx)
-	var id uint64
-	err := tx.Model(&model.BotMessage{}).Select("`id`").Where("`service` = ?", msg.Service).First(&id).Error
-	if notFound(err) {
-		return tx.Create(&msg).Error
-	}
+	msg.TeamID = model.TeamFrom(txCtx)
+	return dao.audit(txCtx, msg.Service, model.AuditActionSetReplyMessage, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		before := model.BotMessage{}
+		err := tx.Scopes(team(txCtx)).Where("`service` = ?", msg.Service).First(&before).Error
+		if notFound(err) {
+			if err := tx.Create(&msg).Error; err != nil {
+				return nil, nil, err
+			}
+			return nil, msg, nil
+		}
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return nil, nil, err
+		}
 
-	msg.ID = id
-	return tx.Save(&msg).Error
+		msg.ID = before.ID
+		if err := tx.Save(&msg).Error; err != nil {
+			return nil, nil, err
+		}
+		return before, msg, nil
+	})
 }
 
 func (dao MysqlDao) GetSubscriber(ctx context.Context) ([]model.Subscriber, error) {
 	subscribers := []model.Subscriber{}
-	err := dao.GetDriver(ctx).Find(&subscribers).Error
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).Find(&subscribers).Error
 	if err != nil {
 		return nil, err
 	}
@@ -361,13 +398,20 @@ func (dao MysqlDao) GetSubscriber(ctx context.Context) ([]model.Subscriber, erro
 }
 
 func (dao MysqlDao) SetSubscriber(ctx context.Context, sub model.Subscriber) error {
-	err := dao.GetDriver(ctx).Save(&sub).Error
-	if err != nil {
-		return err
-	}
-	return nil
+	sub.TeamID = model.TeamFrom(ctx)
+	return dao.audit(ctx, "", model.AuditActionSetSubscriber, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		if err := tx.Save(&sub).Error; err != nil {
+			return nil, nil, err
+		}
+		return nil, sub, nil
+	})
 }
 
 func (dao MysqlDao) DeleteSubscriber(ctx context.Context, sub model.Subscriber) error {
-	return dao.GetDriver(ctx).Where("`user_id` = ?", sub.UserID).Delete(&sub).Error
+	return dao.audit(ctx, "", model.AuditActionDeleteSubscriber, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		if err := tx.Scopes(team(ctx)).Where("`user_id` = ?", sub.UserID).Delete(&sub).Error; err != nil {
+			return nil, nil, err
+		}
+		return sub, nil, nil
+	})
 }
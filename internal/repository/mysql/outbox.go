@@ -0,0 +1,118 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+func (dao MysqlDao) CreateOutboxEntries(txCtx context.Context, entries []model.OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]model.OutboxEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.ID = 0
+		entry.Status = model.OutboxPending
+		entry.NextAttemptAt = now
+		entry.CreatedAt = now
+		entry.UpdatedAt = now
+		rows = append(rows, entry)
+	}
+	return dao.GetDriver(txCtx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (dao MysqlDao) ListDueOutboxEntries(ctx context.Context, service string, now time.Time, limit int) ([]model.OutboxEntry, error) {
+	entries := []model.OutboxEntry{}
+	db := dao.GetDriver(ctx).
+		Where("`service` = ?", service).
+		Where("`status` = ?", model.OutboxPending).
+		Where("`next_attempt_at` <= ?", now).
+		Order("`id`")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if err := db.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (dao MysqlDao) ClaimOutboxEntry(ctx context.Context, entry model.OutboxEntry, until time.Time) (bool, error) {
+	result := dao.GetDriver(ctx).
+		Model(&model.OutboxEntry{}).
+		Where("`id` = ?", entry.ID).
+		Where("`status` = ?", model.OutboxPending).
+		Where("`next_attempt_at` = ?", entry.NextAttemptAt).
+		Updates(map[string]interface{}{
+			"next_attempt_at": until,
+			"updated_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (dao MysqlDao) UpdateOutboxEntry(ctx context.Context, entry model.OutboxEntry) error {
+	return dao.GetDriver(ctx).
+		Model(&model.OutboxEntry{}).
+		Where("`id` = ?", entry.ID).
+		Updates(map[string]interface{}{
+			"status":          entry.Status,
+			"attempts":        entry.Attempts,
+			"last_error":      entry.LastError,
+			"result":          entry.Result,
+			"next_attempt_at": entry.NextAttemptAt,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+func (dao MysqlDao) GetOutboxEntry(ctx context.Context, key string) (model.OutboxEntry, error) {
+	entry := model.OutboxEntry{}
+	if err := dao.GetDriver(ctx).Where("`idempotency_key` = ?", key).First(&entry).Error; err != nil {
+		return model.OutboxEntry{}, err
+	}
+	return entry, nil
+}
+
+func (dao MysqlDao) ListOutboxEntries(ctx context.Context, mentionRecordID uint64) ([]model.OutboxEntry, error) {
+	entries := []model.OutboxEntry{}
+	if err := dao.GetDriver(ctx).Where("`mention_record_id` = ?", mentionRecordID).Order("`id`").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (dao MysqlDao) OutboxBacklog(ctx context.Context, service string) (model.OutboxBacklog, error) {
+	rows := []struct {
+		Status string
+		Count  int64
+		Oldest *time.Time
+	}{}
+	err := dao.GetDriver(ctx).
+		Model(&model.OutboxEntry{}).
+		Select("`status`, COUNT(*) AS `count`, MIN(`created_at`) AS `oldest`").
+		Where("`service` = ?", service).
+		Where("`status` IN ?", []string{model.OutboxPending, model.OutboxFailed}).
+		Group("`status`").
+		Scan(&rows).Error
+	if err != nil {
+		return model.OutboxBacklog{}, err
+	}
+
+	backlog := model.OutboxBacklog{}
+	for _, row := range rows {
+		switch row.Status {
+		case model.OutboxPending:
+			backlog.Pending, backlog.OldestPending = row.Count, row.Oldest
+		case model.OutboxFailed:
+			backlog.Failed = row.Count
+		}
+	}
+	return backlog, nil
+}
@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+)
+
+func (dao MysqlDao) SavePendingJobs(ctx context.Context, jobs []model.PendingJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	rows := make([]model.PendingJob, 0, len(jobs))
+	for _, job := range jobs {
+		job.ID = 0
+		job.CreatedAt = time.Now()
+		rows = append(rows, job)
+	}
+	return dao.GetDriver(ctx).Create(&rows).Error
+}
+
+func (dao MysqlDao) TakePendingJobs(txCtx context.Context, service string) ([]model.PendingJob, error) {
+	jobs := []model.PendingJob{}
+	db := dao.GetDriver(txCtx)
+	if err := db.Where("`service` = ?", service).Order("`id`").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return jobs, nil
+	}
+
+	ids := make([]uint64, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	if err := db.Where("`id` IN ?", ids).Delete(&model.PendingJob{}).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
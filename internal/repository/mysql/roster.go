@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (dao MysqlDao) SnapshotRoster(txCtx context.Context, service string, effectiveFrom time.Time) (model.RosterVersion, error) {
+	members, err := dao.ListMembers(txCtx, service)
+	if err != nil {
+		return model.RosterVersion{}, errors.Wrap(err, "list members")
+	}
+
+	startDate, err := dao.GetStartDate(txCtx, service)
+	if err != nil && !notFound(err) {
+		return model.RosterVersion{}, errors.Wrap(err, "get start date")
+	}
+
+	/* zero duration and count fall back to the defaults of the bot */
+	duration, _ := dao.GetDutyDuration(txCtx, service)
+	count, _ := dao.GetDutyMemberCountPerTime(txCtx, service)
+
+	version := model.RosterVersion{
+		TeamID:             model.TeamFrom(txCtx),
+		Service:            service,
+		StartDate:          startDate,
+		Duration:           duration,
+		MemberCountPerTime: count,
+		EffectiveFrom:      effectiveFrom,
+		CreatedAt:          time.Now(),
+	}
+	if err := version.SetMemberList(members); err != nil {
+		return model.RosterVersion{}, errors.Wrap(err, "marshal members")
+	}
+
+	if err := dao.GetDriver(txCtx).Create(&version).Error; err != nil {
+		return model.RosterVersion{}, errors.Wrap(err, "create roster version")
+	}
+	return version, nil
+}
+
+func (dao MysqlDao) GetRosterVersion(ctx context.Context, service string, at time.Time) (model.RosterVersion, error) {
+	version := model.RosterVersion{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Where("`effective_from` <= ?", at).
+		Order("`effective_from` DESC").
+		Order("`id` DESC").
+		First(&version).Error
+	if err != nil && !notFound(err) {
+		return model.RosterVersion{}, err
+	}
+	return version, nil
+}
+
+func (dao MysqlDao) ListRosterVersions(ctx context.Context, service string) ([]model.RosterVersion, error) {
+	versions := []model.RosterVersion{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Order("`effective_from` DESC").
+		Order("`id` DESC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
@@ -0,0 +1,167 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (dao MysqlDao) ListWebhooks(ctx context.Context, service string) ([]model.Webhook, error) {
+	hooks := []model.Webhook{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Order("`id`").
+		Find(&hooks).Error
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (dao MysqlDao) GetWebhook(ctx context.Context, service string, id uint64) (model.Webhook, error) {
+	hook := model.Webhook{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Where("`id` = ?", id).
+		First(&hook).Error
+	if err != nil {
+		return model.Webhook{}, err
+	}
+	return hook, nil
+}
+
+func (dao MysqlDao) SaveWebhook(txCtx context.Context, hook model.Webhook) (uint64, error) {
+	err := dao.audit(txCtx, hook.Service, model.AuditActionSaveWebhook, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		hook.TeamID = model.TeamFrom(txCtx)
+		hook.UpdatedAt = time.Now()
+
+		if hook.ID == 0 {
+			hook.CreatedAt = hook.UpdatedAt
+			if err := tx.Create(&hook).Error; err != nil {
+				return nil, nil, err
+			}
+			return nil, hook, nil
+		}
+
+		before := model.Webhook{}
+		if err := tx.Scopes(team(txCtx)).Where("`service` = ?", hook.Service).
+			Where("`id` = ?", hook.ID).
+			First(&before).Error; err != nil {
+			return nil, nil, err
+		}
+
+		hook.CreatedAt = before.CreatedAt
+		if err := tx.Save(&hook).Error; err != nil {
+			return nil, nil, err
+		}
+		return before, hook, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return hook.ID, nil
+}
+
+func (dao MysqlDao) DeleteWebhook(txCtx context.Context, service string, id uint64) error {
+	return dao.audit(txCtx, service, model.AuditActionDeleteWebhook, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		before := []model.Webhook{}
+		if err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			Where("`id` = ?", id).
+			Find(&before).Error; err != nil {
+			return nil, nil, err
+		}
+
+		err := tx.Scopes(team(txCtx)).Where("`service` = ?", service).
+			Where("`id` = ?", id).
+			Delete(&model.Webhook{}).Error
+		if err != nil && !notFound(err) {
+			return nil, nil, err
+		}
+		return before, nil, nil
+	})
+}
+
+func (dao MysqlDao) CreateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) (uint64, error) {
+	delivery.TeamID = model.TeamFrom(ctx)
+	delivery.CreatedAt = time.Now()
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = delivery.CreatedAt
+	}
+	if err := dao.GetDriver(ctx).Create(&delivery).Error; err != nil {
+		return 0, err
+	}
+	return delivery.ID, nil
+}
+
+func (dao MysqlDao) UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	return dao.GetDriver(ctx).Scopes(team(ctx)).
+		Model(&model.WebhookDelivery{}).
+		Where("`id` = ?", delivery.ID).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"response_status": delivery.ResponseStatus,
+			"error":           delivery.Error,
+			"delivered_at":    delivery.DeliveredAt,
+			"next_attempt_at": delivery.NextAttemptAt,
+		}).Error
+}
+
+func (dao MysqlDao) ListDueWebhookDeliveries(ctx context.Context, service string, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	db := dao.GetDriver(ctx).
+		Where("`service` = ?", service).
+		Where("`status` = ?", model.WebhookDeliveryPending).
+		Where("`next_attempt_at` <= ?", now).
+		Order("`id`")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if err := db.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (dao MysqlDao) ClaimWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery, until time.Time) (bool, error) {
+	result := dao.GetDriver(ctx).
+		Model(&model.WebhookDelivery{}).
+		Where("`id` = ?", delivery.ID).
+		Where("`status` = ?", model.WebhookDeliveryPending).
+		Where("`next_attempt_at` = ?", delivery.NextAttemptAt).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (dao MysqlDao) GetWebhookDelivery(ctx context.Context, service string, id uint64) (model.WebhookDelivery, error) {
+	delivery := model.WebhookDelivery{}
+	err := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Where("`id` = ?", id).
+		First(&delivery).Error
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+func (dao MysqlDao) ListWebhookDeliveries(ctx context.Context, service string, webhookID uint64, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	db := dao.GetDriver(ctx).Scopes(team(ctx)).
+		Where("`service` = ?", service).
+		Where("`webhook_id` = ?", webhookID).
+		Order("`id` DESC")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	if err := db.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (dao MysqlDao) GetWorkspace(ctx context.Context, service, teamID string) (model.Workspace, error) {
+	ws := model.Workspace{}
+	err := dao.GetDriver(ctx).
+		Where("`service` = ?", service).
+		Where("`team_id` = ?", teamID).
+		First(&ws).Error
+	if err != nil {
+		return model.Workspace{}, err
+	}
+	return ws, nil
+}
+
+func (dao MysqlDao) ListWorkspaces(ctx context.Context, service string) ([]model.Workspace, error) {
+	workspaces := []model.Workspace{}
+	err := dao.GetDriver(ctx).
+		Where("`service` = ?", service).
+		Order("`team_id`").
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (dao MysqlDao) SaveWorkspace(txCtx context.Context, ws model.Workspace) error {
+	return dao.audit(txCtx, ws.Service, model.AuditActionSaveWorkspace, func(tx *gorm.DB) (interface{}, interface{}, error) {
+		ws.UpdatedAt = time.Now()
+
+		before := model.Workspace{}
+		err := tx.Where("`service` = ?", ws.Service).
+			Where("`team_id` = ?", ws.TeamID).
+			First(&before).Error
+		if notFound(err) {
+			ws.InstalledAt = ws.UpdatedAt
+			if err := tx.Create(&ws).Error; err != nil {
+				return nil, nil, err
+			}
+			return nil, ws, nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ws.ID = before.ID
+		ws.InstalledAt = before.InstalledAt
+		if err := tx.Save(&ws).Error; err != nil {
+			return nil, nil, err
+		}
+		return before, ws, nil
+	})
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"bitopi/internal/slack"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	_authCacheTTL      = 10 * time.Minute
+	_authErrorCacheTTL = 10 * time.Second /* failures are retried soon, a recovered token passes the next check */
+)
+
+/*
+authCache caches the auth.test result of the bot token, it's shared by the copies of the service.
+Failures are cached shortly, and failures of the cancelled context aren't cached.
+*/
+type authCache struct {
+	mu        sync.Mutex
+	res       slack.AuthTestResponse
+	err       error
+	checkedAt time.Time
+}
+
+func newAuthCache() *authCache {
+	return &authCache{}
+}
+
+func (c *authCache) get(ctx context.Context, client *slack.Client) (slack.AuthTestResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := _authCacheTTL
+	if c.err != nil {
+		ttl = _authErrorCacheTTL
+	}
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < ttl {
+		return c.res, c.err
+	}
+
+	res, err := client.AuthTest(ctx)
+	if err != nil && ctx.Err() != nil {
+		return res, err
+	}
+	c.res, c.err = res, err
+	c.checkedAt = time.Now()
+	return c.res, c.err
+}
+
+// authTest returns the cached identity of the bot token.
+func (svc *Service) authTest() (slack.AuthTestResponse, error) {
+	return svc.authTestContext(svc.ctx)
+}
+
+// authTestContext is authTest bounded by ctx, e.g. the timeout of the readiness check.
+func (svc *Service) authTestContext(ctx context.Context) (slack.AuthTestResponse, error) {
+	if svc.auth == nil {
+		return slack.AuthTestResponse{}, nil
+	}
+	return svc.auth.get(ctx, svc.client)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bitopi/internal/model"
+)
+
+/*
+channelConfig returns the config of the channel, and whether the bot answers mentions in the channel.
+*/
+func (svc *SlackBot) channelConfig(channel string) (model.ChannelConfig, bool) {
+	cfgs, err := svc.repo.ListChannelConfigs(svc.ctx, svc.Name)
+	if err != nil {
+		svc.l.Warnf("list channel configs failed, err: %+v", err)
+		return model.ChannelConfig{}, true
+	}
+
+	current, hasAllowed := model.ChannelConfig{}, false
+	for _, cfg := range cfgs {
+		if cfg.Access == model.ChannelAccessAllow {
+			hasAllowed = true
+		}
+		if cfg.Channel == channel {
+			current = cfg
+		}
+	}
+
+	switch {
+	case current.Access == model.ChannelAccessDeny:
+		return current, false
+	case hasAllowed && current.Access != model.ChannelAccessAllow:
+		return current, false
+	default:
+		return current, true
+	}
+}
+
+// appendUnique appends elements which aren't in s yet.
+func appendUnique(s []string, elems ...string) []string {
+	seen := make(map[string]bool, len(s))
+	for _, e := range s {
+		seen[e] = true
+	}
+	for _, e := range elems {
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		s = append(s, e)
+	}
+	return s
+}
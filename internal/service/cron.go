@@ -1,6 +1,14 @@
 package service
 
-import "bitopi/internal/util"
+import (
+	"bitopi/internal/metrics"
+	"bitopi/internal/model"
+	"time"
+)
+
+const (
+	_weeklyJob = "weekly_notifier"
+)
 
 type WeeklyNotifier struct {
 	SlackBot
@@ -16,10 +24,66 @@ func NewWeeklyJob(bot SlackBot, opt WeeklyNotifierOpt) *WeeklyNotifier {
 }
 
 func (svc *WeeklyNotifier) Run() {
-	notifier := util.NewSlackNotifier(svc.SlackBot.Token)
-	err := svc.SlackBot.publishHomeView(notifier)
+	bots, err := svc.SlackBot.installedWorkspaces()
+	if err != nil {
+		svc.l.Errorf("list workspaces failed, err: %+v", err)
+	}
+	defer func() { metrics.CronRun(_weeklyJob, err) }()
+
+	now := time.Now()
+	for _, bot := range bots {
+		if e := bot.publishHomeView(); e != nil {
+			svc.l.Errorf("publish home view tab of workspace '%s' failed, err: %+v", model.TeamFrom(bot.ctx), e)
+			err = e
+		}
+
+		if e := bot.emitDutyRotated(now); e != nil {
+			svc.l.Errorf("emit duty rotated of workspace '%s' failed, err: %+v", model.TeamFrom(bot.ctx), e)
+			err = e
+		}
+	}
+}
+
+/*
+emitDutyRotated emits the rotations whose duty members changed since the last run of the weekly job.
+*/
+func (svc *SlackBot) emitDutyRotated(now time.Time) error {
+	previous, err := svc.rotationDuties(nil, now.Add(-_week))
+	if err != nil {
+		return err
+	}
+
+	current, err := svc.rotationDuties(nil, now)
 	if err != nil {
-		svc.l.Errorf("publish home view tab failed, err: %+v", err)
-		return
+		return err
+	}
+
+	for i, d := range current {
+		if i < len(previous) && sameMembers(previous[i].Duty, d.Duty) {
+			continue
+		}
+
+		data := model.DutyRotatedWebhookData{
+			Rotation: d.rotation.Name,
+			Current:  d.Duty,
+			ShiftEnd: d.ShiftEnd,
+		}
+		if i < len(previous) {
+			data.Previous = previous[i].Duty
+		}
+		svc.emit(svc.Name, model.WebhookEventDutyRotated, data)
+	}
+	return nil
+}
+
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
+	return true
 }
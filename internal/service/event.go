@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bitopi/internal/model"
+)
+
+/*
+routeEvent routes the event callback by the event type and subtype.
+
+Events posted by bots, including this bot itself, are ignored.
+*/
+func (svc *SlackBot) routeEvent(slackEventApi model.SlackEventAPI) interface{} {
+	event := slackEventApi.Event
+	switch {
+	case event.Type == model.EventTypeAppMention:
+		if svc.isIgnoredAuthor(event.User, event.IsBot()) {
+			svc.l.Debugf("ignore mention from bot, user: %s, bot: %s", event.User, event.BotID)
+			return nil
+		}
+		return svc.mentionResponse(event)
+	case event.Type == model.EventTypeMessage && event.SubType == model.EventSubTypeMessageChanged:
+		return svc.messageChangedResponse(event)
+	case event.Type == model.EventTypeMessage && event.SubType == model.EventSubTypeMessageDeleted:
+		return svc.messageDeletedResponse(event)
+	default:
+		svc.l.Debugf("ignore event, type: %s, subtype: %s", event.Type, event.SubType)
+		return nil
+	}
+}
+
+func (svc *SlackBot) isIgnoredAuthor(user string, isBot bool) bool {
+	if isBot {
+		return true
+	}
+
+	auth, err := svc.authTest()
+	if err != nil {
+		svc.l.Warnf("auth test failed, err: %+v", err)
+		return false
+	}
+	return len(auth.UserID) != 0 && user == auth.UserID
+}
+
+/*
+messageChangedResponse updates the content of the direct messages when the source message of the mention was edited.
+Changes which keep the text, e.g. unfurled links or new replies of the thread, are ignored.
+*/
+func (svc *SlackBot) messageChangedResponse(event model.Event) interface{} {
+	msg := event.Message
+	if msg == nil || svc.isIgnoredAuthor(msg.User, msg.IsBot()) {
+		return nil
+	}
+
+	if prev := event.PreviousMessage; prev != nil && prev.Text == msg.Text {
+		svc.l.Debugf("ignore message change without text change, ts: %s", msg.TimeStamp)
+		return nil
+	}
+
+	record, ok := svc.sourceMentionRecord(event.Channel, msg.ThreadRootTimeStamp(), msg.TimeStamp)
+	if !ok {
+		return nil
+	}
+
+	svc.goJob(_jobMentionUpdate, updateJob{
+		MentionID: record.ID,
+		Content:   msg.Text,
+		Cancelled: record.Status == model.MentionStatusCancelled,
+	})
+	return nil
+}
+
+/*
+messageDeletedResponse cancels the mention record and marks its direct messages when the source message was deleted.
+*/
+func (svc *SlackBot) messageDeletedResponse(event model.Event) interface{} {
+	rootTS, content := event.DeletedTimeStamp, ""
+	if prev := event.PreviousMessage; prev != nil {
+		if prev.IsBot() {
+			return nil
+		}
+		rootTS, content = prev.ThreadRootTimeStamp(), prev.Text
+	}
+
+	record, ok := svc.sourceMentionRecord(event.Channel, rootTS, event.DeletedTimeStamp)
+	if !ok || record.Status == model.MentionStatusCancelled {
+		return nil
+	}
+
+	if err := svc.repo.UpdateMentionRecordStatus(svc.ctx, record.ID, model.MentionStatusCancelled); err != nil {
+		svc.l.Errorf("cancel mention record failed, err: %+v", err)
+		return nil
+	}
+
+	svc.goTracked(func() {
+		svc.emit(svc.Name, model.WebhookEventMentionCancelled, mentionWebhookData(record, model.MentionStatusCancelled))
+	})
+
+	svc.goJob(_jobMentionUpdate, updateJob{MentionID: record.ID, Content: content, Cancelled: true})
+	return nil
+}
+
+/*
+sourceMentionRecord returns the mention record when the message of ts is the source message of it,
+which is the first mention of the thread, or the thread root of records before tracking events.
+*/
+func (svc *SlackBot) sourceMentionRecord(channel, rootTS, ts string) (model.MentionRecord, bool) {
+	record, err := svc.repo.FindMentionRecord(svc.ctx, svc.Name, channel, rootTS)
+	if err != nil {
+		svc.l.Debugf("find mention record of message, channel: %s, ts: %s, err: %+v", channel, ts, err)
+		return model.MentionRecord{}, false
+	}
+
+	events, err := svc.repo.ListMentionEvents(svc.ctx, record.ID)
+	if err != nil {
+		svc.l.Errorf("list mention events failed, err: %+v", err)
+		return model.MentionRecord{}, false
+	}
+
+	sourceTS := record.Timestamp
+	if len(events) != 0 {
+		sourceTS = events[0].Timestamp
+	}
+
+	return record, sourceTS == ts
+}
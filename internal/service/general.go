@@ -1,10 +1,11 @@
 package service
 
 import (
+	"bitopi/internal/actioncodec"
+	"bitopi/internal/i18n"
 	"bitopi/internal/model"
-	"bitopi/internal/util"
-	"encoding/json"
-	"fmt"
+	"bitopi/internal/platform"
+	"bitopi/internal/slack"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -19,134 +20,177 @@ func (svc *Service) ok(c echo.Context, i ...interface{}) error {
 	return c.JSON(http.StatusOK, nil)
 }
 
-func (svc *Service) postMessage(notifier util.SlackNotifier, msg util.Messenger) ([]byte, error) {
-	res, _, err := notifier.Send(svc.ctx, http.MethodPost, util.PostChat, msg)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
-}
-
-func (svc *Service) getMessage(notifier util.SlackNotifier, channel, ts string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s?channel=%s&ts=%s", util.GetChat, channel, ts)
-	res, _, err := notifier.Send(svc.ctx, http.MethodGet, util.Url(url), &util.GeneralMsg{})
+func (svc *Service) getMessage(channel, ts string) (slack.Message, error) {
+	res, err := svc.client.ConversationReplies(svc.ctx, slack.RepliesRequest{
+		Channel: channel,
+		TS:      ts,
+		Limit:   1,
+	})
 	if err != nil {
-		return nil, err
+		return slack.Message{}, err
 	}
 
-	m := map[string]interface{}{}
-	if err := json.Unmarshal(res, &m); err != nil {
-		return nil, err
+	if len(res.Messages) == 0 {
+		return slack.Message{}, errors.New("empty messages")
 	}
 
-	if m["messages"] == nil {
-		return nil, errors.New("nil messages")
-	}
-
-	msgs := m["messages"].([]interface{})
-	if len(msgs) == 0 {
-		return nil, errors.New("empty messages")
-	}
-
-	for _, raw := range msgs {
-		msg := raw.(map[string]interface{})
-		if msg["ts"] == ts {
+	for _, msg := range res.Messages {
+		if msg.TS == ts {
 			return msg, nil
 		}
 	}
 
-	return nil, errors.New("can't find match message")
+	return slack.Message{}, errors.New("can't find match message")
 }
 
-func (svc *Service) getDirectChannel(userID, token string) (string, error) {
-	buf, _, err := util.HttpRequest(util.HttpRequestOption{
-		Method:       http.MethodPost,
-		Url:          "https://slack.com/api/conversations.open?users=" + userID,
-		Token:        token,
-		IsUrlencoded: true,
-	})
+func (svc *Service) getDirectChannel(userID string) (string, error) {
+	channel, err := svc.client.OpenConversation(svc.ctx, userID)
 	if err != nil {
 		return "", err
 	}
 
-	c := &model.SlackDirectChannel{}
-	if err := json.Unmarshal(buf, c); err != nil {
-		return "", err
+	svc.l.Debugf("success get direct channel id %s", channel)
+	return channel, nil
+}
+
+func (svc *Service) getPermalink(channel, messageTimestamp string) (string, error) {
+	return svc.chat.Permalink(svc.ctx, platform.MessageRef{Channel: channel, ID: messageTimestamp})
+}
+
+/*
+sendDirectMessage notifies the user of the mention with the link, and records the direct message of the mention record.
+
+Retries look for the direct message of the idempotency key first on platforms which can find it,
+so a direct message whose record failed to be written is recorded by the retry instead of being sent again.
+*/
+func (svc *Service) sendDirectMessage(opt model.SlackDirectMsgOption, link, userID string) (platform.MessageRef, error) {
+	locale := svc.userLocale(userID)
+	directMessageText := i18n.T(locale, i18n.MsgNewMention, link, opt.User, opt.LinkChannel)
+	if len(opt.ResendUserID) != 0 {
+		directMessageText = directMessageText + i18n.T(locale, i18n.MsgResentFrom, opt.ResendUserID)
 	}
 
-	if !c.OK {
-		return "", errors.Errorf("invalid request, response:\n%s", string(buf))
+	metadata := notificationMetadata(opt.ServiceName, opt.MentionRecordID)
+	if len(opt.IdempotencyKey) != 0 {
+		metadata.EventPayload[_outboxKeyField] = opt.IdempotencyKey
+	}
+
+	ref, found := platform.MessageRef{}, false
+	if finder, ok := svc.chat.(platform.ReplyFinder); ok && len(opt.IdempotencyKey) != 0 && !opt.RetrySince.IsZero() {
+		var err error
+		ref, found, err = finder.FindDirectMessage(svc.ctx, userID, opt.RetrySince, *metadata)
+		if err != nil {
+			return platform.MessageRef{}, errors.Wrap(err, "find direct message")
+		}
 	}
 
-	svc.l.Debugf("success get direct channel id %s", c.Channel.ID)
-	return c.Channel.ID, nil
+	if found {
+		svc.l.Infof("direct message of %s was sent, skip sending", opt.IdempotencyKey)
+		recorded, err := svc.hasDirectMessageRecord(opt.MentionRecordID, ref)
+		if err != nil || recorded {
+			return ref, err
+		}
+	} else {
+		var err error
+		ref, err = svc.chat.SendDirectMessage(svc.ctx, userID, platform.Message{
+			Text:     directMessageText,
+			Sections: svc.directMessageSections(locale, opt.ServiceName, opt.MentionRecordID, directMessageText, opt.EventContent, false),
+			Metadata: metadata,
+		})
+		if err != nil {
+			return platform.MessageRef{}, err
+		}
+	}
+
+	if err := svc.repo.CreateMentionDirectMessage(svc.ctx, model.MentionDirectMessage{
+		MentionRecordID: opt.MentionRecordID,
+		UserID:          userID,
+		Channel:         ref.Channel,
+		Timestamp:       ref.ID,
+		Header:          directMessageText,
+	}); err != nil {
+		return platform.MessageRef{}, errors.Wrap(err, "create mention direct message")
+	}
+	return ref, nil
 }
 
-func (svc *Service) getPermalink(notifier util.SlackNotifier, channel, messageTimestamp string) (string, error) {
-	url := fmt.Sprintf("%s?channel=%s&message_ts=%s", util.GetPermalink, channel, messageTimestamp)
-	response, _, err := notifier.Send(svc.ctx, http.MethodGet, util.Url(url), util.SlackMsg{})
+// hasDirectMessageRecord reports whether the direct message of the mention record is recorded.
+func (svc *Service) hasDirectMessageRecord(mentionID uint64, ref platform.MessageRef) (bool, error) {
+	dms, err := svc.repo.ListMentionDirectMessages(svc.ctx, mentionID)
 	if err != nil {
-		return "", err
+		return false, errors.Wrap(err, "list mention direct messages")
+	}
+	for _, dm := range dms {
+		if dm.Channel == ref.Channel && dm.Timestamp == ref.ID {
+			return true, nil
+		}
 	}
+	return false, nil
+}
 
-	permalink := model.SlackPermalinkResponse{}
-	if err := json.Unmarshal(response, &permalink); err != nil {
-		return "", err
+/*
+directMessageSections builds the direct message, cancelled mentions only keep the delete button.
+*/
+func (svc *Service) directMessageSections(locale i18n.Locale, service string, mentionID uint64, header, content string, cancelled bool) []platform.Section {
+	if cancelled {
+		header = i18n.T(locale, i18n.MsgSourceDeleted, header)
 	}
 
-	if len(permalink.Error) != 0 {
-		return "", errors.New(permalink.Error)
+	deleteAction := platform.Action{ID: _actionDelete, Text: i18n.T(locale, i18n.MsgButtonDelete), Value: svc.actionValue(service, _actionDelete, mentionID, nil), Style: platform.StyleDanger}
+	section := platform.Section{
+		ID:      _actionBlockDirect,
+		Text:    header,
+		Quote:   content,
+		Actions: []platform.Action{deleteAction},
+	}
+	if cancelled {
+		return []platform.Section{section}
 	}
 
-	return permalink.Permalink, nil
+	section.Actions = []platform.Action{
+		{ID: _actionResend, Text: i18n.T(locale, i18n.MsgButtonResend), Value: svc.actionValue(service, _actionResend, mentionID, nil), Style: platform.StylePrimary},
+		deleteAction,
+		{ID: _actionDeleteAndReply, Text: i18n.T(locale, i18n.MsgButtonDeleteReply), Value: svc.actionValue(service, _actionDeleteAndReply, mentionID, nil)},
+	}
+	return []platform.Section{section}
 }
 
-func (svc *Service) sendReplyDirectMessage(notifier util.SlackNotifier, opt model.SlackDirectMsgOption) error {
-	link, err := svc.getPermalink(notifier, opt.LinkChannel, opt.LinkTimestamp)
+/*
+updateDirectMessages rebuilds every direct message of the mention record with the content.
+*/
+func (svc *Service) updateDirectMessages(service string, mentionRecordID uint64, content string, cancelled bool) error {
+	dms, err := svc.repo.ListMentionDirectMessages(svc.ctx, mentionRecordID)
 	if err != nil {
 		return err
 	}
 
-	directMessageText := fmt.Sprintf("*<%s|新的提及> 來自 <@%s> <#%s>*",
-		link,
-		opt.User,
-		opt.LinkChannel,
-	)
-	if len(opt.ResendUserID) != 0 {
-		directMessageText = directMessageText + fmt.Sprintf(" _轉傳自 <@%s>_", opt.ResendUserID)
-	}
-
-	for _, member := range opt.Members {
-		ch := member[2 : len(member)-1]
-		if !opt.IsUser {
-			ch, err = svc.getDirectChannel(member, notifier.Token())
-			if err != nil {
-				return err
-			}
+	bulkErr := slack.NewBulkError("update direct message", len(dms))
+	for _, dm := range dms {
+		if len(dm.Header) == 0 {
+			svc.l.Warnf("skip updating direct message without header, channel: %s, ts: %s", dm.Channel, dm.Timestamp)
+			continue
 		}
 
-		msg := util.SlackReplyMsg{
-			Text:    directMessageText,
-			Channel: ch,
-		}.AddAttachments(
-			"type", "section",
-			"text", "",
-			"footer", opt.EventContent,
-			"callback_id", fmt.Sprintf("%s_direct_message_action", opt.ServiceName),
-			"actions", []model.SlackActionButton{
-				model.NewSlackActionButton("primary", svc.actionValue(opt.MentionRecordID, "resend"), "轉傳給..."),
-				model.NewSlackActionButton("danger", svc.actionValue(opt.MentionRecordID, "delete"), "刪除"),
-				model.NewSlackActionButton("default", svc.actionValue(opt.MentionRecordID, "delete.and.reply"), "刪除並回覆"),
-			},
-		)
-
-		if _, err := svc.postMessage(notifier, msg); err != nil {
-			return err
-		}
+		err := svc.chat.UpdateMessage(svc.ctx, platform.MessageRef{Channel: dm.Channel, ID: dm.Timestamp}, platform.Message{
+			Text:     dm.Header,
+			Sections: svc.directMessageSections(svc.userLocale(dm.UserID), service, mentionRecordID, dm.Header, content, cancelled),
+		})
+		bulkErr.Add(dm.UserID, err)
 	}
-	return nil
+	return bulkErr.Err()
 }
 
-func (svc *Service) actionValue(id, action string) string {
-	return id + "," + action
+// actionValue encodes the signed payload of buttons and modals, it returns empty string when encoding failed.
+func (svc *Service) actionValue(service, action string, mentionID uint64, args map[string]string) string {
+	value, err := svc.codec.Encode(actioncodec.Payload{
+		Service:   service,
+		Action:    action,
+		MentionID: mentionID,
+		Args:      args,
+	})
+	if err != nil {
+		svc.l.Errorf("encode action value failed, err: %+v", err)
+		return ""
+	}
+	return value
 }
@@ -1,14 +1,17 @@
 package service
 
 import (
-	"bitopi/internal/util"
+	"bitopi/internal/i18n"
+	"bitopi/internal/model"
+	"bitopi/internal/platform"
+	"bitopi/internal/slack"
 	"fmt"
-	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func (svc *SlackBot) publishHomeView(notifier util.SlackNotifier) error {
+func (svc *SlackBot) publishHomeView() error {
 	subscribers, err := svc.repo.GetSubscriber(svc.ctx)
 	if err != nil {
 		return err
@@ -19,6 +22,11 @@ func (svc *SlackBot) publishHomeView(notifier util.SlackNotifier) error {
 		return err
 	}
 
+	admins, err := svc.repo.ListAdmin(svc.ctx, svc.Name)
+	if err != nil {
+		return err
+	}
+
 	subscriberIDs := map[string]bool{}
 	for _, subscriber := range subscribers {
 		subscriberIDs[subscriber.UserID] = true
@@ -27,165 +35,152 @@ func (svc *SlackBot) publishHomeView(notifier util.SlackNotifier) error {
 		subscriberIDs[member.UserID] = true
 	}
 
-	view, err := svc.getHomeView(false)
-	if err != nil {
-		return err
+	/* admins get the home with the setting button even if they don't subscribe */
+	adminIDs := map[string]bool{}
+	for _, admin := range admins {
+		adminIDs[admin.UserID] = true
+		subscriberIDs[admin.UserID] = true
 	}
 
+	/* homes are built once per locale and admin status */
+	type homeKey struct {
+		locale  i18n.Locale
+		isAdmin bool
+	}
+	homes := map[homeKey]platform.Home{}
+	bulkErr := slack.NewBulkError("publish home view", len(subscriberIDs))
 	for subscriberID := range subscriberIDs {
-		if _, _, err := notifier.Send(svc.ctx, http.MethodPost, util.PostHome, svc.createHomeViewRequest(view, subscriberID)); err != nil {
-			return err
+		key := homeKey{locale: svc.userLocale(subscriberID), isAdmin: adminIDs[subscriberID]}
+		home, ok := homes[key]
+		if !ok {
+			home, err = svc.getHome(key.isAdmin, key.locale)
+			if err != nil {
+				return err
+			}
+			homes[key] = home
 		}
-	}
-
-	return nil
-}
 
-func (svc *SlackBot) createHomeViewRequest(view map[string]interface{}, userID string) *util.SlackHomeViewMsg {
-	return &util.SlackHomeViewMsg{
-		UserID: userID,
-		View:   view,
+		if err := svc.chat.PublishHome(svc.ctx, subscriberID, home); err != nil {
+			bulkErr.Add(subscriberID, err)
+		}
 	}
+
+	return bulkErr.Err()
 }
 
-func (svc *SlackBot) getHomeView(isAdmin bool) (map[string]interface{}, error) {
+func (svc *SlackBot) getHome(isAdmin bool, locale i18n.Locale) (platform.Home, error) {
 	mentionTimes, err := svc.repo.CountMentionRecord(svc.ctx, svc.Name)
 	if err != nil {
 		svc.l.Errorf("count mention record failed, err: %+v", err)
-		return nil, err
-	}
-
-	members, err := svc.listMember(true)
-	if err != nil {
-		svc.l.Errorf("list members failed, err: %+v", err)
-		return nil, err
+		return platform.Home{}, err
 	}
 
 	rMsg, err := svc.repo.GetReplyMessage(svc.ctx, svc.Name)
 	if err != nil {
 		svc.l.WithError(err).Errorf("get reply message")
-		return nil, err
+		return platform.Home{}, err
 	}
 
-	startDate := svc.getStartDate()
-	dutyDuration := svc.getDutyDuration()
-	dutyMemberCountPerTime := svc.getDutyMemberCountPerTime()
-
-	dutyMember, leftMembers, err := svc.getDutyMember(true, startDate, dutyMemberCountPerTime, dutyDuration)
+	now := time.Now()
+	duties, err := svc.rotationDuties(svc.rotations, now)
 	if err != nil {
-		svc.l.Errorf("get duty member failed, err: %+v", err)
-		return nil, err
+		svc.l.Errorf("get rotation duties failed, err: %+v", err)
+		return platform.Home{}, err
 	}
+	replyText := svc.renderMessage(rMsg.HomeMentionMessage, rMsg.MentionMultiMember, duties, nil)
+
+	rosterTexts := make([]string, 0, len(svc.rotations))
+	rosterNotes := make([]string, 0, len(svc.rotations))
+	for _, rot := range svc.rotations {
+		roster, err := svc.getRoster(rot, now)
+		if err != nil {
+			svc.l.Errorf("get roster failed, err: %+v", err)
+			return platform.Home{}, err
+		}
+
+		rosterMembers, err := roster.MemberList()
+		if err != nil {
+			svc.l.Errorf("parse roster members failed, err: %+v", err)
+			return platform.Home{}, err
+		}
+		members := svc.transferMembersToString(rosterMembers, true)
 
-	replyText := ""
-	if rMsg.MentionMultiMember {
-		replyText = fmt.Sprintf(rMsg.HomeMentionMessage, strings.Join(dutyMember, " "), strings.Join(leftMembers, " "))
-	} else {
-		replyText = fmt.Sprintf(rMsg.HomeMentionMessage, strings.Join(dutyMember, " "))
+		title, prefix := i18n.T(locale, i18n.MsgHomeRoster), ""
+		if len(svc.rotations) > 1 {
+			title, prefix = i18n.T(locale, i18n.MsgHomeRotationRoster, rot.Title), rot.Title+": "
+		}
+		rosterTexts = append(rosterTexts, fmt.Sprintf("%s \n%s", title, strings.Join(members, " ")))
+		rosterNotes = append(rosterNotes, i18n.T(locale, i18n.MsgHomeShift, prefix, roster.MemberCountPerTime, roster.Duration/(time.Hour*24*7)))
 	}
 
-	history := `*更新歷史*
-- 2023.5 新增調整值班人數及時間、新增刪除並回覆按鈕
-- 2023.3 修改私訊的提及連結到對話串
-- 2023.1 新增私訊通知功能/新增首頁按鈕
-`
+	changes := svc.recentChangesText(locale)
+	history := i18n.T(locale, i18n.MsgHomeHistory)
+
+	clearAll := svc.clearAction(locale, _actionHomeClear, i18n.T(locale, i18n.MsgHomeClear), i18n.T(locale, i18n.MsgHomeClearConfirm), nil)
+	clearAll.Style = platform.StyleDanger
 
-	adminSetButton := ""
+	actions := []platform.Action{}
 	if isAdmin {
-		adminSetButton = `
-{
-	"type": "button",
-	"text": {
-		"type": "plain_text",
-		"text": "更改設定",
-		"emoji": true
-	},
-	"style": "primary",
-	"value": "set",
-	"action_id": "set"
-},`
-	}
-
-	return map[string]interface{}{
-		"type": "home",
-		"blocks": fmt.Sprintf(`[
-				{
-					"type": "section",
-					"text": {
-						"type": "mrkdwn",
-						"text": "%s \n\n*輪值人員順序* \n%s"
-					}
-				},
-				{
-					"type": "context",
-					"elements": [
-						{
-							"type": "mrkdwn",
-							"text": "每次 %d 人輪值，為期 %d 週"
-						}
-					]
-				},
-				{
-					"type": "context",
-					"elements": [
-						{
-							"type": "mrkdwn",
-							"text": "此機器人已被提及 %d 次"
-						}
-					]
-				},
-				{
-					"type": "actions",
-					"elements": [%s
-						{
-							"type": "button",
-							"text": {
-								"type": "plain_text",
-								"text": "刪除所有通知",
-								"emoji": true
-							},
-							"style": "danger",
-							"value": "clear",
-							"action_id": "clear",
-							"confirm": {
-								"style": "danger",
-								"title": {
-									"type": "plain_text",
-									"text": "刪除所有通知"
-								},
-								"text": {
-									"type": "mrkdwn",
-									"text": "是否刪除此機器人傳送給您的所有通知訊息？"
-								},
-								"confirm": {
-									"type": "plain_text",
-									"text": "清除"
-								},
-								"deny": {
-									"type": "plain_text",
-									"text": "取消"
-								}
-							}
-						}
-					]
-				},
-				{
-					"type": "context",
-					"elements": [
-						{
-							"type": "mrkdwn",
-							"text": "%s"
-						}
-					]
-				}
-			]`,
-			replyText,
-			strings.Join(members, " "),
-			dutyMemberCountPerTime,
-			dutyDuration/(time.Hour*24*7),
-			mentionTimes,
-			adminSetButton,
-			history,
-		),
+		actions = append(actions, platform.Action{ID: _actionHomeSet, Text: i18n.T(locale, i18n.MsgHomeSetting), Value: svc.actionValue(svc.Name, _actionHomeSet, 0, nil), Style: platform.StylePrimary})
+	}
+	actions = append(actions,
+		svc.clearAction(locale, _actionHomeClearResolved, i18n.T(locale, i18n.MsgHomeClearResolved), i18n.T(locale, i18n.MsgHomeClearResolvedConfirm), nil),
+		svc.clearAction(locale, _actionHomeClearOlder, i18n.T(locale, i18n.MsgHomeClearOlder, _clearOlderThanDays), i18n.T(locale, i18n.MsgHomeClearOlderConfirm, _clearOlderThanDays),
+			map[string]string{"days": strconv.Itoa(_clearOlderThanDays)}),
+		clearAll,
+	)
+
+	return platform.Home{
+		Status: i18n.T(locale, i18n.MsgHomeStatus, strings.Join(userTags(matchedDutyMembers(duties)), " ")),
+		Sections: []platform.Section{
+			{Text: fmt.Sprintf("%s \n\n%s", replyText, strings.Join(rosterTexts, "\n\n")), Notes: rosterNotes},
+			{Notes: []string{i18n.T(locale, i18n.MsgMentionedTimes, mentionTimes)}},
+			{Notes: []string{changes}},
+			{Actions: actions},
+			{Notes: []string{history}},
+		},
 	}, nil
 }
+
+const (
+	_homeAuditLogLimit = 5
+)
+
+func (svc *SlackBot) recentChangesText(locale i18n.Locale) string {
+	logs, err := svc.repo.ListAuditLogs(svc.ctx, svc.Name, _homeAuditLogLimit)
+	if err != nil {
+		svc.l.Warnf("list audit logs failed, err: %+v", err)
+		return i18n.T(locale, i18n.MsgRecentChanges) + "\n" + i18n.T(locale, i18n.MsgRecentChangesError)
+	}
+
+	if len(logs) == 0 {
+		return i18n.T(locale, i18n.MsgRecentChanges) + "\n" + i18n.T(locale, i18n.MsgRecentChangesEmpty)
+	}
+
+	lines := make([]string, 0, len(logs)+1)
+	lines = append(lines, i18n.T(locale, i18n.MsgRecentChanges))
+	for _, log := range logs {
+		actor := log.Actor
+		if log.Source == model.AuditSourceSlackModal {
+			actor = "<@" + log.Actor + ">"
+		}
+		lines = append(lines, fmt.Sprintf("- %s %s `%s` (%s)", log.CreatedAt.Format("2006.01.02 15:04"), actor, log.Action, log.Source))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clearAction builds an action which clears notifications with the scope of the action ID.
+func (svc *SlackBot) clearAction(locale i18n.Locale, actionID, text, confirm string, args map[string]string) platform.Action {
+	return platform.Action{
+		ID:    actionID,
+		Text:  text,
+		Value: svc.actionValue(svc.Name, actionID, 0, args),
+		Confirm: &platform.Confirm{
+			Title:  text,
+			Text:   confirm,
+			Submit: i18n.T(locale, i18n.MsgHomeClearSubmit),
+			Cancel: i18n.T(locale, i18n.MsgCancel),
+			Style:  platform.StyleDanger,
+		},
+	}
+}
@@ -1,18 +1,103 @@
 package service
 
 import (
+	"bitopi/internal/actioncodec"
+	"bitopi/internal/i18n"
+	"bitopi/internal/logging"
+	"bitopi/internal/metrics"
 	"bitopi/internal/model"
-	"bitopi/internal/util"
+	"bitopi/internal/platform"
+	"context"
 	"encoding/json"
-	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 )
 
+const (
+	_actionResend            = "mention.resend"
+	_actionDelete            = "mention.delete"
+	_actionDeleteAndReply    = "mention.delete_and_reply"
+	_actionHomeClear         = "clear"
+	_actionHomeClearResolved = "clear.resolved"
+	_actionHomeClearOlder    = "clear.older"
+	_actionHomeSet           = "set"
+
+	/* view submissions are routed by 'view.' + callback ID */
+	_viewRoutePrefix   = "view."
+	_callbackResend    = "mention.resend"
+	_callbackSetting   = "bot.setting"
+	_actionBlockDirect = "mention.actions"
+	_unknownAction     = "unknown" /* metrics label of actions without route */
+
+	/* callback ID suffix of the attachments of direct messages sent before Block Kit */
+	_legacyCallbackSuffix = "_direct_message_action"
+
+	/* field IDs of the forms */
+	_inputUsers = "multi_users_select-action"
+	_inputDate  = "datepicker-action"
+	_inputReply = "plain_text_input-action"
+)
+
+type interactionAction struct {
+	ActionID  string
+	Value     string
+	TriggerID string
+	UserID    string
+	Channel   string
+	MessageTS string
+	Data      actioncodec.Payload
+	Payload   map[string]interface{}
+	Inputs    map[string][]string /* values of the submitted form by field ID */
+	Legacy    bool                /* the action of a message sent before Block Kit, its value isn't signed */
+}
+
+// input returns the first value of the field of the submitted form.
+func (action interactionAction) input(fieldID string) string {
+	if values := action.Inputs[fieldID]; len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type actionRoute func(svc *SlackInteraction, action interactionAction) interface{}
+
+/*
+_actionRoutes routes every button action by action ID and every view submission by callback ID.
+*/
+var _actionRoutes = map[string]actionRoute{
+	_actionResend:                       (*SlackInteraction).resendActionReply,
+	_actionDelete:                       (*SlackInteraction).deleteActionReply,
+	_actionDeleteAndReply:               (*SlackInteraction).deleteAndReplyActionReply,
+	_actionHomeClear:                    (*SlackInteraction).clearReply,
+	_actionHomeClearResolved:            (*SlackInteraction).clearReply,
+	_actionHomeClearOlder:               (*SlackInteraction).clearReply,
+	_actionHomeSet:                      (*SlackInteraction).setReply,
+	_viewRoutePrefix + _callbackResend:  (*SlackInteraction).resendSubmissionHandler,
+	_viewRoutePrefix + _callbackSetting: (*SlackInteraction).settingSubmissionHandler,
+}
+
+/*
+_legacyActions map the buttons of direct messages sent before Block Kit onto the routes,
+their values are '<mention ID>,<action>' under the callback ID '<bot>_direct_message_action'.
+*/
+var _legacyActions = map[string]string{
+	"resend":           _actionResend,
+	"delete":           _actionDelete,
+	"delete.and.reply": _actionDeleteAndReply,
+}
+
+/*
+_legacyHomeActions are the buttons of home views published before Block Kit, whose value is the action ID.
+*/
+var _legacyHomeActions = map[string]bool{
+	_actionHomeSet:   true,
+	_actionHomeClear: true,
+}
+
 type SlackInteraction struct {
 	SlackBot
 }
@@ -34,18 +119,123 @@ func (svc *SlackInteraction) interactionResponse(c echo.Context) interface{} {
 	payload, err := svc.parsePayload(c)
 	if err != nil || payload == nil {
 		svc.l.Errorf("parse payload failed, err: %+v", err)
+		return nil
 	}
 
-	switch payload["type"].(string) {
-	case "view_submission": /* .handle action from action block view */
-		return svc.viewSubmissionHandler(c, payload)
-	case "interactive_message": /* handle action from button of bot direct message */
-		return svc.actionHandler(payload)
-	case "block_actions": /* handle action from button of bot home*/
-		return svc.homeHandler(payload)
-	default:
-		return svc.noneInteractionReply(payload)
+	return svc.payloadResponse(payload, c.Response().Header().Get(echo.HeaderXRequestID))
+}
+
+/*
+payloadResponse routes the payload to the bot of its workspace, it's shared by HTTP and Socket Mode.
+requestID is the request or the envelope of the payload.
+*/
+func (svc *SlackInteraction) payloadResponse(payload map[string]interface{}, requestID string) interface{} {
+	teamID := stringField(mapField(payload, "team"), "id")
+	bot, ok := svc.requestWorkspace(teamID)
+	interaction := SlackInteraction{SlackBot: bot}
+	interaction.withLogFields(map[string]interface{}{
+		logging.FieldRequestID: requestID,
+		logging.FieldTeam:      teamID,
+	})
+	if !ok {
+		interaction.l.Warnf("reject interaction of workspace %s which didn't install the bot", teamID)
+		return nil
+	}
+	return interaction.interact(payload)
+}
+
+// interact parses the action of the payload and routes it.
+func (svc *SlackInteraction) interact(payload map[string]interface{}) interface{} {
+	action, err := svc.parseInteractionAction(payload)
+	if err != nil {
+		svc.l.Errorf("parse interaction action failed, err: %+v", err)
+		return svc.noneInteractionReply(action)
 	}
+	return svc.route(action)
+}
+
+// route verifies the action, and routes it by the action ID.
+func (svc *SlackInteraction) route(action interactionAction) interface{} {
+	svc.withLogFields(map[string]interface{}{
+		logging.FieldAction:  action.ActionID,
+		logging.FieldUser:    action.UserID,
+		logging.FieldChannel: action.Channel,
+	})
+
+	if action.Legacy {
+		svc.l.Infof("legacy action: %s", action.ActionID)
+		if err := svc.verifyLegacyAction(action); err != nil {
+			svc.l.Warnf("reject legacy action %s, err: %+v", action.ActionID, err)
+			return svc.noneInteractionReply(action)
+		}
+	} else if err := svc.verifyAction(&action); err != nil {
+		svc.l.Warnf("reject action %s, err: %+v", action.ActionID, err)
+		if strings.HasPrefix(action.ActionID, _viewRoutePrefix) {
+			return svc.closeViewReply()
+		}
+		return svc.noneInteractionReply(action)
+	}
+
+	route, ok := _actionRoutes[action.ActionID]
+	if !ok {
+		metrics.Interaction(svc.Name, _unknownAction)
+		svc.l.Warnf("unknown action: %s", action.ActionID)
+		if strings.HasPrefix(action.ActionID, _viewRoutePrefix) {
+			return svc.closeViewReply()
+		}
+		return svc.noneInteractionReply(action)
+	}
+
+	if action.Data.MentionID != 0 {
+		svc.withLogFields(map[string]interface{}{logging.FieldMentionID: action.Data.MentionID})
+	}
+
+	svc.l.Infof("action: %s", action.ActionID)
+	metrics.Interaction(svc.Name, action.ActionID)
+	return route(svc, action)
+}
+
+/*
+verifyAction decodes the signed value of the action, values of other bots or other actions are rejected.
+*/
+func (svc *SlackInteraction) verifyAction(action *interactionAction) error {
+	data, err := svc.codec.Decode(action.Value)
+	if err != nil {
+		return err
+	}
+
+	if data.Service != svc.Name {
+		return errors.Errorf("mismatch service: %s", data.Service)
+	}
+
+	if data.Action != action.ActionID {
+		return errors.Errorf("mismatch action: %s", data.Action)
+	}
+
+	action.Data = data
+	return nil
+}
+
+/*
+verifyLegacyAction accepts the unsigned buttons of direct messages sent before Block Kit only on the direct message
+recorded for the mention, so a hand-made payload can't act on other mentions.
+Legacy home buttons aren't bound to a mention, they only act for the user and the setting checks the admin.
+*/
+func (svc *SlackInteraction) verifyLegacyAction(action interactionAction) error {
+	if action.Data.MentionID == 0 {
+		return nil
+	}
+
+	dms, err := svc.repo.ListMentionDirectMessages(svc.ctx, action.Data.MentionID)
+	if err != nil {
+		return errors.Wrap(err, "list mention direct messages")
+	}
+	for _, dm := range dms {
+		if dm.UserID == action.UserID && dm.Channel == action.Channel && dm.Timestamp == action.MessageTS {
+			return nil
+		}
+	}
+	return errors.Errorf("message %s of channel %s isn't a direct message of mention %d", action.MessageTS, action.Channel, action.Data.MentionID)
 }
 
 func (svc *SlackInteraction) parsePayload(c echo.Context) (map[string]interface{}, error) {
@@ -64,246 +254,245 @@ func (svc *SlackInteraction) parsePayload(c echo.Context) (map[string]interface{
 	}
 
 	for k, v := range payload {
-		if k == "original_message" {
-			svc.l.Debug("--- original_message ---")
-			for kk, vv := range v.(map[string]interface{}) {
-				svc.l.Debug(kk, ": ", vv)
-			}
-			svc.l.Debug("------")
-			continue
+		/* the verification token of the app is sent in every payload */
+		if k == "token" {
+			v = logging.Redacted
 		}
-
 		svc.l.Debug(k, ": ", v)
 	}
 
 	return payload, nil
 }
 
-func (svc *SlackInteraction) parseActionValue(payload map[string]interface{}) (id, value string, err error) {
-	v, err := svc.parseAction(payload)
-	if err != nil {
-		return "", "", err
+/*
+parseInteractionAction parses block_actions and view_submission payloads,
+and interactive_message payloads of the messages sent before Block Kit.
+*/
+func (svc *SlackInteraction) parseInteractionAction(payload map[string]interface{}) (interactionAction, error) {
+	action := interactionAction{
+		TriggerID: stringField(payload, "trigger_id"),
+		UserID:    stringField(mapField(payload, "user"), "id"),
+		Channel:   stringField(mapField(payload, "channel"), "id"),
+		MessageTS: stringField(payload, "message_ts"),
+		Payload:   payload,
 	}
 
-	vs := strings.Split(v, ",")
-	if len(vs) != 2 {
-		return "", "", errors.Errorf("mismatch action value length: %d", len(vs))
+	if container := mapField(payload, "container"); container != nil {
+		if ch := stringField(container, "channel_id"); len(ch) != 0 {
+			action.Channel = ch
+		}
+		if ts := stringField(container, "message_ts"); len(ts) != 0 {
+			action.MessageTS = ts
+		}
 	}
-	return vs[0], vs[1], nil
-}
 
-func (svc *SlackInteraction) actionHandler(payload map[string]interface{}) interface{} {
-	id, action, err := svc.parseActionValue(payload)
-	if err != nil {
-		svc.l.Errorf("parse action, err: %+v", err)
-		return svc.noneInteractionReply(payload)
-	}
-
-	svc.l.Infof("action: %s", action)
-	switch action {
-	case "delete":
-		return svc.deleteActionReply()
-	case "delete.and.reply":
-		return svc.deleteAndReplyActionReply(id)
-	case "resend":
-		return svc.resendActionReply(id, payload)
+	switch stringField(payload, "type") {
+	case "view_submission":
+		action.ActionID = _viewRoutePrefix + stringField(mapField(payload, "view"), "callback_id")
+		action.Value = stringField(mapField(payload, "view"), "private_metadata")
+		action.Inputs = viewInputs(payload)
+		return action, nil
+	case "block_actions":
+		first, err := firstAction(payload)
+		if err != nil {
+			return action, err
+		}
+		action.ActionID = stringField(first, "action_id")
+		action.Value = stringField(first, "value")
+		if _legacyHomeActions[action.ActionID] && action.Value == action.ActionID {
+			action.Legacy = true
+			action.Data = actioncodec.Payload{Service: svc.Name, Action: action.ActionID}
+		}
+		return action, nil
+	case "interactive_message":
+		return svc.parseLegacyAction(action, payload)
 	default:
-		svc.l.Warnf("unknown action: %s", action)
-		return svc.noneInteractionReply(payload)
+		return action, errors.Errorf("unsupported interaction type: %s", stringField(payload, "type"))
 	}
 }
 
-func (svc *SlackInteraction) parseAction(payload map[string]interface{}) (string, error) {
-	if payload["actions"] == nil {
-		return "", errors.New("empty actions content")
+/*
+parseLegacyAction maps the button of the attachment of the direct message onto the route of the action.
+*/
+func (svc *SlackInteraction) parseLegacyAction(action interactionAction, payload map[string]interface{}) (interactionAction, error) {
+	if callbackID := stringField(payload, "callback_id"); callbackID != svc.Name+_legacyCallbackSuffix {
+		return action, errors.Errorf("mismatch legacy callback ID: %s", callbackID)
 	}
 
-	actions, ok := payload["actions"].([]interface{})
+	first, err := firstAction(payload)
+	if err != nil {
+		return action, err
+	}
+
+	action.Value = stringField(first, "value")
+	id, name, _ := strings.Cut(action.Value, ",")
+	route, ok := _legacyActions[name]
 	if !ok {
-		return "", errors.New("transfer actions type error")
+		return action, errors.Errorf("unknown legacy action: %s", action.Value)
 	}
 
-	value, exist := actions[0].(map[string]interface{})["value"]
-	if !exist {
-		return "", errors.New("empty actions value")
+	mentionID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return action, errors.Wrapf(err, "parse mention ID of legacy action %s", action.Value)
+	}
+
+	action.ActionID, action.Legacy = route, true
+	action.Data = actioncodec.Payload{Service: svc.Name, Action: route, MentionID: mentionID}
+	return action, nil
+}
+
+func firstAction(payload map[string]interface{}) (map[string]interface{}, error) {
+	actions, ok := payload["actions"].([]interface{})
+	if !ok || len(actions) == 0 {
+		return nil, errors.New("empty actions content")
 	}
 
-	svc.l.Debug("action value: ", value)
-	return value.(string), nil
+	first, ok := actions[0].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("transfer actions type error")
+	}
+	return first, nil
+}
+
+func mapField(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	v, _ := m[key].(string)
+	return v
 }
 
-func (svc *SlackInteraction) resendActionReply(mentionID string, payload map[string]interface{}) interface{} {
+func (svc *SlackInteraction) resendActionReply(action interactionAction) interface{} {
 	svc.l.Debug("execute resend")
-	go func() {
-		notifier := util.NewSlackNotifier(svc.Token)
-		_, _, err := notifier.Send(svc.ctx, http.MethodPost, util.PostView,
-			resendView(payload["trigger_id"].(string), mentionID),
-		)
+	svc.goTracked(func() {
+		err := svc.chat.OpenForm(svc.ctx, action.TriggerID, resendForm(svc.userLocale(action.UserID), svc.actionValue(svc.Name, _viewRoutePrefix+_callbackResend, action.Data.MentionID, nil)))
 		if err != nil {
 			svc.l.Errorf("send resend action view, err: %+v", err)
 			return
 		}
-	}()
+	})
 
-	return svc.noneInteractionReply(payload)
+	return svc.noneInteractionReply(action)
 }
 
-func (svc *SlackInteraction) deleteActionReply() interface{} {
+func (svc *SlackInteraction) deleteActionReply(action interactionAction) interface{} {
 	svc.l.Debug("execute delete")
-	return struct {
-		DeleteOriginal bool `json:"delete_original"`
-	}{
-		DeleteOriginal: true,
-	}
+	return svc.deleteOriginalReply(action)
 }
 
-func (svc *SlackInteraction) deleteAndReplyActionReply(mentionID string) interface{} {
-	svc.l.Debug("execute delete and reply")
-	id, err := strconv.Atoi(mentionID)
-	if err != nil {
-		svc.l.Errorf("convert mention ID, err: %+v", err)
-		return nil
+func (svc *SlackInteraction) deleteOriginalReply(action interactionAction) interface{} {
+	if err := svc.chat.DeleteMessage(svc.ctx, platform.MessageRef{Channel: action.Channel, ID: action.MessageTS}); err != nil {
+		svc.l.Errorf("delete message, err: %+v", err)
 	}
+	return nil
+}
 
-	record, err := svc.repo.GetMentionRecord(svc.ctx, uint64(id))
+func (svc *SlackInteraction) deleteAndReplyActionReply(action interactionAction) interface{} {
+	svc.l.Debug("execute delete and reply")
+	record, err := svc.repo.GetMentionRecord(svc.ctx, action.Data.MentionID)
 	if err != nil {
 		svc.l.Errorf("get mention record, err: %+v", err)
 		return nil
 	}
 
-	text := "已處理，有需要再 Tag 我 ☺️"
+	text := svc.t(i18n.MsgDoneReply)
 	msg, err := svc.getReplyMessage()
 	if err == nil && len(msg.DoneReplyMessage) != 0 {
 		text = msg.DoneReplyMessage
 	}
 
-	notifier := util.NewSlackNotifier(svc.Token)
-	svc.postMessage(notifier, util.SlackReplyMsg{
-		Text:      text,
-		Channel:   record.Channel,
-		TimeStamp: record.Timestamp,
-	})
+	if _, err := svc.chat.PostReply(svc.ctx, platform.Message{
+		Channel:  record.Channel,
+		ThreadID: record.Timestamp,
+		Text:     text,
+	}); err != nil {
+		svc.l.Errorf("post done reply, err: %+v", err)
+	}
 
-	return struct {
-		DeleteOriginal bool `json:"delete_original"`
-	}{
-		DeleteOriginal: true,
+	if err := svc.repo.UpdateMentionRecordStatus(svc.ctx, record.ID, model.MentionStatusResolved); err != nil {
+		svc.l.Errorf("update mention record status, err: %+v", err)
+	} else {
+		data := mentionWebhookData(record, model.MentionStatusResolved)
+		data.Actor = action.UserID
+		svc.goTracked(func() { svc.emit(svc.Name, model.WebhookEventMentionResolved, data) })
 	}
+
+	return svc.deleteOriginalReply(action)
 }
 
-func (svc *SlackInteraction) noneInteractionReply(payload map[string]interface{}) interface{} {
+func (svc *SlackInteraction) noneInteractionReply(action interactionAction) interface{} {
 	svc.l.Debug("execute original")
-	return payload["original_message"]
-}
-
-func resendView(triggerID, data string) util.SlackViewMsg {
-	return util.SlackViewMsg{
-		TriggerID: triggerID,
-		View: map[string]interface{}{
-			"private_metadata": data,
-			"type":             "modal",
-			"submit": util.PlainText{
-				Type:  "plain_text",
-				Text:  "轉傳",
-				Emoji: true,
-			},
-			"close": util.PlainText{
-				Type:  "plain_text",
-				Text:  "取消",
-				Emoji: true,
-			},
-			"title": util.PlainText{
-				Type:  "plain_text",
-				Text:  "轉傳給...",
-				Emoji: true,
-			},
-			"blocks": `[
-				{
-					"type": "input",
-					"element": {
-						"type": "multi_users_select",
-						"placeholder": {
-							"type": "plain_text",
-							"text": "選擇人員",
-							"emoji": true
-						},
-						"action_id": "multi_users_select-action"
-					},
-					"label": {
-						"type": "plain_text",
-						"text": "選擇要轉傳通知的使用者(可複選)",
-						"emoji": true
-					}
-				},
-				{
-					"type": "context",
-					"elements": [
-						{
-							"type": "plain_text",
-							"text": "＃通知將會透過機器人轉傳",
-							"emoji": true
-						}
-					]
-				}
-			]`,
+	if action.Payload == nil {
+		return nil
+	}
+	return action.Payload["original_message"]
+}
+
+func resendForm(locale i18n.Locale, data string) platform.Form {
+	return platform.Form{
+		ID:     _callbackResend,
+		Title:  i18n.T(locale, i18n.MsgResendTitle),
+		Submit: i18n.T(locale, i18n.MsgResendSubmit),
+		Cancel: i18n.T(locale, i18n.MsgCancel),
+		State:  data,
+		Fields: []platform.Field{
+			{ID: _inputUsers, Type: platform.FieldUsers, Label: i18n.T(locale, i18n.MsgResendUsers), Placeholder: i18n.T(locale, i18n.MsgResendUsersHolder)},
 		},
+		Footnote: i18n.T(locale, i18n.MsgResendHint),
 	}
 }
 
 // TODO: Add resend user to resend message
-func (svc *SlackInteraction) viewSubmissionHandler(_ echo.Context, payload map[string]interface{}) interface{} {
-	svc.l.Debug("handle view submission")
-	go func() {
-		view := payload["view"].(map[string]interface{})
-		mentionID := view["private_metadata"].(string)
-		id, err := strconv.Atoi(mentionID)
-		if err != nil {
-			svc.l.Errorf("convert mention ID, err: %+v", err)
-			return
-		}
+func (svc *SlackInteraction) resendSubmissionHandler(action interactionAction) interface{} {
+	svc.l.Debug("handle resend view submission")
+	svc.goJob(_jobMentionResend, resendJob{
+		MentionID:    action.Data.MentionID,
+		Users:        action.Inputs[_inputUsers],
+		ResendUserID: action.UserID,
+		ResendID:     strconv.FormatInt(time.Now().UnixNano(), 36),
+	})
 
-		record, err := svc.repo.GetMentionRecord(svc.ctx, uint64(id))
-		if err != nil {
-			svc.l.Errorf("get mention record, err: %+v", err)
-			return
-		}
+	return svc.closeViewReply()
+}
 
-		notifier := util.NewSlackNotifier(svc.Token)
-		msg, err := svc.getMessage(notifier, record.Channel, record.Timestamp)
-		if err != nil {
-			svc.l.Errorf("get message from slack, err: %+v", err)
-			return
+/*
+viewInputs returns the values of the inputs of the submitted view by action ID,
+the users of multi users selects, the date of date pickers and the text of plain text inputs.
+*/
+func viewInputs(payload map[string]interface{}) map[string][]string {
+	inputs := map[string][]string{}
+	values := mapField(mapField(mapField(payload, "view"), "state"), "values")
+	for _, v := range values {
+		block, ok := v.(map[string]interface{})
+		if !ok {
+			continue
 		}
 
-		values := view["state"].(map[string]interface{})["values"].(map[string]interface{})
-		resendUserID := payload["user"].(map[string]interface{})["id"].(string)
-		users := []string{}
-		for _, v := range values {
-			selectAction := v.(map[string]interface{})["multi_users_select-action"]
-			if selectAction == nil {
+		for actionID, i := range block {
+			input, ok := i.(map[string]interface{})
+			if !ok {
 				continue
 			}
-			selectedUsers := selectAction.(map[string]interface{})["selected_users"].([]interface{})
-			for _, u := range selectedUsers {
-				users = append(users, u.(string))
+
+			if selected, ok := input["selected_users"].([]interface{}); ok {
+				for _, u := range selected {
+					if s, ok := u.(string); ok {
+						inputs[actionID] = append(inputs[actionID], s)
+					}
+				}
+				continue
 			}
-		}
 
-		if err := svc.sendReplyDirectMessage(notifier, model.SlackDirectMsgOption{
-			MentionRecordID: mentionID,
-			ServiceName:     svc.Name,
-			User:            msg["user"].(string),
-			LinkChannel:     record.Channel,
-			LinkTimestamp:   record.Timestamp,
-			EventContent:    msg["text"].(string),
-			Members:         users,
-			ResendUserID:    resendUserID,
-		}); err != nil {
-			svc.l.Errorf("send reply direct message failed, err: %+v", err)
+			for _, key := range []string{"selected_date", "value"} {
+				if s := stringField(input, key); len(s) != 0 {
+					inputs[actionID] = append(inputs[actionID], s)
+				}
+			}
 		}
-	}()
-
-	return svc.closeViewReply()
+	}
+	return inputs
 }
 
 func (svc *SlackInteraction) closeViewReply() interface{} {
@@ -314,179 +503,193 @@ func (svc *SlackInteraction) closeViewReply() interface{} {
 	}
 }
 
-func (svc *SlackInteraction) homeHandler(payload map[string]interface{}) interface{} {
-	action, err := svc.parseAction(payload)
+/*
+viewErrors keeps the submitted view open and shows the errors under the inputs, errors are keyed by the field ID of the input.
+Mattermost reads the errors of the dialog from the same field.
+*/
+type viewErrors struct {
+	ResponseAction string            `json:"response_action"`
+	Errors         map[string]string `json:"errors"`
+}
+
+func (svc *SlackInteraction) viewErrorsReply(errs map[string]string) interface{} {
+	return viewErrors{ResponseAction: "errors", Errors: errs}
+}
+
+// isAdmin reports whether the user is an admin of the bot, errors are logged and treated as not an admin.
+func (svc *SlackInteraction) isAdmin(userID string) bool {
+	ok, err := svc.repo.IsAdmin(svc.ctx, svc.Name, userID)
 	if err != nil {
-		svc.l.Errorf("parse action failed, err: %+v", err)
-		return svc.noneInteractionReply(payload)
+		svc.l.Errorf("check admin failed, err: %+v", err)
+		return false
 	}
+	return ok
+}
 
-	switch action {
-	case "clear":
-		return svc.clearReply(payload)
-	case "set":
-		return svc.setReply(payload)
+func (svc *SlackInteraction) clearReply(action interactionAction) interface{} {
+	channel, err := svc.getDirectChannel(action.UserID)
+	if err != nil || len(channel) == 0 {
+		svc.l.Errorf("get channel failed, err: %+v", err)
+		return svc.noneInteractionReply(action)
 	}
 
-	svc.l.Warn("mismatch home interactive action")
-	return svc.noneInteractionReply(payload)
+	svc.goJob(_jobHomeClear, clearJob{Channel: channel, User: action.UserID, Option: clearActionOption(action)})
+	return svc.noneInteractionReply(action)
 }
 
-func (svc *SlackInteraction) clearReply(payload map[string]interface{}) interface{} {
-	channel, err := svc.getDirectChannel(payload["user"].(map[string]interface{})["id"].(string), svc.Token)
-	if err != nil || len(channel) == 0 {
-		svc.l.Errorf("get channel failed, err: %+v", err)
-		return svc.noneInteractionReply(payload)
+/*
+clearActionOption gets the scope of the clear button, the days of 'clear.older' are signed in the args.
+*/
+func clearActionOption(action interactionAction) clearOption {
+	switch action.ActionID {
+	case _actionHomeClearResolved:
+		return clearOption{Scope: _clearScopeResolved}
+	case _actionHomeClearOlder:
+		days, err := strconv.Atoi(action.Data.Args["days"])
+		if err != nil || days <= 0 {
+			days = _clearOlderThanDays
+		}
+		return clearOption{Scope: _clearScopeOlder, Before: time.Now().AddDate(0, 0, -days)}
+	default:
+		return clearOption{Scope: _clearScopeAll}
 	}
+}
+
+func (svc *SlackInteraction) setReply(action interactionAction) interface{} {
+	svc.l.Debug("execute set")
+	if !svc.isAdmin(action.UserID) {
+		svc.l.Warnf("reject setting of user %s who isn't an admin", action.UserID)
+		return svc.noneInteractionReply(action)
+	}
+
+	svc.goTracked(func() {
+		err := svc.chat.OpenForm(svc.ctx, action.TriggerID, svc.settingForm(svc.userLocale(action.UserID)))
+		if err != nil {
+			svc.l.Errorf("send set action view failed, err: %+v", err)
+			return
+		}
+	})
+
+	return svc.noneInteractionReply(action)
+}
 
-	notifier := util.NewSlackNotifier(svc.Token)
-	path := fmt.Sprintf("https://slack.com/api/conversations.history?channel=%s", channel)
-	res, _, err := notifier.Send(svc.ctx, http.MethodGet, util.Url(path), &util.GeneralMsg{})
+func (svc *SlackInteraction) settingForm(locale i18n.Locale) platform.Form {
+	rot := svc.defaultRotation()
+	members, err := svc.listMember(rot, false)
 	if err != nil {
-		return err
+		svc.l.Warnf("list members for setting view failed, err: %+v", err)
 	}
 
-	data := map[string]interface{}{}
+	msg, err := svc.getReplyMessage()
+	if err != nil {
+		svc.l.Warnf("get reply message for setting view failed, err: %+v", err)
+	}
 
-	if err := json.Unmarshal(res, &data); err != nil {
-		svc.l.Errorf("parse data failed, err: %+v", err)
-		return svc.noneInteractionReply(payload)
+	return platform.Form{
+		ID:     _callbackSetting,
+		Title:  i18n.T(locale, i18n.MsgSettingTitle),
+		Submit: i18n.T(locale, i18n.MsgSettingSubmit),
+		Cancel: i18n.T(locale, i18n.MsgCancel),
+		State:  svc.actionValue(svc.Name, _viewRoutePrefix+_callbackSetting, 0, nil),
+		Fields: []platform.Field{
+			{ID: _inputUsers, Type: platform.FieldUsers, Group: i18n.T(locale, i18n.MsgSettingRoster), Label: i18n.T(locale, i18n.MsgSettingMembers), Placeholder: i18n.T(locale, i18n.MsgSettingUsersHolder), Values: members},
+			{ID: _inputDate, Type: platform.FieldDate, Label: i18n.T(locale, i18n.MsgSettingStartDate), Placeholder: i18n.T(locale, i18n.MsgSettingDateHolder), Values: []string{svc.getStartDate(rot).Format("2006-01-02")}},
+			{ID: _inputReply, Type: platform.FieldText, Group: i18n.T(locale, i18n.MsgSettingMessage), Label: i18n.T(locale, i18n.MsgSettingReply), Values: []string{msg.MentionMessage}, Multiline: true},
+		},
 	}
+}
 
-	if data["messages"] == nil {
-		svc.l.Warn("empty messages")
-		return svc.noneInteractionReply(payload)
+/*
+settingSubmissionHandler saves the roster and the reply of the default rotation in one transaction,
+changes are audited as changes of the user by the Slack modal.
+
+Only admins can save it, invalid inputs keep the view open with the errors.
+*/
+func (svc *SlackInteraction) settingSubmissionHandler(action interactionAction) interface{} {
+	svc.l.Debug("handle setting view submission")
+	locale := svc.userLocale(action.UserID)
+	if !svc.isAdmin(action.UserID) {
+		svc.l.Warnf("reject setting of user %s who isn't an admin", action.UserID)
+		return svc.viewErrorsReply(map[string]string{_inputUsers: i18n.T(locale, i18n.MsgSettingNotAdmin)})
 	}
 
-	for _, msg := range data["messages"].([]interface{}) {
-		ts := msg.(map[string]interface{})["ts"].(string)
-		_, _, err := notifier.Send(svc.ctx, http.MethodPost, util.Url("https://slack.com/api/chat.delete"), &util.GeneralMsg{
-			Channel: channel,
-			TS:      ts,
-		})
+	rot := svc.defaultRotation()
+	users := action.Inputs[_inputUsers]
+	reply := action.input(_inputReply)
+	errs := map[string]string{}
+	if err := svc.validateTemplate(svc.Name, reply); err != nil {
+		svc.l.Warnf("invalid reply template of setting view, err: %+v", err)
+		errs[_inputReply] = i18n.T(locale, i18n.MsgSettingInvalidReply, err.Error())
+	}
 
+	startDate := time.Time{}
+	if date := action.input(_inputDate); len(date) != 0 {
+		t, err := time.ParseInLocation("2006-01-02", date, time.Local)
 		if err != nil {
-			return err
+			svc.l.Warnf("parse start date of setting view failed, err: %+v", err)
+			errs[_inputDate] = i18n.T(locale, i18n.MsgSettingInvalidDate)
 		}
+		startDate = t
 	}
 
-	return svc.noneInteractionReply(payload)
-}
+	if len(errs) != 0 {
+		return svc.viewErrorsReply(errs)
+	}
 
-func (svc *SlackInteraction) setReply(payload map[string]interface{}) interface{} {
-	svc.l.Debug("execute set")
-	go func() {
-		notifier := util.NewSlackNotifier(svc.Token)
-		_, _, err := notifier.Send(svc.ctx, http.MethodPost, util.PostView,
-			svc.settingView(payload["trigger_id"].(string)),
-		)
+	ctx := model.WithActor(svc.ctx, model.Actor{Name: action.UserID, Source: model.AuditSourceSlackModal})
+	err := svc.repo.Tx(ctx, func(txCtx context.Context) error {
+		if err := svc.keepRosterBaseline(txCtx, rot.key); err != nil {
+			return errors.Wrap(err, "keep roster baseline")
+		}
+
+		current, err := svc.repo.ListMembers(txCtx, rot.key)
 		if err != nil {
-			svc.l.Errorf("send set action view failed, err: %+v", err)
-			return
+			return errors.Wrap(err, "list members")
 		}
-	}()
-
-	return svc.noneInteractionReply(payload)
-}
-
-func (svc *SlackInteraction) settingView(triggerID string) util.SlackViewMsg {
-	return util.SlackViewMsg{
-		TriggerID: triggerID,
-		View: map[string]interface{}{
-			"type": "modal",
-			"submit": util.PlainText{
-				Type:  "plain_text",
-				Text:  "確認",
-				Emoji: true,
-			},
-			"close": util.PlainText{
-				Type:  "plain_text",
-				Text:  "取消",
-				Emoji: true,
-			},
-			"title": util.PlainText{
-				Type:  "plain_text",
-				Text:  "更改機器人設定",
-				Emoji: true,
-			},
-			"blocks": fmt.Sprintf(`[
-				{
-					"type": "header",
-					"text": {
-						"type": "plain_text",
-						"text": "輪值設定",
-						"emoji": true
-					}
-				},
-				{
-					"type": "divider"
-				},
-				{
-					"type": "input",
-					"element": {
-						"type": "multi_users_select",
-						"placeholder": {
-							"type": "plain_text",
-							"text": "Select users",
-							"emoji": true
-						},
-						"action_id": "multi_users_select-action",
-						"initial_users": [
-							%s
-						]
-					},
-					"label": {
-						"type": "plain_text",
-						"text": "輪值人員",
-						"emoji": true
-					}
-				},
-				{
-					"type": "section",
-					"text": {
-						"type": "mrkdwn",
-						"text": "*開始輪值日期*"
-					},
-					"accessory": {
-						"type": "datepicker",
-						"initial_date": "1990-04-28",
-						"placeholder": {
-							"type": "plain_text",
-							"text": "Select a date",
-							"emoji": true
-						},
-						"action_id": "datepicker-action"
-					}
-				},
-				{
-					"type": "header",
-					"text": {
-						"type": "plain_text",
-						"text": "訊息設定",
-						"emoji": true
-					}
-				},
-				{
-					"type": "divider"
-				},
-				{
-					"type": "input",
-					"element": {
-						"type": "plain_text_input",
-						"action_id": "plain_text_input-action",
-						"initial_value": "123123\n123213",
-						"multiline": true
-					},
-					"label": {
-						"type": "plain_text",
-						"text": "%s",
-						"emoji": true
-					}
-				}
-			]`,
-				`"U01QCKG7529"`,
-				"機器人回覆",
-			),
-		},
+		names := make(map[string]string, len(current))
+		for _, m := range current {
+			names[m.UserID] = m.UserName
+		}
+
+		members := make([]model.Member, 0, len(users))
+		for i, user := range users {
+			members = append(members, model.Member{UserID: user, UserName: names[user], Order: i, Service: rot.key})
+		}
+		if err := svc.repo.ResetMembers(txCtx, rot.key, members); err != nil {
+			return errors.Wrap(err, "reset members")
+		}
+
+		if !startDate.IsZero() {
+			if err := svc.repo.UpdateStartDate(txCtx, rot.key, startDate); err != nil {
+				return errors.Wrap(err, "update start date")
+			}
+		}
+
+		if _, err := svc.repo.SnapshotRoster(txCtx, rot.key, time.Now()); err != nil {
+			return errors.Wrap(err, "snapshot roster")
+		}
+
+		msg, err := svc.repo.GetReplyMessage(txCtx, svc.Name)
+		if err != nil {
+			return errors.Wrap(err, "get reply message")
+		}
+		if msg.ID == 0 {
+			msg.Service = svc.Name
+			msg.MentionMultiMember = svc.DefaultMultiMember
+			msg.HomeMentionMessage = svc.DefaultHomeReplyMessage
+		}
+		msg.MentionMessage = reply
+		return errors.Wrap(svc.repo.SetReplyMessage(txCtx, msg), "set reply message")
+	})
+	if err != nil {
+		svc.l.Errorf("save setting view failed, err: %+v", err)
+		return svc.viewErrorsReply(map[string]string{_inputUsers: i18n.T(locale, i18n.MsgSettingSaveFailed)})
 	}
+
+	svc.goTracked(func() {
+		if err := svc.publishHomeView(); err != nil {
+			svc.l.Errorf("publish home view failed, err: %+v", err)
+		}
+	})
+	return svc.closeViewReply()
 }
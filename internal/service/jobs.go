@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	_jobMentionFollowUp = "mention.follow_up"
+	_jobMentionUpdate   = "mention.update"
+	_jobMentionResend   = "mention.resend"
+	_jobHomeClear       = "home.clear"
+	_jobWebhookDeliver  = "webhook.deliver"
+)
+
+/*
+_jobHandlers run the jobs by kind, the payload is the JSON argument of goJob.
+
+Jobs which don't finish before the deadline of shutdown are cancelled and persisted, and run again from the start on the next start,
+so a job may run twice: follow-ups and resends only create outbox entries of idempotency keys, updates rewrite the same content,
+and clearing deletes what's left and posts the summary again.
+*/
+var _jobHandlers = map[string]func(svc *SlackBot, payload []byte) error{
+	/* follow-ups run in the outbox now, jobs persisted before are moved into it */
+	_jobMentionFollowUp: func(svc *SlackBot, payload []byte) error {
+		arg := followUpJob{}
+		if err := json.Unmarshal(payload, &arg); err != nil {
+			return err
+		}
+		entries, err := svc.followUpOutbox(arg.MentionID, arg.Event)
+		if err != nil {
+			return err
+		}
+		if err := svc.repo.CreateOutboxEntries(svc.ctx, entries); err != nil {
+			return errors.Wrap(err, "create outbox entries")
+		}
+		svc.wakeOutbox()
+		return nil
+	},
+	_jobMentionUpdate: func(svc *SlackBot, payload []byte) error {
+		arg := updateJob{}
+		if err := json.Unmarshal(payload, &arg); err != nil {
+			return err
+		}
+		return svc.updateDirectMessages(svc.Name, arg.MentionID, arg.Content, arg.Cancelled)
+	},
+	_jobMentionResend: func(svc *SlackBot, payload []byte) error {
+		arg := resendJob{}
+		if err := json.Unmarshal(payload, &arg); err != nil {
+			return err
+		}
+		entries, err := svc.resendOutbox(arg)
+		if err != nil {
+			return err
+		}
+		if err := svc.repo.CreateOutboxEntries(svc.ctx, entries); err != nil {
+			return errors.Wrap(err, "create outbox entries")
+		}
+		svc.wakeOutbox()
+		return nil
+	},
+	_jobHomeClear: func(svc *SlackBot, payload []byte) error {
+		arg := clearJob{}
+		if err := json.Unmarshal(payload, &arg); err != nil {
+			return err
+		}
+		svc.clearNotifications(arg.Channel, arg.User, arg.Option)
+		return nil
+	},
+	/* deliveries are attempted by the outbox worker now, jobs persisted before only wake it up */
+	_jobWebhookDeliver: func(svc *SlackBot, _ []byte) error {
+		svc.wakeOutbox()
+		return nil
+	},
+}
+
+type followUpJob struct {
+	MentionID uint64      `json:"mention_id"`
+	Event     model.Event `json:"event"`
+}
+
+type updateJob struct {
+	MentionID uint64 `json:"mention_id"`
+	Content   string `json:"content"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+type resendJob struct {
+	MentionID    uint64   `json:"mention_id"`
+	Users        []string `json:"users"`
+	ResendUserID string   `json:"resend_user_id"`
+	ResendID     string   `json:"resend_id"` /* identifies the submission in the idempotency keys of the direct messages */
+}
+
+type clearJob struct {
+	Channel string      `json:"channel"`
+	User    string      `json:"user"`
+	Option  clearOption `json:"option"`
+}
+
+/*
+jobTracker tracks the background work of every bot, Shutdown waits for it and persists the unfinished jobs.
+*/
+type jobTracker struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	closed  bool /* set by Shutdown, no work starts after it */
+	lastID  uint64
+	running map[uint64]model.PendingJob /* persistable jobs which are running */
+	ctx     context.Context             /* done when Shutdown stops waiting, the running jobs are cancelled */
+	cancel  context.CancelFunc
+}
+
+func newJobTracker() *jobTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobTracker{running: map[uint64]model.PendingJob{}, ctx: ctx, cancel: cancel}
+}
+
+/*
+start tracks the work, it returns false when the tracker is closed by Shutdown.
+Adding to the wait group under the lock keeps it from racing with the wait of Shutdown.
+*/
+func (t *jobTracker) start(job *model.PendingJob) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return 0, false
+	}
+
+	t.wg.Add(1)
+	if job == nil {
+		return 0, true
+	}
+	t.lastID++
+	t.running[t.lastID] = *job
+	return t.lastID, true
+}
+
+func (t *jobTracker) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+}
+
+func (t *jobTracker) done(id uint64) {
+	if id != 0 {
+		t.mu.Lock()
+		delete(t.running, id)
+		t.mu.Unlock()
+	}
+	t.wg.Done()
+}
+
+// abort cancels the running jobs and returns them.
+func (t *jobTracker) abort() []model.PendingJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancel()
+	jobs := make([]model.PendingJob, 0, len(t.running))
+	for _, job := range t.running {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+/*
+goTracked runs fn in background, shutdown waits for it but it isn't persisted, e.g. forms whose trigger expires in seconds.
+fn is dropped after shutdown started.
+*/
+func (svc *Service) goTracked(fn func()) {
+	if _, ok := svc.jobs.start(nil); !ok {
+		svc.l.Warn("drop background work after shutdown")
+		return
+	}
+	go func() {
+		defer svc.jobs.done(0)
+		fn()
+	}()
+}
+
+/*
+goJob runs the job of the kind in background, it's cancelled and persisted when it doesn't finish before the deadline of shutdown.
+Jobs after shutdown started are persisted at once.
+*/
+func (svc *SlackBot) goJob(kind string, arg interface{}) {
+	payload, err := json.Marshal(arg)
+	if err != nil {
+		svc.l.Errorf("marshal payload of job %s failed, err: %+v", kind, err)
+		return
+	}
+
+	job := model.PendingJob{
+		TeamID:  model.TeamFrom(svc.ctx),
+		Service: svc.Name,
+		Kind:    kind,
+		Payload: string(payload),
+	}
+	id, ok := svc.jobs.start(&job)
+	if !ok {
+		if err := svc.repo.SavePendingJobs(svc.ctx, []model.PendingJob{job}); err != nil {
+			svc.l.Errorf("save job %s after shutdown failed, err: %+v", kind, err)
+		}
+		return
+	}
+
+	go func() {
+		defer svc.jobs.done(id)
+
+		/* the job keeps the values of the bot context, and is cancelled with the tracker */
+		ctx, cancel := context.WithCancel(svc.ctx)
+		defer cancel()
+		stop := context.AfterFunc(svc.jobs.ctx, cancel)
+		defer stop()
+
+		bot := *svc
+		bot.ctx = ctx
+		bot.runJob(kind, payload)
+	}()
+}
+
+func (svc *SlackBot) runJob(kind string, payload []byte) {
+	handler, ok := _jobHandlers[kind]
+	if !ok {
+		svc.l.Errorf("unknown job %s", kind)
+		return
+	}
+
+	if err := handler(svc, payload); err != nil {
+		svc.l.Errorf("run job %s failed, err: %+v", kind, err)
+	}
+}
+
+/*
+ResumePendingJobs runs the jobs which didn't finish before the last shutdown, in the workspaces of the jobs.
+*/
+func (svc *SlackBot) ResumePendingJobs() error {
+	var jobs []model.PendingJob
+	if err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
+		var err error
+		jobs, err = svc.repo.TakePendingJobs(txCtx, svc.Name)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		bot := svc.workspace(job.TeamID)
+		svc.l.Infof("resume job %s of workspace '%s'", job.Kind, job.TeamID)
+		bot.goJob(job.Kind, json.RawMessage(job.Payload))
+	}
+	return nil
+}
+
+/*
+Shutdown stops accepting background work and waits for the work of every bot until ctx is done,
+the jobs which are still running are cancelled, persisted and resumed by ResumePendingJobs on the next start.
+*/
+func (svc *Service) Shutdown(ctx context.Context) error {
+	svc.jobs.close()
+	done := make(chan struct{})
+	go func() {
+		svc.jobs.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	jobs := svc.jobs.abort()
+	if len(jobs) == 0 {
+		return errors.Wrap(ctx.Err(), "wait background work")
+	}
+
+	/* ctx is done, the jobs are saved without its deadline */
+	if err := svc.repo.SavePendingJobs(context.Background(), jobs); err != nil {
+		return errors.Wrapf(err, "save %d unfinished jobs", len(jobs))
+	}
+	svc.l.Warnf("%d unfinished jobs were saved for the next start", len(jobs))
+	return nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"bitopi/internal/slack"
+	"bitopi/internal/slack/slacktest"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestResendJobRunsOnce(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+
+	mentionTS := "1700000100.000001"
+	serveEvent(t, bot, slacktest.MentionEvent(slacktest.TeamID, _testChannel, _testAsker, "<@"+slacktest.BotUserID+"> help", mentionTS))
+	bot.runOutbox(ctx)
+	record, err := repo.FindMentionRecord(ctx, "maid", _testChannel, mentionTS)
+	if err != nil {
+		t.Fatalf("find mention record: %+v", err)
+	}
+	srv.SetHistory(_testChannel, slack.Message{TS: mentionTS, User: _testAsker, Text: "help"})
+
+	/* the job resumed after shutdown runs again, the duty member is notified once more by the resend */
+	payload, err := json.Marshal(resendJob{MentionID: record.ID, Users: []string{_testDuty}, ResendUserID: _testAsker, ResendID: "r1"})
+	if err != nil {
+		t.Fatalf("marshal resend job: %+v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := _jobHandlers[_jobMentionResend](&bot, payload); err != nil {
+			t.Fatalf("run resend job: %+v", err)
+		}
+	}
+	bot.runOutbox(ctx)
+
+	if n := len(srv.Messages("D" + _testDuty)); n != 2 {
+		t.Fatalf("expect direct message and one resend, got %d messages", n)
+	}
+
+	if err := bot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+}
+
+func TestJobAfterShutdownIsSaved(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+
+	if err := bot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+
+	bot.goJob(_jobMentionResend, resendJob{MentionID: 1, Users: []string{_testDuty}, ResendID: "r1"})
+	jobs, err := repo.TakePendingJobs(ctx, "maid")
+	if err != nil {
+		t.Fatalf("take pending jobs: %+v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Kind != _jobMentionResend {
+		t.Fatalf("expect the job saved instead of run, got %+v", jobs)
+	}
+	if calls := srv.Calls(""); len(calls) != 0 {
+		t.Fatalf("expect nothing called after shutdown, got %d calls", len(calls))
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"bitopi/internal/i18n"
+	"sync"
+	"time"
+)
+
+const (
+	_localeCacheTTL = 24 * time.Hour
+)
+
+type localeEntry struct {
+	locale    i18n.Locale
+	checkedAt time.Time
+}
+
+/*
+localeCache caches locales of users from the platform, it's shared by the copies of the service.
+*/
+type localeCache struct {
+	mu    sync.Mutex
+	users map[string]localeEntry
+}
+
+func newLocaleCache() *localeCache {
+	return &localeCache{
+		users: map[string]localeEntry{},
+	}
+}
+
+/*
+userLocale returns the locale of the user's setting on the platform, or the default locale of the bot
+when the locale isn't supported or getting the user failed.
+*/
+func (svc *Service) userLocale(userID string) i18n.Locale {
+	if svc.locales == nil || svc.chat == nil || len(userID) == 0 {
+		return svc.locale
+	}
+
+	svc.locales.mu.Lock()
+	entry, ok := svc.locales.users[userID]
+	svc.locales.mu.Unlock()
+	if ok && time.Since(entry.checkedAt) < _localeCacheTTL {
+		return entry.locale
+	}
+
+	locale := svc.locale
+	userLocale, err := svc.chat.UserLocale(svc.ctx, userID)
+	if err != nil {
+		svc.l.Warnf("get user locale of %s failed, err: %+v", userID, err)
+		return locale
+	}
+
+	if l, ok := i18n.Parse(userLocale); ok {
+		locale = l
+	}
+
+	svc.locales.mu.Lock()
+	svc.locales.users[userID] = localeEntry{locale: locale, checkedAt: time.Now()}
+	svc.locales.mu.Unlock()
+	return locale
+}
+
+// t translates the message in the default locale of the bot.
+func (svc *Service) t(key string, args ...interface{}) string {
+	return i18n.T(svc.locale, key, args...)
+}
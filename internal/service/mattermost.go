@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bitopi/internal/logging"
+	"bitopi/internal/mattermost"
+	"bitopi/internal/model"
+	"bitopi/internal/platform"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+MattermostHandler receives mentions from the outgoing webhook of Mattermost, the trigger word of the webhook mentions the bot.
+
+The mention goes through the same rotation and mention records as Slack, and nothing is replied by the webhook response.
+*/
+func (svc *SlackBot) MattermostHandler(c echo.Context) error {
+	hook := mattermost.OutgoingWebhook{}
+	if err := c.Bind(&hook); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid webhook request", err)
+	}
+
+	if len(svc.MattermostWebhookToken) == 0 || subtle.ConstantTimeCompare([]byte(hook.Token), []byte(svc.MattermostWebhookToken)) != 1 {
+		svc.l.Warnf("reject mattermost webhook of channel %s", hook.ChannelID)
+		return ErrorResponse(c, http.StatusForbidden, "invalid webhook token")
+	}
+
+	if len(hook.PostID) == 0 || len(hook.UserID) == 0 {
+		return svc.ok(c, mattermost.OutgoingWebhookResponse{})
+	}
+
+	/* the bot is shared by requests, the logger is scoped on a copy */
+	bot := *svc
+	bot.withLogFields(map[string]interface{}{
+		logging.FieldRequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		logging.FieldEventID:   hook.PostID,
+		logging.FieldChannel:   hook.ChannelID,
+		logging.FieldUser:      hook.UserID,
+	})
+	bot.mentionResponse(bot.mattermostEvent(hook))
+	return svc.ok(c, mattermost.OutgoingWebhookResponse{})
+}
+
+/*
+mattermostEvent converts the webhook to a mention event, post IDs take the place of timestamps.
+*/
+func (svc *SlackBot) mattermostEvent(hook mattermost.OutgoingWebhook) model.Event {
+	event := model.Event{
+		Type:      model.EventTypeAppMention,
+		User:      hook.UserID,
+		Text:      hook.Text,
+		TimeStamp: hook.PostID,
+		Channel:   hook.ChannelID,
+	}
+
+	/* webhooks don't carry the root of the thread, follow-ups are matched by the root */
+	resolver, ok := svc.chat.(platform.ThreadResolver)
+	if !ok {
+		return event
+	}
+
+	rootID, err := resolver.RootID(svc.ctx, hook.PostID)
+	if err != nil {
+		svc.l.Warnf("get root of post %s failed, err: %+v", hook.PostID, err)
+		return event
+	}
+
+	if rootID != hook.PostID {
+		event.ThreadTimeStamp = rootID
+	}
+	return event
+}
+
+/*
+MattermostActionHandler receives the buttons of posts and the submissions of dialogs posted to '<name>.mattermost.action_url'.
+
+Mattermost doesn't sign the requests, the actions are verified by their signed values.
+*/
+func (svc *SlackBot) MattermostActionHandler(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "read action request failed", err)
+	}
+
+	submission := mattermost.DialogSubmission{}
+	if err := json.Unmarshal(body, &submission); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid action request", err)
+	}
+
+	/* the bot is shared by requests, the logger is scoped on a copy */
+	interaction := NewInteraction(*svc)
+	interaction.withLogFields(map[string]interface{}{
+		logging.FieldRequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	})
+
+	if submission.Type == mattermost.DialogSubmissionType {
+		if !submission.Cancelled {
+			/* errors of the inputs keep the dialog open */
+			if errs, ok := interaction.route(mattermostSubmission(submission)).(viewErrors); ok {
+				return svc.ok(c, errs)
+			}
+		}
+		return svc.ok(c, struct{}{})
+	}
+
+	req := mattermost.ActionRequest{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid action request", err)
+	}
+	interaction.route(interactionAction{
+		ActionID:  stringField(req.Context, "action_id"),
+		Value:     stringField(req.Context, "value"),
+		TriggerID: req.TriggerID,
+		UserID:    req.UserID,
+		Channel:   req.ChannelID,
+		MessageTS: req.PostID,
+	})
+	return svc.ok(c, mattermost.ActionResponse{})
+}
+
+/*
+mattermostSubmission converts the submitted dialog to the action of the form, which is routed like view submissions.
+*/
+func mattermostSubmission(submission mattermost.DialogSubmission) interactionAction {
+	inputs := map[string][]string{}
+	for name, v := range submission.Submission {
+		if v == nil {
+			continue
+		}
+		if s := fmt.Sprint(v); len(s) != 0 {
+			inputs[name] = []string{s}
+		}
+	}
+
+	return interactionAction{
+		ActionID: _viewRoutePrefix + submission.CallbackID,
+		Value:    submission.State,
+		UserID:   submission.UserID,
+		Channel:  submission.ChannelID,
+		Inputs:   inputs,
+	}
+}
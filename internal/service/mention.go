@@ -1,28 +1,50 @@
 package service
 
 import (
+	"bitopi/internal/actioncodec"
+	"bitopi/internal/i18n"
+	"bitopi/internal/logging"
+	"bitopi/internal/mattermost"
+	"bitopi/internal/metrics"
 	"bitopi/internal/model"
-	"bitopi/internal/util"
+	"bitopi/internal/platform"
+	"bitopi/internal/slack"
 	"context"
 	"encoding/json"
-	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
-	"github.com/yanun0323/pkg/logs"
+	"github.com/spf13/viper"
 )
 
 const (
 	_eventVerification = "url_verification"
 	_eventCallback     = "event_callback"
+
+	_defaultActionTTL = 30 * 24 * time.Hour
+)
+
+type mentionState int
+
+const (
+	_mentionNew mentionState = iota
+	_mentionFollowUp
+	_mentionDuplicated
+)
+
+var (
+	/* effective time of the first roster version, which covers all the history before any change */
+	_rosterEpoch = time.Unix(0, 0)
 )
 
 type SlackBot struct {
 	Service
 	SlackBotOption
+	rotations  []*rotation
+	workspaces *workspaceCache
+	outbox     *outbox
 }
 
 type SlackBotOption struct {
@@ -35,14 +57,128 @@ type SlackBotOption struct {
 	DefaultReplyMessage       string
 	DefaultHomeReplyMessage   string
 	DefaultMultiMember        bool
+	SlackBaseURL              string           /* optional, 'slack.base_url' or Slack by default */
+	Rotations                 []RotationOption /* optional, one rotation of the bot defaults when empty */
+	DefaultRotation           string           /* rotation of unmatched mentions, all rotations when empty */
+	DefaultLocale             string           /* optional, '<name>.locale' or zh-TW by default */
+	ClientID                  string           /* optional, '<name>.oauth.client_id', OAuth installation is disabled without it */
+	ClientSecret              string           /* optional, '<name>.oauth.client_secret' */
+	RedirectURL               string           /* optional, '<name>.oauth.redirect_url' or the redirect url of the Slack app by default */
+	OAuthScope                string           /* optional, '<name>.oauth.scope' or the scopes the bot needs by default */
+	SlackAuthorizeURL         string           /* optional, 'slack.authorize_url' or Slack by default */
+	AppToken                  string           /* optional, '<name>.app_token', receives events over Socket Mode when it's set */
+	Platform                  string           /* optional, '<name>.platform', platform.Slack or platform.Mattermost, Slack by default */
+	MattermostURL             string           /* optional, '<name>.mattermost.url', site url of the Mattermost server */
+	MattermostToken           string           /* optional, '<name>.mattermost.token', access token of the bot account */
+	MattermostWebhookToken    string           /* optional, '<name>.mattermost.webhook_token', verifies requests of the outgoing webhook */
+	MattermostActionURL       string           /* optional, '<name>.mattermost.action_url', public url of '/<name>/mattermost/action', buttons and forms are dropped without it */
 }
 
-func NewBot(svc Service, opt SlackBotOption) SlackBot {
-	svc.l = logs.New(logs.LevelError)
+func NewBot(svc Service, opt SlackBotOption) (SlackBot, error) {
+	rotations, err := newRotations(opt)
+	if err != nil {
+		return SlackBot{}, err
+	}
+	svc.botRotations.set(opt.Name, rotations)
+
+	svc.l = logging.New(opt.Name)
+	baseURL := opt.SlackBaseURL
+	if len(baseURL) == 0 {
+		baseURL = viper.GetString("slack.base_url")
+	}
+	svc.client = newSlackClient(opt.Token, baseURL)
+	opt = platformOption(opt)
+	svc.chat = newPlatform(svc.client, opt)
+	svc.auth = newAuthCache()
+	svc.locales = newLocaleCache()
+
+	locale := opt.DefaultLocale
+	if len(locale) == 0 {
+		locale = viper.GetString(opt.Name + ".locale")
+	}
+	if l, ok := i18n.Parse(locale); ok {
+		svc.locale = l
+	}
+
+	opt = oauthOption(opt)
+	if len(opt.AppToken) == 0 {
+		opt.AppToken = viper.GetString(opt.Name + ".app_token")
+	}
+
+	/*
+		action payloads are signed by the bot token, the client secret or the Mattermost token when 'action.secret' isn't set,
+		buttons sent before are rejected once the token rotates.
+	*/
+	secret := viper.GetString("action.secret")
+	if len(secret) == 0 {
+		svc.l.Warnf("'action.secret' isn't set, actions of bot '%s' are signed by its token", opt.Name)
+	}
+	for _, fallback := range []string{opt.Token, opt.ClientSecret, opt.MattermostToken} {
+		if len(secret) == 0 {
+			secret = fallback
+		}
+	}
+	ttl := viper.GetDuration("action.ttl")
+	if ttl == 0 {
+		ttl = _defaultActionTTL
+	}
+	/* notifications can always be deleted */
+	svc.codec = actioncodec.New(secret, ttl).WithTTL(_actionDelete, 0).WithTTL(_actionOAuthState, _oauthStateTTL)
+	logging.Redact(opt.Token, opt.AppToken, opt.ClientSecret, opt.MattermostToken, opt.MattermostWebhookToken, secret)
 	return SlackBot{
 		Service:        svc,
 		SlackBotOption: opt,
+		rotations:      rotations,
+		workspaces:     newWorkspaceCache(),
+		outbox:         newOutbox(),
+	}, nil
+}
+
+// oauthOption fills the empty OAuth options with the config.
+func oauthOption(opt SlackBotOption) SlackBotOption {
+	fill := func(field *string, value string) {
+		if len(*field) == 0 {
+			*field = value
+		}
+	}
+	fill(&opt.ClientID, viper.GetString(opt.Name+".oauth.client_id"))
+	fill(&opt.ClientSecret, viper.GetString(opt.Name+".oauth.client_secret"))
+	fill(&opt.RedirectURL, viper.GetString(opt.Name+".oauth.redirect_url"))
+	fill(&opt.OAuthScope, viper.GetString(opt.Name+".oauth.scope"))
+	fill(&opt.OAuthScope, _defaultOAuthScope)
+	fill(&opt.SlackAuthorizeURL, viper.GetString("slack.authorize_url"))
+	fill(&opt.SlackAuthorizeURL, slack.DefaultAuthorizeURL)
+	return opt
+}
+
+// platformOption fills the empty platform options with the config.
+func platformOption(opt SlackBotOption) SlackBotOption {
+	fill := func(field *string, value string) {
+		if len(*field) == 0 {
+			*field = value
+		}
+	}
+	fill(&opt.Platform, viper.GetString(opt.Name+".platform"))
+	fill(&opt.Platform, platform.Slack)
+	fill(&opt.MattermostURL, viper.GetString(opt.Name+".mattermost.url"))
+	fill(&opt.MattermostToken, viper.GetString(opt.Name+".mattermost.token"))
+	fill(&opt.MattermostWebhookToken, viper.GetString(opt.Name+".mattermost.webhook_token"))
+	fill(&opt.MattermostActionURL, viper.GetString(opt.Name+".mattermost.action_url"))
+	return opt
+}
+
+// newSlackClient creates the client of the token, every call of it is recorded in metrics.
+func newSlackClient(token, baseURL string) *slack.Client {
+	return slack.New(token, slack.WithBaseURL(baseURL), slack.WithObserver(metrics.ObserveSlackCall))
+}
+
+func newPlatform(client *slack.Client, opt SlackBotOption) platform.Platform {
+	if opt.Platform == platform.Mattermost {
+		return platform.NewMattermost(mattermost.New(opt.MattermostURL, opt.MattermostToken), platform.MattermostOption{
+			ActionURL: opt.MattermostActionURL,
+		})
 	}
+	return platform.NewSlack(client)
 }
 
 func (svc *SlackBot) Handler(c echo.Context) error {
@@ -90,115 +226,259 @@ func (svc *SlackBot) eventCallbackResponse(c echo.Context) interface{} {
 		svc.l.Errorf("decode json failed, err: %+v", err)
 		return nil
 	}
-	svc.l.Debugf("slack event api: %+v", slackEventApi)
 
-	id, exist, err := svc.recordMention(slackEventApi)
-	if err != nil {
-		svc.l.Errorf("record mention failed, err: %+v", err)
+	return svc.eventResponse(slackEventApi, c.Response().Header().Get(echo.HeaderXRequestID))
+}
+
+/*
+eventResponse routes the event to the bot of its workspace, it's shared by HTTP and Socket Mode.
+The logger of the bot is scoped to the event, requestID is the request or the envelope of the event.
+*/
+func (svc *SlackBot) eventResponse(slackEventApi model.SlackEventAPI, requestID string) interface{} {
+	bot, ok := svc.requestWorkspace(slackEventApi.TeamID)
+	bot.withLogFields(map[string]interface{}{
+		logging.FieldRequestID: requestID,
+		logging.FieldTeam:      slackEventApi.TeamID,
+		logging.FieldEventID:   slackEventApi.EventId,
+		logging.FieldChannel:   slackEventApi.Event.Channel,
+		logging.FieldUser:      slackEventApi.Event.User,
+	})
+	if !ok {
+		bot.l.Warnf("reject event of workspace %s which didn't install the bot", slackEventApi.TeamID)
 		return nil
 	}
 
-	if exist {
-		svc.l.Warnf("message was already replied, user: %s, channel: %s", slackEventApi.Event.User, slackEventApi.Event.Channel)
+	bot.l.Debugf("receive event, type: %s, subtype: %s", slackEventApi.Event.Type, slackEventApi.Event.SubType)
+	return bot.routeEvent(slackEventApi)
+}
+
+/*
+mentionResponse replies the mention and notifies the duty members, the event is converted from any platform.
+*/
+func (svc *SlackBot) mentionResponse(event model.Event) interface{} {
+	metrics.Mention(svc.Name, metrics.MentionReceived)
+	channelCfg, answer := svc.channelConfig(event.Channel)
+	if !answer {
+		svc.l.Debugf("ignore mention in channel %s", event.Channel)
 		return nil
 	}
 
-	startDate := svc.getStartDate()
-	dutyDuration := svc.getDutyDuration()
-	dutyMemberCountPerTime := svc.getDutyMemberCountPerTime()
-	dutyMember, leftMembers, err := svc.getDutyMember(true, startDate, dutyMemberCountPerTime, dutyDuration)
+	duties, err := svc.rotationDuties(svc.matchRotations(event), time.Now())
 	if err != nil {
-		svc.l.Errorf("get duty member failed, err: %+v", err)
+		svc.l.Errorf("get rotation duties failed, err: %+v", err)
+		metrics.Mention(svc.Name, metrics.MentionFailed)
 		return nil
 	}
+	dutyMemberIDs := matchedDutyMembers(duties)
 
 	rMsg, err := svc.getReplyMessage()
 	if err != nil {
+		metrics.Mention(svc.Name, metrics.MentionFailed)
 		return err
 	}
 
-	notifier := util.NewSlackNotifier(svc.Token)
-	res, err := svc.sendMentionReply(notifier, slackEventApi, dutyMember, leftMembers, rMsg)
-	if err != nil {
-		svc.l.Errorf("send mention reply failed, err: %+v", err)
-	}
-	resMap, err := util.ParseByte2Map(res)
-	if err != nil {
-		svc.l.Errorf("parse slack response failed, err: %+v", err)
-		return err
+	if len(channelCfg.ReplyMessage) != 0 {
+		rMsg.MentionMessage = channelCfg.ReplyMessage
 	}
-	resChannel, ok := resMap["channel"].(string)
-	if !ok {
-		err := errors.New("get channel from slack response failed")
-		svc.l.Error(err)
-		return err
+
+	/* silent channels have no reply */
+	reply := ""
+	if !channelCfg.Silent {
+		reply = svc.renderMessage(rMsg.MentionMessage, rMsg.MentionMultiMember, duties, &event)
 	}
-	resTS, ok := resMap["ts"].(string)
-	if !ok {
-		err := errors.New("get ts from slack response failed")
-		svc.l.Error(err)
-		return err
+
+	receiveMembers := appendUnique([]string{}, dutyMemberIDs...)
+	if rMsg.MentionMultiMember && len(duties) == 1 {
+		receiveMembers = appendUnique(receiveMembers, duties[0].Left...)
 	}
+	receiveMembers = appendUnique(receiveMembers, channelCfg.NotifyList()...)
 
-	go func() {
-		receiveMembers := dutyMember
-		if rMsg.MentionMultiMember {
-			receiveMembers = append(receiveMembers, leftMembers...)
-		}
-		if err := svc.sendReplyDirectMessage(notifier, model.SlackDirectMsgOption{
-			IsUser:          true,
-			MentionRecordID: fmt.Sprintf("%d", id),
-			ServiceName:     svc.Name,
-			User:            slackEventApi.Event.User,
-			EventContent:    slackEventApi.Event.Text,
-			Members:         receiveMembers,
-			LinkChannel:     resChannel,
-			LinkTimestamp:   resTS,
-		}); err != nil {
-			svc.l.Errorf("send direct message failed, err: %+v", err)
-			return
+	id, state, err := svc.recordMention(event, dutyMemberIDs, func(id uint64, state mentionState) ([]model.OutboxEntry, error) {
+		if state == _mentionFollowUp {
+			return svc.followUpOutbox(id, event)
 		}
+		return svc.mentionOutbox(id, event, reply, receiveMembers)
+	})
+	if err != nil {
+		svc.l.Errorf("record mention failed, err: %+v", err)
+		metrics.Mention(svc.Name, metrics.MentionFailed)
+		return nil
+	}
 
-		if err := svc.publishHomeView(notifier); err != nil {
-			svc.l.Errorf("publish home view failed, err: %+v", err)
-		}
-	}()
+	svc.withLogFields(map[string]interface{}{logging.FieldMentionID: id})
+
+	switch state {
+	case _mentionDuplicated:
+		metrics.Mention(svc.Name, metrics.MentionDeduplicated)
+		svc.l.Warnf("message was already replied, user: %s, channel: %s", event.User, event.Channel)
+		return nil
+	case _mentionFollowUp:
+		svc.wakeOutbox()
+		return nil
+	}
+
+	svc.wakeOutbox()
+	if channelCfg.Silent {
+		metrics.Mention(svc.Name, metrics.MentionReplied)
+	}
+
+	svc.goTracked(func() {
+		svc.emit(svc.Name, model.WebhookEventMentionOpened, model.MentionWebhookData{
+			MentionID:   id,
+			Channel:     event.Channel,
+			Timestamp:   event.ThreadRootTimeStamp(),
+			User:        event.User,
+			Text:        event.Text,
+			DutyMembers: dutyMemberIDs,
+			Status:      model.MentionStatusOpen,
+		})
+	})
 
 	return nil
 }
 
-func (svc *SlackBot) recordMention(slackEventApi model.SlackEventAPI) (uint64, bool, error) {
+/*
+recordMention attaches the mention to the record of its thread.
+
+The mention is a follow-up when the record of the thread exists, or a duplicate when the event was already handled.
+The outbox entries of new mentions and follow-ups are created in the same transaction as the record.
+*/
+func (svc *SlackBot) recordMention(event model.Event, dutyMemberIDs []string, outbox func(id uint64, state mentionState) ([]model.OutboxEntry, error)) (uint64, mentionState, error) {
+	rootTS := event.ThreadRootTimeStamp()
+
 	var (
 		id    uint64
-		found bool
-		err   error
+		state mentionState
 	)
-	err = svc.repo.Tx(svc.ctx, func(ctx context.Context) error {
-		id, found, err = svc.repo.FindOrCreateMentionRecord(svc.ctx, svc.Name, slackEventApi.Event.Channel, slackEventApi.Event.EventTimeStamp)
-		return err
+	err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
+		recordID, found, err := svc.repo.FindOrCreateMentionRecord(txCtx, svc.Name, event.Channel, rootTS, dutyMemberIDs)
+		if err != nil {
+			return errors.Wrap(err, "find or create mention record")
+		}
+		id = recordID
+
+		ts := event.TimeStamp
+		if len(ts) == 0 {
+			ts = event.EventTimeStamp
+		}
+
+		/* records before tracking events have no event of the root message */
+		if found && ts == rootTS {
+			state = _mentionDuplicated
+			return nil
+		}
+
+		duplicated, err := svc.repo.AddMentionEvent(txCtx, model.MentionEvent{
+			MentionRecordID: recordID,
+			Timestamp:       ts,
+			User:            event.User,
+			Text:            event.Text,
+		})
+		if err != nil {
+			return errors.Wrap(err, "add mention event")
+		}
+
+		switch {
+		case duplicated:
+			state = _mentionDuplicated
+			return nil
+		case found:
+			state = _mentionFollowUp
+		default:
+			state = _mentionNew
+		}
+
+		entries, err := outbox(recordID, state)
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(svc.repo.CreateOutboxEntries(txCtx, entries), "create outbox entries")
 	})
 	if err != nil {
-		return 0, false, err
+		return 0, _mentionNew, err
+	}
+
+	return id, state, nil
+}
+
+/*
+getRoster returns the roster version of the rotation in effect at the moment with the defaults of the rotation applied.
+
+The current roster is stored as the first version when there's no version yet.
+*/
+func (svc *SlackBot) getRoster(rot *rotation, at time.Time) (model.RosterVersion, error) {
+	roster, err := svc.repo.GetRosterVersion(svc.ctx, rot.key, at)
+	if err != nil {
+		return model.RosterVersion{}, err
+	}
+
+	if roster.ID == 0 {
+		roster, err = svc.initRoster(rot)
+		if err != nil {
+			return model.RosterVersion{}, err
+		}
+	}
+
+	if roster.Duration == 0 {
+		roster.Duration = svc.getDutyDuration(rot)
 	}
 
-	return id, found, err
+	if roster.MemberCountPerTime == 0 {
+		roster.MemberCountPerTime = svc.getDutyMemberCountPerTime(rot)
+	}
+
+	return roster, nil
 }
 
-func (svc *SlackBot) getDutyMember(mention bool, startDate time.Time, dutyCount int, dutyDuration time.Duration) ([]string, []string, error) {
-	now := time.Now()
+func (svc *SlackBot) initRoster(rot *rotation) (model.RosterVersion, error) {
+	_ = svc.getStartDate(rot)
+	if _, err := svc.listMember(rot, false); err != nil {
+		return model.RosterVersion{}, err
+	}
+
+	var roster model.RosterVersion
+	err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
+		var err error
+		roster, err = svc.repo.SnapshotRoster(txCtx, rot.key, _rosterEpoch)
+		return err
+	})
+	if err != nil {
+		return model.RosterVersion{}, err
+	}
+	return roster, nil
+}
+
+/*
+getDutyMember returns user IDs of the duty members and the others of the roster at the moment.
+*/
+func (svc *SlackBot) getDutyMember(roster model.RosterVersion, at time.Time) ([]string, []string, error) {
+	startDate := roster.StartDate
+	dutyCount := roster.MemberCountPerTime
 	svc.l.Debug("time start: ", startDate.Format("20060102 15:04:05 MST"))
-	svc.l.Debug("time now: ", now.Format("20060102 15:04:05 MST"))
+	svc.l.Debug("time at: ", at.Format("20060102 15:04:05 MST"))
 
-	interval := now.Sub(startDate)
+	interval := at.Sub(startDate)
 	weekFromStartDate := (((interval.Milliseconds() / 1000 / 60) / 60) / 24) / 7
 	svc.l.Debug("week from start date: ", weekFromStartDate)
 
-	member, err := svc.listMember(mention)
+	members, err := roster.MemberList()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	dutyWeek := int(dutyDuration / (time.Hour * 24 * 7))
+	if len(members) == 0 {
+		return nil, nil, errors.Errorf("empty roster of version %d", roster.ID)
+	}
+	member := svc.transferMembersToString(members, false)
+
+	if dutyCount > len(member) {
+		dutyCount = len(member)
+	}
+
+	dutyWeek := int(roster.Duration / (time.Hour * 24 * 7))
+	if dutyWeek < 1 {
+		dutyWeek = 1
+	}
 	passedRound := int(int(weekFromStartDate) / dutyWeek)
 	index := passedRound * dutyCount % len(member)
 
@@ -248,52 +528,60 @@ func (svc *SlackBot) getReplyMessage() (model.BotMessage, error) {
 	return msg, nil
 }
 
-func (svc *SlackBot) getStartDate() time.Time {
-	startDate, err := svc.repo.GetStartDate(svc.ctx, svc.Name)
+func (svc *SlackBot) getStartDate(rot *rotation) time.Time {
+	startDate, err := svc.repo.GetStartDate(svc.ctx, rot.key)
 	if err != nil {
 		svc.l.WithError(err).Warn("get start date")
 		svc.l.Warn("reset start date to database")
-		_ = svc.repo.Tx(svc.ctx, func(ctx context.Context) error {
-			return svc.repo.UpdateStartDate(svc.ctx, svc.Name, svc.DefaultStartDate)
+		_ = svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
+			return svc.repo.UpdateStartDate(txCtx, rot.key, rot.DefaultStartDate)
 		})
-		startDate = svc.DefaultStartDate
+		startDate = rot.DefaultStartDate
 	}
 	return startDate
 }
 
-func (svc *SlackBot) getDutyDuration() time.Duration {
-	dutyDuration, err := svc.repo.GetDutyDuration(svc.ctx, svc.Name)
+func (svc *SlackBot) getDutyDuration(rot *rotation) time.Duration {
+	dutyDuration, err := svc.repo.GetDutyDuration(svc.ctx, rot.key)
 	if err != nil || dutyDuration == 0 {
 		svc.l.Warnf("get duty duration, err: %+v", err)
-		return svc.DefaultDutyDuration
+		return rot.DefaultDutyDuration
 	}
 	return dutyDuration
 }
 
-func (svc *SlackBot) getDutyMemberCountPerTime() int {
-	dutyMemberCountPerTime, err := svc.repo.GetDutyMemberCountPerTime(svc.ctx, svc.Name)
+func (svc *SlackBot) getDutyMemberCountPerTime(rot *rotation) int {
+	dutyMemberCountPerTime, err := svc.repo.GetDutyMemberCountPerTime(svc.ctx, rot.key)
 	if err != nil || dutyMemberCountPerTime == 0 {
 		svc.l.Warnf("get duty member count per time, err: %+v", err)
-		return svc.DefaultMemberCountPerTime
+		return rot.DefaultMemberCountPerTime
 	}
 	return dutyMemberCountPerTime
 }
 
-func (svc *SlackBot) listMember(mention bool) ([]string, error) {
-	members, err := svc.repo.ListMembers(svc.ctx, svc.Name)
+func (svc *SlackBot) listMember(rot *rotation, mention bool) ([]string, error) {
+	members, err := svc.repo.ListMembers(svc.ctx, rot.key)
 	if err == nil && len(members) != 0 {
 		return svc.transferMembersToString(members, mention), nil
 	}
 	svc.l.WithError(err).Warn("list member")
-	svc.l.Warnf("reset member to database '%s'", svc.Name)
+	svc.l.Warnf("reset member to database '%s'", rot.key)
 
-	if err := svc.repo.Tx(svc.ctx, func(ctx context.Context) error {
-		return svc.repo.ResetMembers(svc.ctx, svc.Name, svc.DefaultMemberList)
+	if err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
+		return svc.repo.ResetMembers(txCtx, rot.key, rot.DefaultMemberList)
 	}); err != nil {
 		return nil, err
 	}
 
-	return svc.transferMembersToString(svc.DefaultMemberList, mention), nil
+	return svc.transferMembersToString(rot.DefaultMemberList, mention), nil
+}
+
+func userTags(userIDs []string) []string {
+	tags := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		tags = append(tags, "<@"+id+">")
+	}
+	return tags
 }
 
 func (svc *SlackBot) transferMembersToString(members []model.Member, mention bool) []string {
@@ -307,22 +595,3 @@ func (svc *SlackBot) transferMembersToString(members []model.Member, mention boo
 	}
 	return s
 }
-
-func (svc *SlackBot) sendMentionReply(notifier util.SlackNotifier, slackEventApi model.SlackEventAPI, dutyMember []string, leftMembers []string, rMsg model.BotMessage) ([]byte, error) {
-	replyText := ""
-	if rMsg.MentionMultiMember {
-		replyText = fmt.Sprintf(rMsg.MentionMessage, strings.Join(dutyMember, " "), strings.Join(leftMembers, " "))
-	} else {
-		replyText = fmt.Sprintf(rMsg.MentionMessage, strings.Join(dutyMember, " "))
-	}
-
-	res, err := svc.postMessage(notifier, util.SlackReplyMsg{
-		Text:      replyText,
-		Channel:   slackEventApi.Event.Channel,
-		TimeStamp: slackEventApi.Event.TimeStamp,
-	})
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
-}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/repository/memory"
+	"bitopi/internal/slack"
+	"bitopi/internal/slack/slacktest"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	_testChannel = "CHELP"
+	_testAsker   = "UASKER"
+	_testDuty    = "UDUTY"
+)
+
+func newTestBot(t *testing.T, srv *slacktest.Server) (SlackBot, memory.MemoryDao) {
+	t.Helper()
+
+	repo := memory.New()
+	bot, err := NewBot(NewWithRepo(context.Background(), repo), SlackBotOption{
+		Name:                      "maid",
+		Token:                     "xoxb-test",
+		SlackBaseURL:              srv.BaseURL(),
+		DefaultStartDate:          time.Now().AddDate(0, 0, -1),
+		DefaultDutyDuration:       _week,
+		DefaultMemberCountPerTime: 1,
+		DefaultMemberList:         []model.Member{{UserID: _testDuty, UserName: "duty", Order: 1}},
+		DefaultReplyMessage:       "on duty",
+	})
+	if err != nil {
+		t.Fatalf("new bot: %+v", err)
+	}
+	return bot, repo
+}
+
+func serveEvent(t *testing.T, bot SlackBot, body []byte) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/maid", strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	rec := httptest.NewRecorder()
+	if err := bot.Handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatalf("handle event: %+v", err)
+	}
+}
+
+func serveInteraction(t *testing.T, bot SlackBot, payload map[string]interface{}) {
+	t.Helper()
+
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %+v", err)
+	}
+	form := url.Values{"payload": {string(buf)}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/maid/interaction", strings.NewReader(form))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	interaction := NewInteraction(bot)
+	if err := interaction.Handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatalf("handle interaction: %+v", err)
+	}
+}
+
+// buttonValue finds the value of the button in the blocks of the call.
+func buttonValue(call slacktest.Call, actionID string) string {
+	blocks, _ := call.Params["blocks"].([]interface{})
+	for _, b := range blocks {
+		block, _ := b.(map[string]interface{})
+		elements, _ := block["elements"].([]interface{})
+		for _, e := range elements {
+			element, _ := e.(map[string]interface{})
+			if stringField(element, "action_id") == actionID {
+				return stringField(element, "value")
+			}
+		}
+	}
+	return ""
+}
+
+func TestMentionFlow(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+
+	/* mention */
+	mentionTS := "1700000100.000001"
+	serveEvent(t, bot, slacktest.MentionEvent(slacktest.TeamID, _testChannel, _testAsker, "<@"+slacktest.BotUserID+"> help", mentionTS))
+
+	record, err := repo.FindMentionRecord(ctx, "maid", _testChannel, mentionTS)
+	if err != nil {
+		t.Fatalf("find mention record: %+v", err)
+	}
+	if record.Status != model.MentionStatusOpen || record.DutyMembers != _testDuty {
+		t.Fatalf("unexpected mention record: %+v", record)
+	}
+
+	/* thread reply and direct message are sent by the outbox */
+	bot.runOutbox(ctx)
+
+	posts := srv.Calls(slack.MethodChatPostMessage)
+	if len(posts) != 2 {
+		t.Fatalf("expect reply and direct message, got %d posts", len(posts))
+	}
+	reply, dm := posts[0], posts[1]
+	if reply.String("channel") != _testChannel || reply.String("thread_ts") != mentionTS || reply.String("text") != "on duty" {
+		t.Fatalf("unexpected reply: %+v", reply.Params)
+	}
+	if dm.String("channel") != "D"+_testDuty {
+		t.Fatalf("unexpected direct message channel: %s", dm.String("channel"))
+	}
+	replyTS := srv.Messages(_testChannel)[0].TS
+	if !strings.Contains(dm.String("text"), "/p"+strings.ReplaceAll(replyTS, ".", "")) {
+		t.Fatalf("direct message doesn't link to the reply: %s", dm.String("text"))
+	}
+
+	dms, err := repo.ListMentionDirectMessages(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("list mention direct messages: %+v", err)
+	}
+	dmTS := srv.Messages("D" + _testDuty)[0].TS
+	if len(dms) != 1 || dms[0].UserID != _testDuty || dms[0].Channel != "D"+_testDuty || dms[0].Timestamp != dmTS {
+		t.Fatalf("unexpected direct messages: %+v", dms)
+	}
+
+	/* the duty member presses 'delete and reply' on the direct message */
+	value := buttonValue(dm, _actionDeleteAndReply)
+	if len(value) == 0 {
+		t.Fatalf("no %s button in the direct message: %s", _actionDeleteAndReply, dm.Body)
+	}
+	serveInteraction(t, bot, map[string]interface{}{
+		"type":      "block_actions",
+		"team":      map[string]interface{}{"id": slacktest.TeamID},
+		"user":      map[string]interface{}{"id": _testDuty},
+		"container": map[string]interface{}{"channel_id": "D" + _testDuty, "message_ts": dmTS},
+		"actions":   []interface{}{map[string]interface{}{"action_id": _actionDeleteAndReply, "value": value}},
+	})
+
+	posts = srv.Calls(slack.MethodChatPostMessage)
+	if len(posts) != 3 || posts[2].String("channel") != _testChannel || posts[2].String("thread_ts") != mentionTS {
+		t.Fatalf("expect done reply in the thread, got %d posts", len(posts))
+	}
+
+	deletes := srv.Calls(slack.MethodChatDelete)
+	if len(deletes) != 1 || deletes[0].String("channel") != "D"+_testDuty || deletes[0].String("ts") != dmTS {
+		t.Fatalf("expect the direct message deleted, got %+v", deletes)
+	}
+
+	record, err = repo.GetMentionRecord(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get mention record: %+v", err)
+	}
+	if record.Status != model.MentionStatusResolved {
+		t.Fatalf("expect resolved mention, got %s", record.Status)
+	}
+
+	if err := bot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+}
+
+func TestLegacyDirectMessageAction(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+
+	mentionTS := "1700000100.000001"
+	serveEvent(t, bot, slacktest.MentionEvent(slacktest.TeamID, _testChannel, _testAsker, "<@"+slacktest.BotUserID+"> help", mentionTS))
+	record, err := repo.FindMentionRecord(ctx, "maid", _testChannel, mentionTS)
+	if err != nil {
+		t.Fatalf("find mention record: %+v", err)
+	}
+
+	if err := repo.CreateMentionDirectMessage(ctx, model.MentionDirectMessage{MentionRecordID: record.ID, UserID: _testDuty, Channel: "D" + _testDuty, Timestamp: "1600000000.000001"}); err != nil {
+		t.Fatalf("create mention direct message: %+v", err)
+	}
+
+	/* buttons of direct messages sent before Block Kit, they're only accepted on the recorded direct message */
+	legacyAction := func(user, channel, ts string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "interactive_message",
+			"callback_id": "maid" + _legacyCallbackSuffix,
+			"team":        map[string]interface{}{"id": slacktest.TeamID},
+			"user":        map[string]interface{}{"id": user},
+			"channel":     map[string]interface{}{"id": channel},
+			"message_ts":  ts,
+			"actions":     []interface{}{map[string]interface{}{"name": "direct_msg_action", "value": strconv.FormatUint(record.ID, 10) + ",delete"}},
+		}
+	}
+	serveInteraction(t, bot, legacyAction(_testAsker, "D"+_testAsker, "1600000000.000002"))
+	if deletes := srv.Calls(slack.MethodChatDelete); len(deletes) != 0 {
+		t.Fatalf("expect the hand-made legacy action rejected, got %+v", deletes)
+	}
+
+	serveInteraction(t, bot, legacyAction(_testDuty, "D"+_testDuty, "1600000000.000001"))
+
+	deletes := srv.Calls(slack.MethodChatDelete)
+	if len(deletes) != 1 || deletes[0].String("channel") != "D"+_testDuty || deletes[0].String("ts") != "1600000000.000001" {
+		t.Fatalf("expect the legacy direct message deleted, got %+v", deletes)
+	}
+
+	if err := bot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+}
+
+func TestFollowUpWaitsForDirectMessages(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, _ := newTestBot(t, srv)
+	ctx := context.Background()
+
+	/* the follow-up arrives before the outbox sends the direct message */
+	mentionTS := "1700000100.000001"
+	serveEvent(t, bot, slacktest.MentionEvent(slacktest.TeamID, _testChannel, _testAsker, "<@"+slacktest.BotUserID+"> help", mentionTS))
+	serveEvent(t, bot, slacktest.ThreadMentionEvent(slacktest.TeamID, _testChannel, _testAsker, "<@"+slacktest.BotUserID+"> any news?", "1700000100.000002", mentionTS))
+	if posts := srv.Calls(slack.MethodChatPostMessage); len(posts) != 0 {
+		t.Fatalf("expect nothing posted before the outbox runs, got %d posts", len(posts))
+	}
+
+	bot.runOutbox(ctx)
+
+	dms := srv.Messages("D" + _testDuty)
+	if len(dms) != 2 {
+		t.Fatalf("expect direct message and follow-up, got %d messages", len(dms))
+	}
+	if dms[1].ThreadTS != dms[0].TS {
+		t.Fatalf("expect follow-up in the thread of the direct message, got thread %s", dms[1].ThreadTS)
+	}
+
+	/* the follow-up is done, running the outbox again posts nothing */
+	bot.runOutbox(ctx)
+	if n := len(srv.Messages("D" + _testDuty)); n != 2 {
+		t.Fatalf("expect follow-up sent once, got %d messages", n)
+	}
+
+	if err := bot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+}
+
+func TestDirectMessageRetryFindsSent(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+
+	/* the last attempt sent the direct message but failed to record it */
+	key := model.OutboxKey("maid", 1, model.OutboxSendDM, _testDuty)
+	sentTS := strconv.FormatInt(time.Now().Unix(), 10) + ".000001"
+	srv.SetHistory("D"+_testDuty, slack.Message{TS: sentTS, User: slacktest.BotUserID, Metadata: &slack.MessageMetadata{
+		EventType:    _notificationEventType,
+		EventPayload: map[string]interface{}{"service": "maid", "mention_id": "1", _outboxKeyField: key},
+	}})
+
+	ref, err := bot.sendDirectMessage(model.SlackDirectMsgOption{
+		MentionRecordID: 1,
+		ServiceName:     "maid",
+		User:            _testAsker,
+		LinkChannel:     _testChannel,
+		LinkTimestamp:   "1700000100.000001",
+		IdempotencyKey:  key,
+		RetrySince:      time.Now().Add(-time.Minute),
+	}, "https://fake.slack.com/archives/CHELP/p1700000100000001", _testDuty)
+	if err != nil {
+		t.Fatalf("send direct message: %+v", err)
+	}
+	if ref.ID != sentTS {
+		t.Fatalf("expect the sent direct message found, got %+v", ref)
+	}
+	if posts := srv.Calls(slack.MethodChatPostMessage); len(posts) != 0 {
+		t.Fatalf("expect the direct message not sent again, got %d posts", len(posts))
+	}
+
+	dms, err := repo.ListMentionDirectMessages(ctx, 1)
+	if err != nil {
+		t.Fatalf("list mention direct messages: %+v", err)
+	}
+	if len(dms) != 1 || dms[0].Timestamp != sentTS {
+		t.Fatalf("expect the found direct message recorded, got %+v", dms)
+	}
+}
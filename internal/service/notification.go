@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bitopi/internal/i18n"
+	"bitopi/internal/model"
+	"bitopi/internal/platform"
+	"bitopi/internal/slack"
+	"strconv"
+	"time"
+)
+
+const (
+	/* direct messages of mentions carry this metadata, so that clearing only removes them */
+	_notificationEventType = "bitopi_mention"
+	_clearHistoryPageSize  = 200
+	_clearOlderThanDays    = 7
+)
+
+type clearScope string
+
+const (
+	_clearScopeAll      clearScope = "all"
+	_clearScopeResolved clearScope = "resolved" /* done or the source message was deleted */
+	_clearScopeOlder    clearScope = "older"
+)
+
+type clearOption struct {
+	Scope  clearScope
+	Before time.Time /* only used by _clearScopeOlder */
+}
+
+func notificationMetadata(service string, mentionID uint64) *platform.Metadata {
+	return &platform.Metadata{
+		EventType: _notificationEventType,
+		EventPayload: map[string]interface{}{
+			"service":    service,
+			"mention_id": strconv.FormatUint(mentionID, 10),
+		},
+	}
+}
+
+/*
+clearNotifications deletes the notifications of this bot in the direct channel,
+and posts an ephemeral summary to the user when it's done.
+*/
+func (svc *SlackBot) clearNotifications(channel, userID string, opt clearOption) {
+	targets, err := svc.listClearTargets(channel, opt)
+	if err != nil {
+		svc.l.Errorf("list notifications to clear failed, err: %+v", err)
+		return
+	}
+
+	bulkErr := slack.NewBulkError("clear notifications", len(targets))
+	for _, ts := range targets {
+		bulkErr.Add(ts, svc.chat.DeleteMessage(svc.ctx, platform.MessageRef{Channel: channel, ID: ts}))
+	}
+
+	failed := bulkErr.Failed()
+	if err := bulkErr.Err(); err != nil {
+		svc.l.Errorf("delete messages failed, err: %+v", err)
+	}
+
+	locale := svc.userLocale(userID)
+	text := i18n.T(locale, i18n.MsgClearSummary, len(targets)-failed)
+	if failed != 0 {
+		text = text + i18n.T(locale, i18n.MsgClearFailed, failed)
+	}
+
+	if _, err := svc.client.PostEphemeral(svc.ctx, slack.PostEphemeralRequest{
+		Channel: channel,
+		User:    userID,
+		Text:    text,
+	}); err != nil {
+		svc.l.Errorf("post clear summary failed, err: %+v", err)
+	}
+}
+
+/*
+listClearTargets pages through the whole direct channel before deleting anything,
+deleting while paging would shift the cursor.
+*/
+func (svc *SlackBot) listClearTargets(channel string, opt clearOption) ([]string, error) {
+	auth, err := svc.authTest()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := map[uint64]string{}
+	targets := []string{}
+	cursor := ""
+	for {
+		res, err := svc.client.ConversationHistory(svc.ctx, slack.HistoryRequest{
+			Channel:            channel,
+			Cursor:             cursor,
+			Limit:              _clearHistoryPageSize,
+			IncludeAllMetadata: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range res.Messages {
+			if svc.shouldClear(auth, msg, opt, statuses) {
+				targets = append(targets, msg.TS)
+			}
+		}
+
+		cursor = res.NextCursor()
+		if len(cursor) == 0 {
+			return targets, nil
+		}
+	}
+}
+
+/*
+shouldClear reports whether the message is a notification of this bot matching the option.
+Notifications sent before metadata was attached are only cleared by time or by clearing all.
+*/
+func (svc *SlackBot) shouldClear(auth slack.AuthTestResponse, msg slack.Message, opt clearOption, statuses map[uint64]string) bool {
+	if !isOwnMessage(auth, msg) {
+		return false
+	}
+
+	legacy := msg.Metadata == nil || len(msg.Metadata.EventType) == 0
+	if !legacy {
+		if msg.Metadata.EventType != _notificationEventType || msg.Metadata.String("service") != svc.Name {
+			return false
+		}
+	}
+
+	switch opt.Scope {
+	case _clearScopeOlder:
+		t, ok := messageTime(msg.TS)
+		return ok && t.Before(opt.Before)
+	case _clearScopeResolved:
+		if legacy {
+			return false
+		}
+		mentionID, err := strconv.ParseUint(msg.Metadata.String("mention_id"), 10, 64)
+		if err != nil {
+			return false
+		}
+		status, ok := statuses[mentionID]
+		if !ok {
+			status = svc.mentionStatus(mentionID)
+			statuses[mentionID] = status
+		}
+		return status == model.MentionStatusResolved || status == model.MentionStatusCancelled
+	default:
+		return true
+	}
+}
+
+func (svc *Service) mentionStatus(mentionID uint64) string {
+	record, err := svc.repo.GetMentionRecord(svc.ctx, mentionID)
+	if err != nil {
+		svc.l.Warnf("get mention record %d failed, err: %+v", mentionID, err)
+		return ""
+	}
+	return record.Status
+}
+
+func isOwnMessage(auth slack.AuthTestResponse, msg slack.Message) bool {
+	if len(auth.BotID) != 0 && msg.BotID == auth.BotID {
+		return true
+	}
+	return len(auth.UserID) != 0 && msg.User == auth.UserID
+}
+
+// messageTime converts the timestamp of message like '1355517523.000005' to time.
+func messageTime(ts string) (time.Time, bool) {
+	sec, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(sec), 0), true
+}
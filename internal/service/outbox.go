@@ -0,0 +1,505 @@
+package service
+
+import (
+	"bitopi/internal/i18n"
+	"bitopi/internal/logging"
+	"bitopi/internal/metrics"
+	"bitopi/internal/model"
+	"bitopi/internal/platform"
+	"bitopi/internal/slack"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	_defaultOutboxInterval    = 5 * time.Second
+	_defaultOutboxMaxAttempts = 10
+	_outboxBatch              = 50
+	/* entries in progress are leased to the worker, they run again when the worker dies before the lease ends */
+	_outboxLease      = 2 * time.Minute
+	_outboxBackoff    = 5 * time.Second
+	_outboxMaxBackoff = 10 * time.Minute
+
+	_outboxEventType = "bitopi_reply"
+	_outboxKeyField  = "idempotency_key"
+)
+
+var (
+	/* errOutboxNotReady defers the entry without counting the attempt, e.g. direct messages waiting for the reply */
+	errOutboxNotReady = errors.New("outbox entry isn't ready")
+)
+
+/*
+outbox runs the outbox entries of the bot, it's shared by the copies of the bot.
+*/
+type outbox struct {
+	wake        chan struct{}
+	interval    time.Duration
+	maxAttempts int
+}
+
+func newOutbox() *outbox {
+	o := &outbox{
+		wake:        make(chan struct{}, 1),
+		interval:    viper.GetDuration("outbox.interval"),
+		maxAttempts: viper.GetInt("outbox.max_attempts"),
+	}
+	if o.interval <= 0 {
+		o.interval = _defaultOutboxInterval
+	}
+	if o.maxAttempts <= 0 {
+		o.maxAttempts = _defaultOutboxMaxAttempts
+	}
+	return o
+}
+
+/*
+mentionOutbox returns the side effects of the new mention: the reply when it's set, the direct message to every member,
+and publishing the home. Direct messages link to the reply, or to the mention when there's no reply or it failed.
+*/
+func (svc *SlackBot) mentionOutbox(id uint64, event model.Event, reply string, members []string) ([]model.OutboxEntry, error) {
+	entries := []model.OutboxEntry{}
+	add := func(kind string, payload interface{}, parts ...string) error {
+		buf, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrapf(err, "marshal payload of %s", kind)
+		}
+		entries = append(entries, model.OutboxEntry{
+			TeamID:          model.TeamFrom(svc.ctx),
+			Service:         svc.Name,
+			MentionRecordID: id,
+			Kind:            kind,
+			IdempotencyKey:  model.OutboxKey(svc.Name, id, kind, parts...),
+			Payload:         string(buf),
+		})
+		return nil
+	}
+
+	replyKey := ""
+	if len(reply) != 0 {
+		replyKey = model.OutboxKey(svc.Name, id, model.OutboxPostReply)
+		if err := add(model.OutboxPostReply, model.OutboxReply{
+			Channel:  event.Channel,
+			ThreadID: event.ThreadRootTimeStamp(),
+			Text:     reply,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, member := range members {
+		if err := add(model.OutboxSendDM, model.OutboxDirectMessage{
+			UserID:        member,
+			User:          event.User,
+			EventContent:  event.Text,
+			LinkChannel:   event.Channel,
+			LinkTimestamp: event.TimeStamp,
+			ReplyKey:      replyKey,
+		}, member); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := add(model.OutboxPublishHome, struct{}{}); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+/*
+followUpOutbox returns the follow-up of the mention in the thread of the record, it runs after the direct messages of the mention.
+*/
+func (svc *SlackBot) followUpOutbox(id uint64, event model.Event) ([]model.OutboxEntry, error) {
+	ts := event.TimeStamp
+	if len(ts) == 0 {
+		ts = event.EventTimeStamp
+	}
+
+	buf, err := json.Marshal(model.OutboxFollowUpMessage{
+		Channel:   event.Channel,
+		Timestamp: ts,
+		User:      event.User,
+		Text:      event.Text,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal payload of %s", model.OutboxFollowUp)
+	}
+
+	return []model.OutboxEntry{{
+		TeamID:          model.TeamFrom(svc.ctx),
+		Service:         svc.Name,
+		MentionRecordID: id,
+		Kind:            model.OutboxFollowUp,
+		IdempotencyKey:  model.OutboxKey(svc.Name, id, model.OutboxFollowUp, ts),
+		Payload:         string(buf),
+	}}, nil
+}
+
+/*
+resendOutbox returns the outbox entries resending the direct messages of the mention record to the users,
+they're keyed by the resend so a job which runs again creates them once.
+*/
+func (svc *SlackBot) resendOutbox(arg resendJob) ([]model.OutboxEntry, error) {
+	record, err := svc.repo.GetMentionRecord(svc.ctx, arg.MentionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get mention record")
+	}
+
+	msg, err := svc.getMessage(record.Channel, record.Timestamp)
+	if err != nil {
+		return nil, errors.Wrap(err, "get message from slack")
+	}
+
+	entries := make([]model.OutboxEntry, 0, len(arg.Users))
+	for _, user := range arg.Users {
+		payload, err := json.Marshal(model.OutboxDirectMessage{
+			UserID:        user,
+			User:          msg.User,
+			EventContent:  msg.Text,
+			LinkChannel:   record.Channel,
+			LinkTimestamp: record.Timestamp,
+			ResendUserID:  arg.ResendUserID,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal payload of %s", model.OutboxResendDM)
+		}
+
+		entries = append(entries, model.OutboxEntry{
+			TeamID:          model.TeamFrom(svc.ctx),
+			Service:         svc.Name,
+			MentionRecordID: record.ID,
+			Kind:            model.OutboxResendDM,
+			IdempotencyKey:  model.OutboxKey(svc.Name, record.ID, model.OutboxResendDM, user, arg.ResendID),
+			Payload:         string(payload),
+		})
+	}
+	return entries, nil
+}
+
+// wakeOutbox runs the outbox without waiting for the next interval.
+func (svc *SlackBot) wakeOutbox() {
+	select {
+	case svc.outbox.wake <- struct{}{}:
+	default:
+	}
+}
+
+/*
+StartOutbox runs the outbox entries and the webhook deliveries of the bot in background until ctx is done,
+entries of every workspace run in their workspaces. Shutdown waits for the entry in progress.
+*/
+func (svc *SlackBot) StartOutbox(ctx context.Context) {
+	svc.goTracked(func() {
+		ticker := time.NewTicker(svc.outbox.interval)
+		defer ticker.Stop()
+
+		for {
+			svc.runOutbox(ctx)
+			svc.runWebhookDeliveries(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-svc.outbox.wake:
+			}
+		}
+	})
+}
+
+func (svc *SlackBot) runOutbox(ctx context.Context) {
+	entries, err := svc.repo.ListDueOutboxEntries(svc.ctx, svc.Name, time.Now(), _outboxBatch)
+	if err != nil {
+		svc.l.Errorf("list due outbox entries failed, err: %+v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		claimed, err := svc.repo.ClaimOutboxEntry(svc.ctx, entry, time.Now().Add(_outboxLease))
+		if err != nil {
+			svc.l.Errorf("claim outbox entry %d failed, err: %+v", entry.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		bot := svc.workspace(entry.TeamID)
+		bot.withLogFields(map[string]interface{}{
+			logging.FieldTeam:      entry.TeamID,
+			logging.FieldMentionID: entry.MentionRecordID,
+		})
+		bot.runOutboxEntry(entry)
+	}
+}
+
+/*
+runOutboxEntry runs the entry once, failed entries are retried with exponential backoff until the max attempts.
+*/
+func (svc *SlackBot) runOutboxEntry(entry model.OutboxEntry) {
+	result, err := svc.execOutboxEntry(entry)
+	if errors.Is(err, errOutboxNotReady) {
+		entry.NextAttemptAt = time.Now().Add(svc.outbox.interval)
+		if err := svc.repo.UpdateOutboxEntry(svc.ctx, entry); err != nil {
+			svc.l.Errorf("update outbox entry %d failed, err: %+v", entry.ID, err)
+		}
+		return
+	}
+
+	entry.Attempts++
+	entry.NextAttemptAt = time.Now()
+	switch {
+	case err == nil:
+		entry.Status, entry.LastError, entry.Result = model.OutboxDone, "", result
+		if entry.Kind == model.OutboxPostReply {
+			metrics.Mention(svc.Name, metrics.MentionReplied)
+		}
+	case entry.Attempts >= svc.outbox.maxAttempts:
+		entry.Status, entry.LastError = model.OutboxFailed, err.Error()
+		svc.l.Errorf("run outbox entry %s failed, gave up after %d attempts, err: %+v", entry.IdempotencyKey, entry.Attempts, err)
+		if entry.Kind == model.OutboxPostReply {
+			metrics.Mention(svc.Name, metrics.MentionFailed)
+		}
+	default:
+		entry.LastError = err.Error()
+		entry.NextAttemptAt = entry.NextAttemptAt.Add(outboxBackoff(entry.Attempts))
+		svc.l.Warnf("run outbox entry %s, attempt %d failed, err: %+v", entry.IdempotencyKey, entry.Attempts, err)
+	}
+
+	if err := svc.repo.UpdateOutboxEntry(svc.ctx, entry); err != nil {
+		svc.l.Errorf("update outbox entry %d failed, err: %+v", entry.ID, err)
+	}
+}
+
+// execOutboxEntry runs the side effect of the entry, and returns the JSON result of it.
+func (svc *SlackBot) execOutboxEntry(entry model.OutboxEntry) (string, error) {
+	switch entry.Kind {
+	case model.OutboxPostReply:
+		arg := model.OutboxReply{}
+		if err := json.Unmarshal([]byte(entry.Payload), &arg); err != nil {
+			return "", err
+		}
+		ref, err := svc.postOutboxReply(entry, arg)
+		if err != nil {
+			return "", errors.Wrap(err, "post reply")
+		}
+		return outboxResult(ref)
+	case model.OutboxSendDM, model.OutboxResendDM:
+		arg := model.OutboxDirectMessage{}
+		if err := json.Unmarshal([]byte(entry.Payload), &arg); err != nil {
+			return "", err
+		}
+		ref, err := svc.sendOutboxDirectMessage(entry, arg)
+		if err != nil {
+			return "", err
+		}
+		return outboxResult(ref)
+	case model.OutboxPublishHome:
+		return "", errors.Wrap(svc.publishHomeView(), "publish home view")
+	case model.OutboxFollowUp:
+		arg := model.OutboxFollowUpMessage{}
+		if err := json.Unmarshal([]byte(entry.Payload), &arg); err != nil {
+			return "", err
+		}
+		return "", svc.sendOutboxFollowUp(entry, arg)
+	default:
+		return "", errors.Errorf("unknown outbox entry kind '%s'", entry.Kind)
+	}
+}
+
+/*
+postOutboxReply posts the reply with the idempotency key in its metadata,
+retries look for the reply first on platforms which can find it, in case the last attempt posted it.
+*/
+func (svc *SlackBot) postOutboxReply(entry model.OutboxEntry, arg model.OutboxReply) (platform.MessageRef, error) {
+	metadata := platform.Metadata{
+		EventType:    _outboxEventType,
+		EventPayload: map[string]interface{}{_outboxKeyField: entry.IdempotencyKey},
+	}
+
+	if finder, ok := svc.chat.(platform.ReplyFinder); ok && entry.Retried() && len(arg.ThreadID) != 0 {
+		ref, found, err := finder.FindReply(svc.ctx, arg.Channel, arg.ThreadID, metadata)
+		if err != nil {
+			return platform.MessageRef{}, errors.Wrap(err, "find reply")
+		}
+		if found {
+			svc.l.Infof("reply of %s was posted, skip posting", entry.IdempotencyKey)
+			return ref, nil
+		}
+	}
+
+	return svc.chat.PostReply(svc.ctx, platform.Message{
+		Channel:  arg.Channel,
+		ThreadID: arg.ThreadID,
+		Text:     arg.Text,
+		Metadata: &metadata,
+	})
+}
+
+/*
+sendOutboxDirectMessage sends the direct message once, users who have the direct message of the mention are skipped.
+Resends are sent to the users even if they have one, their retries find the direct message of the key like the others.
+*/
+func (svc *SlackBot) sendOutboxDirectMessage(entry model.OutboxEntry, arg model.OutboxDirectMessage) (platform.MessageRef, error) {
+	if entry.Kind == model.OutboxSendDM {
+		dms, err := svc.repo.ListMentionDirectMessages(svc.ctx, entry.MentionRecordID)
+		if err != nil {
+			return platform.MessageRef{}, errors.Wrap(err, "list mention direct messages")
+		}
+		for _, dm := range dms {
+			if dm.UserID == arg.UserID {
+				return platform.MessageRef{Channel: dm.Channel, ID: dm.Timestamp}, nil
+			}
+		}
+	}
+
+	linkChannel, linkTimestamp := arg.LinkChannel, arg.LinkTimestamp
+	if len(arg.ReplyKey) != 0 {
+		reply, err := svc.repo.GetOutboxEntry(svc.ctx, arg.ReplyKey)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return platform.MessageRef{}, errors.Wrap(err, "get outbox entry of reply")
+		}
+
+		/* the direct message waits for the first attempt of the reply, and links to the mention when the reply failed */
+		switch {
+		case reply.Status == model.OutboxPending && reply.Attempts == 0:
+			return platform.MessageRef{}, errOutboxNotReady
+		case reply.Status == model.OutboxDone:
+			ref := model.OutboxMessage{}
+			if err := json.Unmarshal([]byte(reply.Result), &ref); err != nil {
+				return platform.MessageRef{}, errors.Wrap(err, "unmarshal result of reply")
+			}
+			linkChannel, linkTimestamp = ref.Channel, ref.ID
+		}
+	}
+
+	link, err := svc.getPermalink(linkChannel, linkTimestamp)
+	if err != nil {
+		return platform.MessageRef{}, errors.Wrap(err, "get permalink")
+	}
+
+	ref, err := svc.sendDirectMessage(model.SlackDirectMsgOption{
+		MentionRecordID: entry.MentionRecordID,
+		ServiceName:     svc.Name,
+		User:            arg.User,
+		EventContent:    arg.EventContent,
+		LinkChannel:     linkChannel,
+		LinkTimestamp:   linkTimestamp,
+		ResendUserID:    arg.ResendUserID,
+		IdempotencyKey:  entry.IdempotencyKey,
+		RetrySince:      retrySince(entry),
+	}, link, arg.UserID)
+	if err != nil {
+		return platform.MessageRef{}, errors.Wrap(err, "send direct message")
+	}
+	return ref, nil
+}
+
+/*
+sendOutboxFollowUp replies the follow-up mention in the threads of the direct messages of the mention record.
+
+It waits for the direct messages of the mention in the outbox, and fails to be retried when none of them was sent.
+Retries skip the threads which have the reply of the entry.
+*/
+func (svc *SlackBot) sendOutboxFollowUp(entry model.OutboxEntry, arg model.OutboxFollowUpMessage) error {
+	entries, err := svc.repo.ListOutboxEntries(svc.ctx, entry.MentionRecordID)
+	if err != nil {
+		return errors.Wrap(err, "list outbox entries of mention")
+	}
+
+	sending := 0
+	for _, e := range entries {
+		if e.Kind != model.OutboxSendDM {
+			continue
+		}
+		if e.Status == model.OutboxPending {
+			return errOutboxNotReady
+		}
+		sending++
+	}
+
+	dms, err := svc.repo.ListMentionDirectMessages(svc.ctx, entry.MentionRecordID)
+	if err != nil {
+		return errors.Wrap(err, "list mention direct messages")
+	}
+
+	if len(dms) == 0 {
+		if sending == 0 {
+			svc.l.Infof("no direct message of mention record %d to follow up", entry.MentionRecordID)
+			return nil
+		}
+		return errors.Errorf("no direct message of mention record %d was sent", entry.MentionRecordID)
+	}
+
+	link, err := svc.getPermalink(arg.Channel, arg.Timestamp)
+	if err != nil {
+		return errors.Wrap(err, "get permalink")
+	}
+
+	metadata := platform.Metadata{
+		EventType:    _outboxEventType,
+		EventPayload: map[string]interface{}{_outboxKeyField: entry.IdempotencyKey},
+	}
+	finder, canFind := svc.chat.(platform.ReplyFinder)
+
+	bulkErr := slack.NewBulkError("send follow-up direct message", len(dms))
+	for _, dm := range dms {
+		if canFind && entry.Retried() {
+			_, found, err := finder.FindReply(svc.ctx, dm.Channel, dm.Timestamp, metadata)
+			if err != nil {
+				bulkErr.Add(dm.UserID, errors.Wrap(err, "find follow-up"))
+				continue
+			}
+			if found {
+				continue
+			}
+		}
+
+		text := i18n.T(svc.userLocale(dm.UserID), i18n.MsgFollowUp, link, arg.User)
+		_, err := svc.chat.PostReply(svc.ctx, platform.Message{
+			Channel:  dm.Channel,
+			ThreadID: dm.Timestamp,
+			Text:     text,
+			Sections: []platform.Section{{Text: text, Quote: arg.Text}},
+			Metadata: &metadata,
+		})
+		bulkErr.Add(dm.UserID, err)
+	}
+	return bulkErr.Err()
+}
+
+/*
+retrySince returns the created time of the entry when its side effect may have been done by the last attempt,
+a minute earlier in case the clock of the platform is behind.
+*/
+func retrySince(entry model.OutboxEntry) time.Time {
+	if !entry.Retried() {
+		return time.Time{}
+	}
+	return entry.CreatedAt.Add(-time.Minute)
+}
+
+func outboxResult(ref platform.MessageRef) (string, error) {
+	buf, err := json.Marshal(model.OutboxMessage{Channel: ref.Channel, ID: ref.ID})
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// outboxBackoff returns the delay after the nth failed attempt.
+func outboxBackoff(n int) time.Duration {
+	d := _outboxBackoff << (n - 1)
+	if d <= 0 || d > _outboxMaxBackoff {
+		return _outboxMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/platform"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	_readinessTimeout = 3 * time.Second
+	/* the scheduler is stalled when the next run of a job is overdue by this long */
+	_schedulerGrace = time.Minute
+)
+
+// Scheduler is the scheduler of the cron jobs, e.g. *cron.Cron.
+type Scheduler interface {
+	Entries() []cron.Entry
+}
+
+/*
+Readiness checks the dependencies of the bots for Kubernetes, the bots and the scheduler are added by the app.
+
+The outbox backlog is reported but never fails the check, restarting the pod doesn't drain it.
+*/
+type Readiness struct {
+	svc       Service
+	bots      []SlackBot
+	scheduler Scheduler
+}
+
+func NewReadiness(svc Service) *Readiness {
+	return &Readiness{svc: svc}
+}
+
+// AddBot checks the token and the outbox of the bot, it's called before the server starts.
+func (r *Readiness) AddBot(bot SlackBot) {
+	r.bots = append(r.bots, bot)
+}
+
+// SetScheduler checks the scheduler is running, it's called before the server starts.
+func (r *Readiness) SetScheduler(s Scheduler) {
+	r.scheduler = s
+}
+
+/*
+Handler responds the status of every component, with 503 when any component is unavailable.
+*/
+func (r *Readiness) Handler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), _readinessTimeout)
+	defer cancel()
+
+	res := model.ReadinessResponse{
+		Status:     model.ReadinessOK,
+		Components: map[string]model.ComponentStatus{},
+	}
+	set := func(name string, detail interface{}, err error) {
+		status := model.ComponentStatus{Status: model.ReadinessOK, Detail: detail}
+		if err != nil {
+			status.Status, status.Error = model.ReadinessUnavailable, err.Error()
+			res.Status = model.ReadinessUnavailable
+		}
+		res.Components[name] = status
+	}
+
+	set("database", nil, r.svc.repo.Ping(ctx))
+	set(r.checkMigration(ctx))
+	set(r.checkScheduler())
+	for i := range r.bots {
+		bot := &r.bots[i]
+		if bot.Platform == platform.Mattermost {
+			res.Components["bot:"+bot.Name] = model.ComponentStatus{Status: model.ReadinessSkipped, Detail: "auth.test is only checked on Slack"}
+		} else {
+			set(r.checkBot(ctx, bot))
+		}
+		set(r.checkOutbox(ctx, bot))
+	}
+
+	if res.Status != model.ReadinessOK {
+		return c.JSON(http.StatusServiceUnavailable, res)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (r *Readiness) checkMigration(ctx context.Context) (string, interface{}, error) {
+	applied, latest, err := r.svc.repo.SchemaVersion(ctx)
+	detail := map[string]int{"applied": applied, "latest": latest}
+	if err != nil {
+		return "migration", detail, err
+	}
+	if applied < latest {
+		return "migration", detail, errors.Errorf("schema version %d is behind %d", applied, latest)
+	}
+	return "migration", detail, nil
+}
+
+func (r *Readiness) checkScheduler() (string, interface{}, error) {
+	if r.scheduler == nil {
+		return "scheduler", nil, errors.New("no scheduler")
+	}
+
+	entries := r.scheduler.Entries()
+	if len(entries) == 0 {
+		return "scheduler", nil, errors.New("no cron job")
+	}
+
+	var next time.Time
+	for _, e := range entries {
+		/* the next run is only scheduled after the scheduler starts */
+		if e.Next.IsZero() {
+			return "scheduler", nil, errors.New("scheduler isn't running")
+		}
+		if time.Since(e.Next) > _schedulerGrace {
+			return "scheduler", nil, errors.Errorf("cron job %d is overdue since %s", e.ID, e.Next.Format(time.RFC3339))
+		}
+		if next.IsZero() || e.Next.Before(next) {
+			next = e.Next
+		}
+	}
+	return "scheduler", map[string]interface{}{"jobs": len(entries), "next_run": next}, nil
+}
+
+/*
+checkBot verifies the static token and the tokens of the installed workspaces with the cached auth.test results.
+
+The bot is unavailable when none of its tokens passes, workspaces which fail are listed in the detail,
+a revoked installation doesn't take the other workspaces down.
+*/
+func (r *Readiness) checkBot(ctx context.Context, bot *SlackBot) (string, interface{}, error) {
+	name := "bot:" + bot.Name
+	workspaces, err := r.svc.repo.ListWorkspaces(ctx, bot.Name)
+	if err != nil {
+		return name, nil, errors.Wrap(err, "list workspaces")
+	}
+
+	detail := map[string]interface{}{"token": "not configured"}
+	if len(bot.Token) == 0 && len(workspaces) == 0 {
+		return name, detail, nil
+	}
+
+	passed := 0
+	var lastErr error
+	if len(bot.Token) != 0 {
+		res, err := bot.authTestContext(ctx)
+		if err != nil {
+			detail["token"], lastErr = err.Error(), errors.Wrap(err, "auth test")
+		} else {
+			detail["token"], passed = map[string]string{"team_id": res.TeamID, "user_id": res.UserID}, passed+1
+		}
+	}
+
+	failed := map[string]string{}
+	for _, ws := range workspaces {
+		wsBot := bot.workspace(ws.TeamID)
+		if _, err := wsBot.authTestContext(ctx); err != nil {
+			failed[ws.TeamID], lastErr = err.Error(), errors.Wrapf(err, "auth test of workspace '%s'", ws.TeamID)
+			continue
+		}
+		passed++
+	}
+	detail["workspaces"] = len(workspaces)
+	if len(failed) != 0 {
+		detail["failed_workspaces"] = failed
+	}
+
+	if passed == 0 {
+		return name, detail, lastErr
+	}
+	return name, detail, nil
+}
+
+func (r *Readiness) checkOutbox(ctx context.Context, bot *SlackBot) (string, interface{}, error) {
+	name := "outbox:" + bot.Name
+	backlog, err := r.svc.repo.OutboxBacklog(ctx, bot.Name)
+	if err != nil {
+		return name, nil, errors.Wrap(err, "count outbox backlog")
+	}
+	return name, backlog, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"bitopi/internal/slack/slacktest"
+	"context"
+	"testing"
+)
+
+func TestReadinessCheckBot(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, _ := newTestBot(t, srv)
+	r := NewReadiness(bot.Service)
+
+	/* bots of OAuth without installations have nothing to check */
+	noToken := bot
+	noToken.Token = ""
+	_, detail, err := r.checkBot(context.Background(), &noToken)
+	if err != nil {
+		t.Fatalf("expect bot without token ready, got %+v", err)
+	}
+	if d, _ := detail.(map[string]interface{}); d["token"] != "not configured" {
+		t.Fatalf("expect token not configured, got %+v", detail)
+	}
+
+	/* the failure of the cancelled check isn't cached */
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := r.checkBot(ctx, &bot); err == nil {
+		t.Fatal("expect the cancelled check failed")
+	}
+	if _, _, err := r.checkBot(context.Background(), &bot); err != nil {
+		t.Fatalf("expect the token checked again, got %+v", err)
+	}
+}
@@ -1,17 +1,30 @@
 package service
 
 import (
+	"bitopi/internal/logging"
 	"bitopi/internal/model"
+	"bitopi/internal/msgtemplate"
 	"context"
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/yanun0323/pkg/logs"
 )
 
 const (
 	_botServicePathKey = "service"
+	_limitQueryKey     = "limit"
+	_rotationQueryKey  = "rotation"
+	_channelPathKey    = "channel"
+	_teamQueryKey      = "team_id"
+
+	_defaultAuditLogLimit = 50
+	_maxListLimit         = 500 /* limits of list endpoints over it are capped */
 )
 
 var (
@@ -24,11 +37,20 @@ var (
 )
 
 func DataResponse(c echo.Context, data interface{}, msgs ...string) error {
+	return dataResponse(c, http.StatusOK, data, msgs...)
+}
+
+// AcceptedResponse responds 202 with the data of the work queued by the request.
+func AcceptedResponse(c echo.Context, data interface{}, msgs ...string) error {
+	return dataResponse(c, http.StatusAccepted, data, msgs...)
+}
+
+func dataResponse(c echo.Context, code int, data interface{}, msgs ...string) error {
 	msg := "OK"
 	if len(msgs) != 0 {
 		msg = msgs[0]
 	}
-	return c.JSON(http.StatusOK, struct {
+	return c.JSON(code, struct {
 		Msg  string      `json:"message"`
 		Data interface{} `json:"data,omitempty"`
 	}{
@@ -52,6 +74,49 @@ func ErrorResponse(c echo.Context, code int, msg string, errs ...error) error {
 	})
 }
 
+/*
+rosterKey returns the service key of the rotation in the query, which is the bot itself when there's no rotation.
+*/
+func (svc *Service) rosterKey(c echo.Context, category string) (string, bool) {
+	rotation := c.QueryParam(_rotationQueryKey)
+	if !svc.isValidRotation(category, rotation) {
+		return "", false
+	}
+	return RotationKey(category, rotation), true
+}
+
+/*
+listLimit parses the 'limit' query of list endpoints, def is used when it's empty.
+Limits which aren't positive are rejected, and limits over _maxListLimit are capped.
+*/
+func listLimit(c echo.Context, def int) (int, error) {
+	l := c.QueryParam(_limitQueryKey)
+	if len(l) == 0 {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(l)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errors.Errorf("limit %d isn't positive", n)
+	}
+	if n > _maxListLimit {
+		return _maxListLimit, nil
+	}
+	return n, nil
+}
+
+/*
+requestContext attaches the actor of the request into the service context for audit logs,
+and scopes the request to the workspace of the 'team_id' query, the static token workspace by default.
+*/
+func (svc *Service) requestContext(c echo.Context) context.Context {
+	ctx := model.WithActor(svc.ctx, model.ActorFrom(c.Request().Context()))
+	return model.WithTeam(ctx, c.QueryParam(_teamQueryKey))
+}
+
 func (svc *Service) HealthCheck(c echo.Context) error {
 	return ErrorResponse(c, http.StatusOK, "OK")
 }
@@ -62,17 +127,22 @@ func (svc *Service) GetMemberList(c echo.Context) error {
 		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
 	}
 
+	key, ok := svc.rosterKey(c, category)
+	if !ok {
+		return ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("rotation '%s' of bot '%s' not found", c.QueryParam(_rotationQueryKey), category))
+	}
+
 	response := model.GetMemberListResponse{}
 
-	err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
-		members, err := svc.repo.ListMembers(txCtx, category)
+	err := svc.repo.Tx(svc.requestContext(c), func(txCtx context.Context) error {
+		members, err := svc.repo.ListMembers(txCtx, key)
 		if err != nil {
-			return ErrorResponse(c, http.StatusInternalServerError, "list member error", err)
+			return errors.Wrap(err, "list member")
 		}
 
-		startAt, err := svc.repo.GetStartDate(txCtx, category)
+		startAt, err := svc.repo.GetStartDate(txCtx, key)
 		if err != nil {
-			return ErrorResponse(c, http.StatusInternalServerError, "get start time error", err)
+			return errors.Wrap(err, "get start time")
 		}
 
 		response.Members = members
@@ -80,7 +150,7 @@ func (svc *Service) GetMemberList(c echo.Context) error {
 		return nil
 	})
 	if err != nil {
-		return err
+		return ErrorResponse(c, http.StatusInternalServerError, "get member list error", err)
 	}
 
 	return DataResponse(c, response)
@@ -92,43 +162,103 @@ func (svc *Service) SetMemberList(c echo.Context) error {
 		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
 	}
 
+	key, ok := svc.rosterKey(c, category)
+	if !ok {
+		return ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("rotation '%s' of bot '%s' not found", c.QueryParam(_rotationQueryKey), category))
+	}
+
 	req := model.SetMemberListRequest{}
 	if err := c.Bind(&req); err != nil {
 		return ErrorResponse(c, http.StatusBadRequest, "request parameters mismatch", err)
 	}
 
 	for i := range req.Members {
-		req.Members[i].Service = category
+		req.Members[i].Service = key
 	}
 
 	sort.Slice(req.Members, func(i, j int) bool {
 		return req.Members[i].Order < req.Members[j].Order
 	})
 
-	err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
-		if err := svc.repo.ResetMembers(txCtx, category, req.Members); err != nil {
-			return ErrorResponse(c, http.StatusInternalServerError, "set members error", err)
+	effectiveFrom := req.EffectiveFrom
+	if effectiveFrom.IsZero() {
+		effectiveFrom = time.Now()
+	}
+
+	err := svc.repo.Tx(svc.requestContext(c), func(txCtx context.Context) error {
+		if err := svc.keepRosterBaseline(txCtx, key); err != nil {
+			return errors.Wrap(err, "keep roster baseline")
 		}
 
-		if err := svc.repo.UpdateStartDate(txCtx, category, req.StartAt); err != nil {
-			return ErrorResponse(c, http.StatusInternalServerError, "set start time error", err)
+		if err := svc.repo.ResetMembers(txCtx, key, req.Members); err != nil {
+			return errors.Wrap(err, "set members")
+		}
+
+		if err := svc.repo.UpdateStartDate(txCtx, key, req.StartAt); err != nil {
+			return errors.Wrap(err, "set start time")
+		}
+
+		if _, err := svc.repo.SnapshotRoster(txCtx, key, effectiveFrom); err != nil {
+			return errors.Wrap(err, "snapshot roster")
 		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return ErrorResponse(c, http.StatusInternalServerError, "set member list error", err)
 	}
 
 	return svc.GetMemberList(c)
 }
 
+/*
+keepRosterBaseline stores the current roster as the first version before it's changed,
+so the duty members before the change are still answered by the old roster.
+*/
+func (svc *Service) keepRosterBaseline(txCtx context.Context, service string) error {
+	roster, err := svc.repo.GetRosterVersion(txCtx, service, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if roster.ID != 0 {
+		return nil
+	}
+
+	members, err := svc.repo.ListMembers(txCtx, service)
+	if err != nil || len(members) == 0 {
+		return err
+	}
+
+	_, err = svc.repo.SnapshotRoster(txCtx, service, _rosterEpoch)
+	return err
+}
+
+func (svc *Service) ListRosterVersions(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	key, ok := svc.rosterKey(c, category)
+	if !ok {
+		return ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("rotation '%s' of bot '%s' not found", c.QueryParam(_rotationQueryKey), category))
+	}
+
+	versions, err := svc.repo.ListRosterVersions(svc.requestContext(c), key)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "list roster versions error", err)
+	}
+
+	return DataResponse(c, versions)
+}
+
 func (svc *Service) GetMentionMessage(c echo.Context) error {
 	category := c.Param(_botServicePathKey)
 	if !_validCategory[category] {
 		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
 	}
 
-	reply, err := svc.repo.GetReplyMessage(svc.ctx, category)
+	reply, err := svc.repo.GetReplyMessage(svc.requestContext(c), category)
 	if err != nil {
 		return ErrorResponse(c, http.StatusInternalServerError, "get message error", err)
 	}
@@ -147,8 +277,14 @@ func (svc *Service) SetMentionMessage(c echo.Context) error {
 		return ErrorResponse(c, http.StatusBadRequest, "request parameters mismatch", err)
 	}
 
+	for _, text := range []string{req.MentionMessage, req.HomeMentionMessage} {
+		if err := svc.validateTemplate(category, text); err != nil {
+			return ErrorResponse(c, http.StatusBadRequest, "invalid message template", err)
+		}
+	}
+
 	req.Service = category
-	if err := svc.repo.Tx(svc.ctx, func(txCtx context.Context) error {
+	if err := svc.repo.Tx(svc.requestContext(c), func(txCtx context.Context) error {
 		return svc.repo.SetReplyMessage(txCtx, req)
 	}); err != nil {
 		return ErrorResponse(c, http.StatusInternalServerError, "set mention message error", err)
@@ -156,3 +292,180 @@ func (svc *Service) SetMentionMessage(c echo.Context) error {
 
 	return svc.GetMentionMessage(c)
 }
+
+func (svc *Service) ListChannelConfigs(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	cfgs, err := svc.repo.ListChannelConfigs(svc.requestContext(c), category)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "list channel configs error", err)
+	}
+
+	return DataResponse(c, cfgs)
+}
+
+func (svc *Service) SetChannelConfig(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	req := model.SetChannelConfigRequest{}
+	if err := c.Bind(&req); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "request parameters mismatch", err)
+	}
+
+	if err := svc.validateTemplate(category, req.ReplyMessage); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid reply template", err)
+	}
+
+	switch req.Access {
+	case model.ChannelAccessDefault, model.ChannelAccessAllow, model.ChannelAccessDeny:
+	default:
+		return ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid access '%s'", req.Access))
+	}
+
+	cfg := model.ChannelConfig{
+		Service:      category,
+		Channel:      c.Param(_channelPathKey),
+		Access:       req.Access,
+		ReplyMessage: req.ReplyMessage,
+		Silent:       req.Silent,
+	}
+	cfg.SetNotifyList(req.Notify)
+
+	if err := svc.repo.Tx(svc.requestContext(c), func(txCtx context.Context) error {
+		return svc.repo.SetChannelConfig(txCtx, cfg)
+	}); err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "set channel config error", err)
+	}
+
+	return svc.ListChannelConfigs(c)
+}
+
+func (svc *Service) DeleteChannelConfig(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	if err := svc.repo.Tx(svc.requestContext(c), func(txCtx context.Context) error {
+		return svc.repo.DeleteChannelConfig(txCtx, category, c.Param(_channelPathKey))
+	}); err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "delete channel config error", err)
+	}
+
+	return svc.ListChannelConfigs(c)
+}
+
+func (svc *Service) PreviewMentionMessage(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	req := model.PreviewMessageRequest{}
+	if err := c.Bind(&req); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "request parameters mismatch", err)
+	}
+
+	if err := svc.validateTemplate(category, req.Template); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid message template", err)
+	}
+
+	text, err := msgtemplate.Preview(req.Template, svc.rotationNames(category))
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid message template", err)
+	}
+
+	return DataResponse(c, model.PreviewMessageResponse{Text: text})
+}
+
+/*
+validateTemplate rejects positional templates and templates with unknown placeholders or rotations of the bot, empty template is valid.
+*/
+func (svc *Service) validateTemplate(service, text string) error {
+	if len(text) == 0 {
+		return nil
+	}
+
+	if msgtemplate.IsLegacy(text) {
+		return errors.New("positional '%s' is no longer supported, use placeholders like {{.Duty}}")
+	}
+
+	return msgtemplate.Validate(text, svc.rotationNames(service))
+}
+
+func (svc *Service) ListAuditLogs(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	limit, err := listLimit(c, _defaultAuditLogLimit)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid limit", err)
+	}
+
+	logs, err := svc.repo.ListAuditLogs(svc.requestContext(c), category, limit)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "list audit logs error", err)
+	}
+
+	return DataResponse(c, logs)
+}
+
+func (svc *Service) ListWorkspaces(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	workspaces, err := svc.repo.ListWorkspaces(svc.ctx, category)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "list workspaces error", err)
+	}
+
+	return DataResponse(c, workspaces)
+}
+
+func (svc *Service) GetLogLevel(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	return DataResponse(c, model.LogLevelResponse{Service: category, Level: string(logging.Level(category))})
+}
+
+/*
+SetLogLevel changes the log level of the bot at runtime, it's reset to 'log.level' on restart.
+*/
+func (svc *Service) SetLogLevel(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	req := model.SetLogLevelRequest{}
+	if err := c.Bind(&req); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "request parameters mismatch", err)
+	}
+
+	var level logs.Level
+	if len(req.Level) != 0 {
+		l, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			return ErrorResponse(c, http.StatusBadRequest, "invalid log level", err)
+		}
+		level = l
+	}
+
+	logging.SetLevel(category, level)
+	svc.l.Infof("log level of bot '%s' was set to '%s' by %s", category, logging.Level(category), model.ActorFrom(c.Request().Context()).Name)
+
+	return svc.GetLogLevel(c)
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bitopi/internal/metrics"
+	"bitopi/internal/model"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+/*
+RotationOption is a named duty rotation of the bot, e.g. 'ex-pro' and 'meta' of devops.
+
+Roster data of the rotation is stored under '<bot>/<name>', or under the bot name when the name is empty,
+so the first rotation can keep the roster of a bot which had no rotation before.
+Zero defaults fall back to the defaults of the bot.
+*/
+type RotationOption struct {
+	Name                      string
+	Title                     string   /* shown in replies, name by default */
+	Keywords                  []string /* case-insensitive whole words of the message */
+	Patterns                  []string /* regular expressions of the message */
+	Channels                  []string /* channel IDs */
+	DefaultStartDate          time.Time
+	DefaultDutyDuration       time.Duration
+	DefaultMemberCountPerTime int
+	DefaultMemberList         []model.Member
+}
+
+type rotation struct {
+	RotationOption
+	key      string
+	patterns []*regexp.Regexp /* keywords and patterns */
+}
+
+const (
+	_week = 7 * 24 * time.Hour
+)
+
+/*
+rotationRegistry keeps the rotations of every bot of the service by bot name, it's shared by the copies of the service
+and validates the rotations of REST requests.
+*/
+type rotationRegistry struct {
+	mu   sync.RWMutex
+	bots map[string][]*rotation
+}
+
+func newRotationRegistry() *rotationRegistry {
+	return &rotationRegistry{bots: map[string][]*rotation{}}
+}
+
+func (r *rotationRegistry) set(bot string, rotations []*rotation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bots[bot] = rotations
+}
+
+func (r *rotationRegistry) get(bot string) []*rotation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.bots[bot]
+}
+
+type rotationDuty struct {
+	rotation *rotation
+	Duty     []string /* user IDs */
+	Left     []string /* user IDs */
+	Next     []string /* user IDs of the next shift */
+	ShiftEnd time.Time
+	Matched  bool
+}
+
+// RotationKey returns the service key which roster data of the rotation is stored under.
+func RotationKey(service, rotation string) string {
+	if len(rotation) == 0 {
+		return service
+	}
+	return service + "/" + rotation
+}
+
+func newRotations(opt SlackBotOption) ([]*rotation, error) {
+	opts := opt.Rotations
+	if len(opts) == 0 {
+		opts = []RotationOption{{}}
+	}
+
+	rotations := make([]*rotation, 0, len(opts))
+	names := map[string]bool{}
+	for _, o := range opts {
+		if names[o.Name] {
+			return nil, errors.Errorf("duplicated rotation '%s' of bot '%s'", o.Name, opt.Name)
+		}
+		names[o.Name] = true
+
+		if len(o.Title) == 0 {
+			o.Title = o.Name
+		}
+		if o.DefaultStartDate.IsZero() {
+			o.DefaultStartDate = opt.DefaultStartDate
+		}
+		if o.DefaultDutyDuration == 0 {
+			o.DefaultDutyDuration = opt.DefaultDutyDuration
+		}
+		if o.DefaultMemberCountPerTime == 0 {
+			o.DefaultMemberCountPerTime = opt.DefaultMemberCountPerTime
+		}
+		if len(o.DefaultMemberList) == 0 {
+			o.DefaultMemberList = opt.DefaultMemberList
+		}
+
+		rot := &rotation{
+			RotationOption: o,
+			key:            RotationKey(opt.Name, o.Name),
+			patterns:       make([]*regexp.Regexp, 0, len(o.Keywords)+len(o.Patterns)),
+		}
+		for _, keyword := range o.Keywords {
+			rot.patterns = append(rot.patterns, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(keyword)+`\b`))
+		}
+		for _, p := range o.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, errors.Wrapf(err, "compile pattern of rotation '%s'", o.Name)
+			}
+			rot.patterns = append(rot.patterns, re)
+		}
+		rotations = append(rotations, rot)
+	}
+
+	if len(opt.DefaultRotation) != 0 && !names[opt.DefaultRotation] {
+		return nil, errors.Errorf("default rotation '%s' of bot '%s' not found", opt.DefaultRotation, opt.Name)
+	}
+
+	return rotations, nil
+}
+
+// rotationNames returns the names and titles of the rotations of the bot of the service, for the placeholders of templates.
+func (svc *Service) rotationNames(service string) []string {
+	rotations := svc.botRotations.get(service)
+	names := make([]string, 0, 2*len(rotations))
+	for _, rot := range rotations {
+		names = append(names, rot.Name, rot.Title)
+	}
+	return names
+}
+
+// isValidRotation reports whether the bot of the service has the rotation, empty rotation is the bot itself.
+func (svc *Service) isValidRotation(service, rotation string) bool {
+	if len(rotation) == 0 {
+		return true
+	}
+
+	for _, rot := range svc.botRotations.get(service) {
+		if rot.Name == rotation {
+			return true
+		}
+	}
+	return false
+}
+
+func (rot *rotation) match(event model.Event) bool {
+	for _, ch := range rot.Channels {
+		if ch == event.Channel {
+			return true
+		}
+	}
+
+	for _, re := range rot.patterns {
+		if re.MatchString(event.Text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+matchRotations picks rotations by the channel and the content of the event.
+
+Unmatched mentions go to the default rotation, or to all rotations when there's no default rotation.
+*/
+func (svc *SlackBot) matchRotations(event model.Event) []*rotation {
+	if len(svc.rotations) == 1 {
+		return svc.rotations
+	}
+
+	matched := []*rotation{}
+	for _, rot := range svc.rotations {
+		if rot.match(event) {
+			matched = append(matched, rot)
+		}
+	}
+
+	if len(matched) != 0 {
+		return matched
+	}
+
+	if rot := svc.rotation(svc.DefaultRotation); rot != nil && len(svc.DefaultRotation) != 0 {
+		return []*rotation{rot}
+	}
+
+	return svc.rotations
+}
+
+// rotation returns nil when the rotation is not found.
+func (svc *SlackBot) rotation(name string) *rotation {
+	for _, rot := range svc.rotations {
+		if rot.Name == name {
+			return rot
+		}
+	}
+	return nil
+}
+
+// defaultRotation returns the rotation of unmatched mentions, or the first rotation.
+func (svc *SlackBot) defaultRotation() *rotation {
+	if rot := svc.rotation(svc.DefaultRotation); rot != nil {
+		return rot
+	}
+	return svc.rotations[0]
+}
+
+/*
+rotationDuties returns the duty members of every rotation of the bot at the moment, in the order of rotations.
+*/
+func (svc *SlackBot) rotationDuties(matched []*rotation, at time.Time) ([]rotationDuty, error) {
+	isMatched := map[*rotation]bool{}
+	for _, rot := range matched {
+		isMatched[rot] = true
+	}
+
+	duties := make([]rotationDuty, 0, len(svc.rotations))
+	for _, rot := range svc.rotations {
+		roster, err := svc.getRoster(rot, at)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get roster of '%s'", rot.key)
+		}
+
+		duty, left, err := svc.getDutyMember(roster, at)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get duty member of '%s'", rot.key)
+		}
+
+		end := shiftEnd(roster, at)
+		next, _, err := svc.getDutyMember(roster, end)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get next duty member of '%s'", rot.key)
+		}
+
+		duties = append(duties, rotationDuty{
+			rotation: rot,
+			Duty:     duty,
+			Left:     left,
+			Next:     next,
+			ShiftEnd: end,
+			Matched:  isMatched[rot],
+		})
+	}
+	return duties, nil
+}
+
+// shiftEnd returns the end of the shift at the moment, which is the start of the next shift.
+func shiftEnd(roster model.RosterVersion, at time.Time) time.Time {
+	shift := roster.Duration
+	if shift < _week {
+		shift = _week
+	}
+	shift = shift / _week * _week
+
+	passed := at.Sub(roster.StartDate)
+	if passed < 0 {
+		return roster.StartDate
+	}
+	return roster.StartDate.Add((passed/shift + 1) * shift)
+}
+
+func matchedDutyMembers(duties []rotationDuty) []string {
+	ids := []string{}
+	for _, d := range duties {
+		if d.Matched {
+			ids = appendUnique(ids, d.Duty...)
+		}
+	}
+	return ids
+}
+
+/*
+Duties returns the duty members of the rotations of the bot in every workspace at the moment, it's the source of the duty gauge.
+Workspaces whose duties can't be read are skipped.
+*/
+func (svc *SlackBot) Duties() []metrics.Duty {
+	bots, err := svc.installedWorkspaces()
+	if err != nil {
+		svc.l.Errorf("list workspaces failed, err: %+v", err)
+	}
+
+	now := time.Now()
+	result := []metrics.Duty{}
+	for _, bot := range bots {
+		duties, err := bot.rotationDuties(nil, now)
+		if err != nil {
+			svc.l.Errorf("get rotation duties of workspace '%s' failed, err: %+v", model.TeamFrom(bot.ctx), err)
+			continue
+		}
+
+		for _, d := range duties {
+			result = append(result, metrics.Duty{
+				Bot:      svc.Name,
+				Team:     model.TeamFrom(bot.ctx),
+				Rotation: d.rotation.Name,
+				Members:  d.Duty,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/slack"
+	"bitopi/internal/slack/slacktest"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func settingSubmission(bot SlackBot, user, date string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "view_submission",
+		"team": map[string]interface{}{"id": slacktest.TeamID},
+		"user": map[string]interface{}{"id": user},
+		"view": map[string]interface{}{
+			"callback_id":      _callbackSetting,
+			"private_metadata": bot.actionValue("maid", _viewRoutePrefix+_callbackSetting, 0, nil),
+			"state": map[string]interface{}{"values": map[string]interface{}{
+				_inputUsers: map[string]interface{}{_inputUsers: map[string]interface{}{"selected_users": []interface{}{_testAsker}}},
+				_inputDate:  map[string]interface{}{_inputDate: map[string]interface{}{"selected_date": date}},
+				_inputReply: map[string]interface{}{_inputReply: map[string]interface{}{"value": "on duty"}},
+			}},
+		},
+	}
+}
+
+func TestSettingSubmission(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+	interaction := NewInteraction(bot)
+
+	/* users who aren't admins can't save the setting */
+	res, ok := interaction.interact(settingSubmission(bot, _testAsker, "2024-01-01")).(viewErrors)
+	if !ok || len(res.Errors[_inputUsers]) == 0 {
+		t.Fatalf("expect the submission of a user rejected, got %+v", res)
+	}
+
+	if err := repo.AddAdmin(ctx, model.Admin{Service: "maid", UserID: _testDuty}); err != nil {
+		t.Fatalf("add admin: %+v", err)
+	}
+
+	/* invalid inputs keep the view open */
+	res, ok = interaction.interact(settingSubmission(bot, _testDuty, "2024-13-01")).(viewErrors)
+	if !ok || len(res.Errors[_inputDate]) == 0 {
+		t.Fatalf("expect the invalid date reported, got %+v", res)
+	}
+
+	if _, ok := interaction.interact(settingSubmission(bot, _testDuty, "2024-01-01")).(viewErrors); ok {
+		t.Fatal("expect the submission of an admin saved")
+	}
+	members, err := repo.ListMembers(ctx, "maid")
+	if err != nil {
+		t.Fatalf("list members: %+v", err)
+	}
+	if len(members) != 1 || members[0].UserID != _testAsker {
+		t.Fatalf("expect the roster saved, got %+v", members)
+	}
+
+	if err := bot.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+}
+
+func TestHomeSettingButtonForAdmins(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	ctx := context.Background()
+
+	if err := repo.AddAdmin(ctx, model.Admin{Service: "maid", UserID: _testAsker}); err != nil {
+		t.Fatalf("add admin: %+v", err)
+	}
+	if err := repo.SetSubscriber(ctx, model.Subscriber{UserID: _testDuty}); err != nil {
+		t.Fatalf("set subscriber: %+v", err)
+	}
+	if err := bot.publishHomeView(); err != nil {
+		t.Fatalf("publish home view: %+v", err)
+	}
+
+	hasSetting := map[string]bool{}
+	for _, call := range srv.Calls(slack.MethodViewsPublish) {
+		view, _ := json.Marshal(call.Params["view"])
+		hasSetting[call.String("user_id")] = strings.Contains(string(view), `"action_id":"`+_actionHomeSet+`"`)
+	}
+	if len(hasSetting) != 2 || !hasSetting[_testAsker] || hasSetting[_testDuty] {
+		t.Fatalf("expect the setting button only in the home of the admin, got %+v", hasSetting)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bitopi/internal/i18n"
+	"bitopi/internal/model"
+	"bitopi/internal/slack"
+	"context"
+	"encoding/json"
+)
+
+const (
+	_responseTypeEphemeral = "ephemeral"
+)
+
+type socketCommand struct {
+	Command string `json:"command"`
+	UserID  string `json:"user_id"`
+	TeamID  string `json:"team_id"`
+}
+
+/*
+socketCommandResponse answers a slash command with the ack of its envelope.
+
+https://api.slack.com/interactivity/slash-commands#responding_to_commands
+*/
+type socketCommandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+/*
+RunSocketMode receives events and interactions over Socket Mode with the app-level token,
+and feeds them to the same handlers as the HTTP routes. It reconnects until ctx is done.
+*/
+func (svc *SlackBot) RunSocketMode(ctx context.Context) error {
+	interaction := NewInteraction(*svc)
+	client := newSlackClient(svc.AppToken, svc.client.BaseURL())
+	socket := slack.NewSocketModeClient(client, func(ctx context.Context, env slack.SocketEnvelope) interface{} {
+		return interaction.socketResponse(env)
+	}, slack.WithSocketErrorHandler(func(err error) {
+		svc.l.Warnf("socket mode of bot %s, err: %+v", svc.Name, err)
+	}), slack.WithSocketRunner(svc.goTracked))
+
+	svc.l.Infof("bot %s receives events over socket mode", svc.Name)
+	return socket.Run(ctx)
+}
+
+func (svc *SlackInteraction) socketResponse(env slack.SocketEnvelope) interface{} {
+	switch env.Type {
+	case slack.SocketTypeEventsAPI:
+		slackEventApi := model.SlackEventAPI{}
+		if err := json.Unmarshal(env.Payload, &slackEventApi); err != nil {
+			svc.l.Errorf("decode events api envelope failed, err: %+v", err)
+			return nil
+		}
+		return svc.eventResponse(slackEventApi, env.EnvelopeID)
+	case slack.SocketTypeInteractive:
+		payload := map[string]interface{}{}
+		if err := json.Unmarshal(env.Payload, &payload); err != nil {
+			svc.l.Errorf("decode interactive envelope failed, err: %+v", err)
+			return nil
+		}
+		return svc.payloadResponse(payload, env.EnvelopeID)
+	case slack.SocketTypeSlashCommands:
+		/* slash commands have no HTTP route either, the user is told instead of waiting for a timeout */
+		command := socketCommand{}
+		if err := json.Unmarshal(env.Payload, &command); err != nil {
+			svc.l.Errorf("decode slash command envelope failed, err: %+v", err)
+		}
+		svc.l.Warnf("reject slash command '%s' of user %s", command.Command, command.UserID)
+		bot := svc.workspace(command.TeamID)
+		return socketCommandResponse{
+			ResponseType: _responseTypeEphemeral,
+			Text:         i18n.T(bot.userLocale(command.UserID), i18n.MsgSlashCommandUnsupported, svc.Name),
+		}
+	default:
+		svc.l.Warnf("unsupported socket mode envelope: %s", env.Type)
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bitopi/internal/slack"
+	"bitopi/internal/slack/slacktest"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it's true, the test fails after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func findAck(srv *slacktest.Server, envelopeID string) (slacktest.SocketAck, bool) {
+	for _, ack := range srv.Acks() {
+		if ack.EnvelopeID == envelopeID {
+			return ack, true
+		}
+	}
+	return slacktest.SocketAck{}, false
+}
+
+func TestSocketMode(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	bot.AppToken = "xapp-test"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- bot.RunSocketMode(ctx) }()
+	waitFor(t, "socket mode connection", func() bool { return srv.Connections() == 1 })
+
+	/* slash commands are rejected with the ack */
+	command, _ := json.Marshal(map[string]interface{}{"command": "/duty", "user_id": _testAsker, "team_id": slacktest.TeamID})
+	if err := srv.SendEnvelope(slack.SocketEnvelope{EnvelopeID: "env-command", Type: slack.SocketTypeSlashCommands, Payload: command}); err != nil {
+		t.Fatalf("send slash command: %+v", err)
+	}
+	waitFor(t, "ack of the slash command", func() bool { _, ok := findAck(srv, "env-command"); return ok })
+
+	ack, _ := findAck(srv, "env-command")
+	res := socketCommandResponse{}
+	if err := json.Unmarshal(ack.Payload, &res); err != nil {
+		t.Fatalf("decode ack payload %s: %+v", ack.Payload, err)
+	}
+	if res.ResponseType != _responseTypeEphemeral || len(res.Text) == 0 {
+		t.Fatalf("expect ephemeral rejection, got %s", ack.Payload)
+	}
+
+	/* mentions are acked before handling, shutdown waits for the handling */
+	mentionTS := "1700000100.000001"
+	mention := slacktest.MentionEvent(slacktest.TeamID, _testChannel, _testAsker, "<@"+slacktest.BotUserID+"> help", mentionTS)
+	if err := srv.SendEnvelope(slack.SocketEnvelope{EnvelopeID: "env-mention", Type: slack.SocketTypeEventsAPI, Payload: mention}); err != nil {
+		t.Fatalf("send mention: %+v", err)
+	}
+	waitFor(t, "ack of the mention", func() bool { _, ok := findAck(srv, "env-mention"); return ok })
+
+	cancel()
+	if err := <-stopped; err != context.Canceled {
+		t.Fatalf("expect socket mode stopped by ctx, got %+v", err)
+	}
+	if err := bot.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %+v", err)
+	}
+
+	if _, err := repo.FindMentionRecord(context.Background(), "maid", _testChannel, mentionTS); err != nil {
+		t.Fatalf("find mention record: %+v", err)
+	}
+}
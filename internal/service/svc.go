@@ -1,8 +1,14 @@
 package service
 
 import (
+	"bitopi/internal/actioncodec"
 	"bitopi/internal/domain"
+	"bitopi/internal/i18n"
+	"bitopi/internal/logging"
+	"bitopi/internal/platform"
 	"bitopi/internal/repository"
+	"bitopi/internal/slack"
+	"bitopi/internal/webhook"
 	"context"
 
 	"github.com/spf13/viper"
@@ -10,10 +16,18 @@ import (
 )
 
 type Service struct {
-	repo     domain.Repository
-	l        logs.Logger
-	ctx      context.Context
-	logLevel uint8
+	repo         domain.Repository
+	client       *slack.Client
+	chat         platform.Platform /* platform of the mentions, the Slack workspace of client by default */
+	codec        actioncodec.Codec
+	auth         *authCache
+	locales      *localeCache
+	locale       i18n.Locale /* default locale of the bot */
+	hooks        *webhook.Sender
+	jobs         *jobTracker       /* background work of every bot */
+	botRotations *rotationRegistry /* rotations of every bot */
+	l            logs.Logger
+	ctx          context.Context
 }
 
 func New(ctx context.Context) (Service, error) {
@@ -21,11 +35,30 @@ func New(ctx context.Context) (Service, error) {
 	if err != nil {
 		return Service{}, err
 	}
-	logLevel := uint8(viper.GetUint16("log.level"))
+	return NewWithRepo(ctx, repo), nil
+}
+
+// NewWithRepo creates the service with the repository, e.g. the in-memory repository for testing.
+func NewWithRepo(ctx context.Context, repo domain.Repository) Service {
+	logging.SetDefaultLevel(logging.ConfigLevel(viper.GetUint16("log.level")))
 	return Service{
-		repo:     repo,
-		l:        logs.New(logs.LevelInfo),
-		ctx:      ctx,
-		logLevel: logLevel,
-	}, nil
+		repo:   repo,
+		locale: i18n.DefaultLocale,
+		hooks: webhook.NewSender(nil, webhook.Option{
+			MaxAttempts: viper.GetInt("webhook.max_attempts"),
+			Backoff:     viper.GetDuration("webhook.backoff"),
+			Timeout:     viper.GetDuration("webhook.timeout"),
+		}),
+		jobs:         newJobTracker(),
+		botRotations: newRotationRegistry(),
+		l:            logging.New(""),
+		ctx:          ctx,
+	}
+}
+
+/*
+withLogFields scopes the logger of the copy of the service to the request, and attaches the logger into its context.
+*/
+func (svc *Service) withLogFields(fields map[string]interface{}) {
+	svc.ctx, svc.l = svc.l.WithFields(fields).Attach(svc.ctx)
 }
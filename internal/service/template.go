@@ -0,0 +1,95 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/msgtemplate"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	_shiftEndLayout = "2006/01/02"
+)
+
+/*
+renderMessage renders the reply template with the duty members, event is nil outside mentions, e.g. home view.
+
+Legacy positional templates are converted by the placeholders of the bot before rendering.
+*/
+func (svc *SlackBot) renderMessage(text string, multiMember bool, duties []rotationDuty, event *model.Event) string {
+	if msgtemplate.IsLegacy(text) {
+		text = msgtemplate.FromLegacy(text, svc.legacyPlaceholders(multiMember)...)
+	}
+
+	data := templateData(duties, event)
+	out, err := msgtemplate.Render(text, data)
+	if err != nil {
+		svc.l.Errorf("render message template failed, err: %+v", err)
+		return data.Duty
+	}
+	return out
+}
+
+/*
+legacyPlaceholders returns placeholders of '%s' in order.
+
+Single rotation bots have the duty members and the others when multiMember is set,
+multiple rotation bots have the duty members of every rotation when multiMember is set.
+*/
+func (svc *SlackBot) legacyPlaceholders(multiMember bool) []string {
+	if !multiMember {
+		return []string{"{{.Duty}}"}
+	}
+
+	if len(svc.rotations) == 1 {
+		return []string{"{{.Duty}}", "{{.Backup}}"}
+	}
+
+	placeholders := make([]string, 0, len(svc.rotations))
+	for _, rot := range svc.rotations {
+		placeholders = append(placeholders, "{{.Rotation "+strconv.Quote(rot.Name)+"}}")
+	}
+	return placeholders
+}
+
+func templateData(duties []rotationDuty, event *model.Event) msgtemplate.Data {
+	duty, backup, next := []string{}, []string{}, []string{}
+	rotations := map[string]string{}
+	end := time.Time{}
+	for _, d := range duties {
+		if !d.Matched {
+			continue
+		}
+
+		duty = appendUnique(duty, d.Duty...)
+		backup = appendUnique(backup, d.Left...)
+		next = appendUnique(next, d.Next...)
+
+		tags := strings.Join(userTags(d.Duty), " ")
+		rotations[d.rotation.Name] = tags
+		rotations[d.rotation.Title] = tags
+
+		if end.IsZero() || d.ShiftEnd.Before(end) {
+			end = d.ShiftEnd
+		}
+	}
+
+	data := msgtemplate.Data{
+		Duty:      strings.Join(userTags(duty), " "),
+		Backup:    strings.Join(userTags(backup), " "),
+		NextDuty:  strings.Join(userTags(next), " "),
+		Rotations: rotations,
+	}
+
+	if !end.IsZero() {
+		data.ShiftEnd = end.Format(_shiftEndLayout)
+	}
+
+	if event != nil {
+		data.Requester = "<@" + event.User + ">"
+		data.Channel = "<#" + event.Channel + ">"
+	}
+
+	return data
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"bitopi/internal/logging"
+	"bitopi/internal/model"
+	"bitopi/internal/webhook"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	_idPathKey            = "id"
+	_webhookSecretBytes   = 32
+	_defaultDeliveryLimit = 50
+)
+
+/*
+emit queues the delivery of the event to every webhook of the service subscribing it, in the workspace of the service context.
+The deliveries are attempted by the worker of the outbox.
+*/
+func (svc *SlackBot) emit(service, event string, data interface{}) {
+	hooks, err := svc.repo.ListWebhooks(svc.ctx, service)
+	if err != nil {
+		svc.l.Errorf("list webhooks failed, err: %+v", err)
+		return
+	}
+
+	var body []byte
+	for _, hook := range hooks {
+		if !hook.Subscribes(event) {
+			continue
+		}
+
+		if body == nil {
+			body, err = json.Marshal(model.WebhookPayload{
+				Event:     event,
+				Service:   service,
+				TeamID:    model.TeamFrom(svc.ctx),
+				CreatedAt: time.Now(),
+				Data:      data,
+			})
+			if err != nil {
+				svc.l.Errorf("marshal webhook payload failed, err: %+v", err)
+				return
+			}
+		}
+
+		delivery := model.WebhookDelivery{
+			Service:   service,
+			WebhookID: hook.ID,
+			Event:     event,
+			Payload:   string(body),
+			Status:    model.WebhookDeliveryPending,
+		}
+		if _, err := svc.repo.CreateWebhookDelivery(svc.ctx, delivery); err != nil {
+			svc.l.Errorf("create webhook delivery failed, err: %+v", err)
+			continue
+		}
+	}
+	svc.wakeOutbox()
+}
+
+/*
+runWebhookDeliveries attempts the due deliveries of the bot in their workspaces,
+failed attempts are attempted again by later runs with backoff.
+*/
+func (svc *SlackBot) runWebhookDeliveries(ctx context.Context) {
+	deliveries, err := svc.repo.ListDueWebhookDeliveries(svc.ctx, svc.Name, time.Now(), _outboxBatch)
+	if err != nil {
+		svc.l.Errorf("list due webhook deliveries failed, err: %+v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		claimed, err := svc.repo.ClaimWebhookDelivery(svc.ctx, delivery, time.Now().Add(_outboxLease))
+		if err != nil {
+			svc.l.Errorf("claim webhook delivery %d failed, err: %+v", delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		bot := svc.workspace(delivery.TeamID)
+		bot.withLogFields(map[string]interface{}{logging.FieldTeam: delivery.TeamID})
+		bot.deliver(ctx, delivery)
+	}
+}
+
+/*
+deliver makes the next attempt of the delivery, which is recorded in the delivery.
+The attempt is cancelled when ctx is done, e.g. by shutdown, and it's attempted again on the next start without counting it.
+*/
+func (svc *Service) deliver(ctx context.Context, delivery model.WebhookDelivery) {
+	hook, err := svc.repo.GetWebhook(svc.ctx, delivery.Service, delivery.WebhookID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		/* the lease expires and the delivery is attempted again */
+		svc.l.Errorf("get webhook %d failed, err: %+v", delivery.WebhookID, err)
+		return
+	}
+
+	now := time.Now()
+	delivery.NextAttemptAt = now
+	switch {
+	case err != nil:
+		delivery.Status, delivery.Error = model.WebhookDeliveryFailed, "webhook was deleted"
+	case !hook.Active:
+		delivery.Status, delivery.Error = model.WebhookDeliveryFailed, "webhook is inactive"
+	default:
+		a := svc.hooks.Try(ctx, webhook.Request{
+			URL:      hook.URL,
+			Secret:   hook.Secret,
+			Event:    delivery.Event,
+			Delivery: strconv.FormatUint(delivery.ID, 10),
+			Body:     []byte(delivery.Payload),
+		}, delivery.Attempts+1)
+		if ctx.Err() != nil {
+			svc.l.Infof("delivery %d of webhook %d was interrupted", delivery.ID, hook.ID)
+			break
+		}
+
+		delivery.Attempts, delivery.ResponseStatus, delivery.DeliveredAt = a.Number, a.StatusCode, &now
+		switch {
+		case a.Err == nil:
+			delivery.Status, delivery.Error = model.WebhookDeliverySucceeded, ""
+		case a.Retry > 0:
+			delivery.Error, delivery.NextAttemptAt = a.Err.Error(), now.Add(a.Retry)
+			svc.l.Warnf("deliver webhook %d of event %s, attempt %d failed, err: %+v", hook.ID, delivery.Event, a.Number, a.Err)
+		default:
+			delivery.Status, delivery.Error = model.WebhookDeliveryFailed, a.Err.Error()
+			svc.l.Errorf("deliver webhook %d of event %s failed, gave up after %d attempts, err: %+v", hook.ID, delivery.Event, a.Number, a.Err)
+		}
+	}
+
+	if err := svc.repo.UpdateWebhookDelivery(svc.ctx, delivery); err != nil {
+		svc.l.Errorf("update webhook delivery failed, err: %+v", err)
+	}
+}
+
+func mentionWebhookData(record model.MentionRecord, status string) model.MentionWebhookData {
+	return model.MentionWebhookData{
+		MentionID:   record.ID,
+		Channel:     record.Channel,
+		Timestamp:   record.Timestamp,
+		DutyMembers: record.DutyMemberList(),
+		Status:      status,
+	}
+}
+
+func (svc *Service) ListWebhooks(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	hooks, err := svc.repo.ListWebhooks(svc.requestContext(c), category)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "list webhooks error", err)
+	}
+
+	return DataResponse(c, hooks)
+}
+
+func (svc *Service) CreateWebhook(c echo.Context) error {
+	return svc.saveWebhook(c, 0)
+}
+
+func (svc *Service) UpdateWebhook(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param(_idPathKey), 10, 64)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid webhook id", err)
+	}
+	return svc.saveWebhook(c, id)
+}
+
+/*
+saveWebhook creates the webhook when id is 0, the secret is only responded when it's generated.
+*/
+func (svc *Service) saveWebhook(c echo.Context, id uint64) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	req := model.SaveWebhookRequest{}
+	if err := c.Bind(&req); err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "request parameters mismatch", err)
+	}
+
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid url '%s'", req.URL))
+	}
+
+	for _, e := range req.Events {
+		if !isWebhookEvent(e) {
+			return ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("invalid event '%s'", e))
+		}
+	}
+
+	ctx := svc.requestContext(c)
+	hook := model.Webhook{ID: id, Service: category, URL: req.URL, Secret: req.Secret, Active: true}
+	hook.SetEventList(req.Events)
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	if id != 0 && len(hook.Secret) == 0 {
+		current, err := svc.repo.GetWebhook(ctx, category, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("webhook %d not found", id))
+		}
+		if err != nil {
+			return ErrorResponse(c, http.StatusInternalServerError, "get webhook error", err)
+		}
+		hook.Secret = current.Secret
+	}
+
+	generated := ""
+	if len(hook.Secret) == 0 {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return ErrorResponse(c, http.StatusInternalServerError, "generate secret error", err)
+		}
+		hook.Secret, generated = secret, secret
+	}
+
+	if err := svc.repo.Tx(ctx, func(txCtx context.Context) error {
+		var err error
+		hook.ID, err = svc.repo.SaveWebhook(txCtx, hook)
+		return err
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("webhook %d not found", id))
+		}
+		return ErrorResponse(c, http.StatusInternalServerError, "save webhook error", err)
+	}
+
+	saved, err := svc.repo.GetWebhook(ctx, category, hook.ID)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "get webhook error", err)
+	}
+
+	return DataResponse(c, model.SaveWebhookResponse{Webhook: saved, Secret: generated})
+}
+
+func (svc *Service) DeleteWebhook(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	id, err := strconv.ParseUint(c.Param(_idPathKey), 10, 64)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid webhook id", err)
+	}
+
+	if err := svc.repo.Tx(svc.requestContext(c), func(txCtx context.Context) error {
+		return svc.repo.DeleteWebhook(txCtx, category, id)
+	}); err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "delete webhook error", err)
+	}
+
+	return svc.ListWebhooks(c)
+}
+
+func (svc *Service) ListWebhookDeliveries(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	id, err := strconv.ParseUint(c.Param(_idPathKey), 10, 64)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid webhook id", err)
+	}
+
+	limit, err := listLimit(c, _defaultDeliveryLimit)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid limit", err)
+	}
+
+	deliveries, err := svc.repo.ListWebhookDeliveries(svc.requestContext(c), category, id, limit)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "list webhook deliveries error", err)
+	}
+
+	return DataResponse(c, deliveries)
+}
+
+/*
+RedeliverWebhook queues the payload of the delivery again as a new delivery, and responds 202 with the pending delivery.
+*/
+func (svc *Service) RedeliverWebhook(c echo.Context) error {
+	category := c.Param(_botServicePathKey)
+	if !_validCategory[category] {
+		return ErrorResponse(c, http.StatusBadGateway, fmt.Sprintf("bot '%s' not found", category))
+	}
+
+	id, err := strconv.ParseUint(c.Param(_idPathKey), 10, 64)
+	if err != nil {
+		return ErrorResponse(c, http.StatusBadRequest, "invalid delivery id", err)
+	}
+
+	ctx := svc.requestContext(c)
+	prev, err := svc.repo.GetWebhookDelivery(ctx, category, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("delivery %d not found", id))
+	}
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "get webhook delivery error", err)
+	}
+
+	hook, err := svc.repo.GetWebhook(ctx, category, prev.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("webhook %d not found", prev.WebhookID))
+	}
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "get webhook error", err)
+	}
+
+	delivery := model.WebhookDelivery{
+		Service:      category,
+		WebhookID:    hook.ID,
+		Event:        prev.Event,
+		Payload:      prev.Payload,
+		Status:       model.WebhookDeliveryPending,
+		RedeliveryOf: prev.ID,
+	}
+	delivery.ID, err = svc.repo.CreateWebhookDelivery(ctx, delivery)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "create webhook delivery error", err)
+	}
+
+	result, err := svc.repo.GetWebhookDelivery(ctx, category, delivery.ID)
+	if err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "get webhook delivery error", err)
+	}
+
+	return AcceptedResponse(c, result)
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range model.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, _webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
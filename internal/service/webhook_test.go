@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/slack/slacktest"
+	"bitopi/internal/webhook"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, repo := newTestBot(t, srv)
+	bot.hooks = webhook.NewSender(nil, webhook.Option{Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	ctx := context.Background()
+
+	/* the receiver fails the first attempt */
+	var received int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&received, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	var hookID uint64
+	if err := repo.Tx(ctx, func(txCtx context.Context) error {
+		var err error
+		hookID, err = repo.SaveWebhook(txCtx, model.Webhook{Service: "maid", URL: receiver.URL, Secret: "secret", Active: true})
+		return err
+	}); err != nil {
+		t.Fatalf("save webhook: %+v", err)
+	}
+
+	bot.emit("maid", model.WebhookEventMentionOpened, model.MentionWebhookData{MentionID: 1})
+	if n := atomic.LoadInt32(&received); n != 0 {
+		t.Fatalf("expect the delivery queued, got %d requests", n)
+	}
+
+	bot.runWebhookDeliveries(ctx)
+	deliveries, err := repo.ListWebhookDeliveries(ctx, "maid", hookID, 0)
+	if err != nil {
+		t.Fatalf("list webhook deliveries: %+v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != model.WebhookDeliveryPending || deliveries[0].Attempts != 1 {
+		t.Fatalf("expect the failed attempt rescheduled, got %+v", deliveries)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	bot.runWebhookDeliveries(ctx)
+	deliveries, err = repo.ListWebhookDeliveries(ctx, "maid", hookID, 0)
+	if err != nil {
+		t.Fatalf("list webhook deliveries: %+v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != model.WebhookDeliverySucceeded || deliveries[0].Attempts != 2 {
+		t.Fatalf("expect the delivery succeeded by the retry, got %+v", deliveries)
+	}
+
+	/* done deliveries aren't attempted again */
+	bot.runWebhookDeliveries(ctx)
+	if n := atomic.LoadInt32(&received); n != 2 {
+		t.Fatalf("expect 2 requests, got %d", n)
+	}
+}
+
+func TestListLimit(t *testing.T) {
+	testCases := []struct {
+		query string
+		limit int
+		valid bool
+	}{
+		{"", _defaultDeliveryLimit, true},
+		{"limit=10", 10, true},
+		{"limit=100000", _maxListLimit, true},
+		{"limit=0", 0, false},
+		{"limit=-1", 0, false},
+		{"limit=ten", 0, false},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/maid/webhook/1/delivery?"+tc.query, nil)
+		limit, err := listLimit(echo.New().NewContext(req, httptest.NewRecorder()), _defaultDeliveryLimit)
+		if (err == nil) != tc.valid || limit != tc.limit {
+			t.Fatalf("query '%s': expect limit %d valid %v, got %d %v", tc.query, tc.limit, tc.valid, limit, err)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/platform"
+	"bitopi/internal/slack"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	_workspaceCacheTTL = 10 * time.Minute
+
+	_actionOAuthState  = "oauth.install"
+	_oauthStateTTL     = 10 * time.Minute
+	_oauthNonceCookie  = "bitopi_oauth_nonce"
+	_oauthNonceArg     = "nonce"
+	_oauthNonceBytes   = 16
+	_defaultOAuthScope = "app_mentions:read,channels:history,groups:history,chat:write,im:history,im:write,reactions:write,users:read"
+)
+
+type workspaceEntry struct {
+	bot       SlackBot
+	installed bool
+	checkedAt time.Time
+}
+
+type workspaceClient struct {
+	token  string
+	client *slack.Client
+}
+
+/*
+workspaceCache caches the bots of workspaces by team ID, it's shared by the copies of the bot.
+Workspaces without installation are cached too, so that events of the static token workspace don't query the repository.
+
+Clients are kept apart from the entries and outlive their expiry, so the rate limits of a workspace aren't reset by refreshing it.
+*/
+type workspaceCache struct {
+	mu    sync.Mutex
+	teams map[string]workspaceEntry
+
+	clientsMu sync.Mutex
+	clients   map[string]workspaceClient
+}
+
+func newWorkspaceCache() *workspaceCache {
+	return &workspaceCache{
+		teams:   map[string]workspaceEntry{},
+		clients: map[string]workspaceClient{},
+	}
+}
+
+// client returns the client of the workspace, a new one is created when the token of the workspace changed.
+func (c *workspaceCache) client(teamID, token, baseURL string) *slack.Client {
+	c.clientsMu.Lock()
+	defer c.clientsMu.Unlock()
+
+	if wc, ok := c.clients[teamID]; ok && wc.token == token {
+		return wc.client
+	}
+
+	client := newSlackClient(token, baseURL)
+	c.clients[teamID] = workspaceClient{token: token, client: client}
+	return client
+}
+
+func (c *workspaceCache) forget(teamID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.teams, teamID)
+}
+
+/*
+workspace returns the bot of the workspace which the request came from.
+
+The bot itself serves the workspace of the static token and workspaces without installation, requests of the latter are rejected by requestWorkspace.
+*/
+func (svc *SlackBot) workspace(teamID string) SlackBot {
+	if len(teamID) == 0 || svc.workspaces == nil || len(model.TeamFrom(svc.ctx)) != 0 {
+		return *svc
+	}
+
+	svc.workspaces.mu.Lock()
+	defer svc.workspaces.mu.Unlock()
+
+	entry, ok := svc.workspaces.teams[teamID]
+	if ok && time.Since(entry.checkedAt) < _workspaceCacheTTL {
+		if entry.installed {
+			return entry.bot
+		}
+		return *svc
+	}
+
+	entry = workspaceEntry{checkedAt: time.Now()}
+	ws, err := svc.repo.GetWorkspace(svc.ctx, svc.Name, teamID)
+	if err == nil {
+		entry.bot, entry.installed = svc.forWorkspace(ws), true
+	} else {
+		svc.l.Debugf("get workspace %s, err: %+v", teamID, err)
+	}
+	svc.workspaces.teams[teamID] = entry
+
+	if entry.installed {
+		return entry.bot
+	}
+	return *svc
+}
+
+/*
+requestWorkspace returns the bot of the workspace which the event or the interaction came from.
+
+It returns false when the workspace didn't install the bot, the bot itself only serves the workspace of its static token.
+*/
+func (svc *SlackBot) requestWorkspace(teamID string) (SlackBot, bool) {
+	bot := svc.workspace(teamID)
+	if len(teamID) == 0 || svc.Platform != platform.Slack || len(model.TeamFrom(bot.ctx)) != 0 {
+		return bot, true
+	}
+
+	if len(svc.Token) == 0 {
+		return bot, false
+	}
+
+	auth, err := svc.authTest()
+	if err != nil {
+		svc.l.Warnf("auth test of the static token failed, err: %+v", err)
+		return bot, false
+	}
+	return bot, auth.TeamID == teamID
+}
+
+/*
+forWorkspace copies the bot with the token of the workspace, repository data of the copy is scoped by the team.
+*/
+func (svc *SlackBot) forWorkspace(ws model.Workspace) SlackBot {
+	bot := *svc
+	bot.ctx = model.WithTeam(svc.ctx, ws.TeamID)
+	bot.client = svc.workspaces.client(ws.TeamID, ws.BotToken, svc.client.BaseURL())
+	bot.chat = platform.NewSlack(bot.client)
+	bot.auth = newAuthCache()
+	bot.locales = newLocaleCache()
+	return bot
+}
+
+/*
+installedWorkspaces returns the bots of every workspace, including the static token workspace when the token is set,
+bots of other platforms only serve the workspace of their config.
+*/
+func (svc *SlackBot) installedWorkspaces() ([]SlackBot, error) {
+	bots := []SlackBot{}
+	if len(svc.Token) != 0 || svc.Platform != platform.Slack {
+		bots = append(bots, *svc)
+	}
+
+	workspaces, err := svc.repo.ListWorkspaces(svc.ctx, svc.Name)
+	if err != nil {
+		return bots, err
+	}
+
+	for _, ws := range workspaces {
+		bots = append(bots, svc.workspace(ws.TeamID))
+	}
+	return bots, nil
+}
+
+/*
+InstallHandler redirects to the Slack authorize page.
+
+The state is signed and expires in minutes, and carries the nonce of the cookie of the browser,
+so the redirect is only accepted from the browser which started the installation.
+*/
+func (svc *SlackBot) InstallHandler(c echo.Context) error {
+	if len(svc.ClientID) == 0 {
+		return ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("oauth of bot '%s' isn't configured", svc.Name))
+	}
+
+	buf := make([]byte, _oauthNonceBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return ErrorResponse(c, http.StatusInternalServerError, "generate oauth nonce error", err)
+	}
+	nonce := hex.EncodeToString(buf)
+
+	state := svc.actionValue(svc.Name, _actionOAuthState, 0, map[string]string{_oauthNonceArg: nonce})
+	if len(state) == 0 {
+		return ErrorResponse(c, http.StatusInternalServerError, "encode oauth state error")
+	}
+
+	c.SetCookie(svc.oauthNonceCookie(c, nonce, int(_oauthStateTTL/time.Second)))
+	return c.Redirect(http.StatusFound, slack.AuthorizeURL(svc.SlackAuthorizeURL, svc.ClientID, svc.OAuthScope, svc.RedirectURL, state))
+}
+
+/*
+OAuthRedirectHandler exchanges the code for the bot token, and stores the token of the workspace.
+*/
+func (svc *SlackBot) OAuthRedirectHandler(c echo.Context) error {
+	if len(svc.ClientID) == 0 {
+		return ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("oauth of bot '%s' isn't configured", svc.Name))
+	}
+
+	if reason := c.QueryParam("error"); len(reason) != 0 {
+		return ErrorResponse(c, http.StatusForbidden, "installation was cancelled", errors.New(reason))
+	}
+
+	nonce := ""
+	if cookie, err := c.Cookie(_oauthNonceCookie); err == nil {
+		nonce = cookie.Value
+	}
+	c.SetCookie(svc.oauthNonceCookie(c, "", -1))
+
+	if err := svc.verifyOAuthState(c.QueryParam("state"), nonce); err != nil {
+		svc.l.Warnf("reject oauth redirect, err: %+v", err)
+		return ErrorResponse(c, http.StatusForbidden, "invalid state", err)
+	}
+
+	/* oauth.v2.access is authorized by the client credentials, the bot token isn't needed */
+	client := newSlackClient("", svc.client.BaseURL())
+	res, err := client.OAuthV2Access(svc.ctx, slack.OAuthV2AccessRequest{
+		ClientID:     svc.ClientID,
+		ClientSecret: svc.ClientSecret,
+		Code:         c.QueryParam("code"),
+		RedirectURI:  svc.RedirectURL,
+	})
+	if err != nil {
+		svc.l.Errorf("exchange oauth code failed, err: %+v", err)
+		return ErrorResponse(c, http.StatusBadRequest, "exchange oauth code error", err)
+	}
+
+	if len(res.AccessToken) == 0 || len(res.Team.ID) == 0 {
+		return ErrorResponse(c, http.StatusBadRequest, "empty bot token or team of the installation")
+	}
+
+	ws := model.Workspace{
+		Service:     svc.Name,
+		TeamID:      res.Team.ID,
+		TeamName:    res.Team.Name,
+		BotToken:    res.AccessToken,
+		BotUserID:   res.BotUserID,
+		Scope:       res.Scope,
+		InstalledBy: res.AuthedUser.ID,
+	}
+
+	ctx := model.WithTeam(model.WithActor(svc.ctx, model.Actor{Name: res.AuthedUser.ID, Source: model.AuditSourceOAuth}), ws.TeamID)
+	if err := svc.repo.SaveWorkspace(ctx, ws); err != nil {
+		svc.l.Errorf("save workspace failed, err: %+v", err)
+		return ErrorResponse(c, http.StatusInternalServerError, "save workspace error", err)
+	}
+	svc.workspaces.forget(ws.TeamID)
+	svc.l.Infof("installed bot %s in workspace %s (%s)", svc.Name, ws.TeamName, ws.TeamID)
+
+	if saved, err := svc.repo.GetWorkspace(svc.ctx, svc.Name, ws.TeamID); err == nil {
+		ws = saved
+	}
+	return DataResponse(c, ws, fmt.Sprintf("installed bot '%s' in workspace '%s'", svc.Name, ws.TeamName))
+}
+
+// oauthNonceCookie builds the cookie of the nonce of the installation, negative maxAge removes it.
+func (svc *SlackBot) oauthNonceCookie(c echo.Context, nonce string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     _oauthNonceCookie,
+		Value:    nonce,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (svc *SlackBot) verifyOAuthState(state, nonce string) error {
+	data, err := svc.codec.Decode(state)
+	if err != nil {
+		return err
+	}
+
+	if len(nonce) == 0 || subtle.ConstantTimeCompare([]byte(nonce), []byte(data.Arg(_oauthNonceArg))) != 1 {
+		return errors.New("mismatch nonce of the browser")
+	}
+
+	if data.Service != svc.Name {
+		return errors.Errorf("mismatch service: %s", data.Service)
+	}
+
+	if data.Action != _actionOAuthState {
+		return errors.Errorf("mismatch action: %s", data.Action)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"bitopi/internal/model"
+	"bitopi/internal/slack/slacktest"
+	"testing"
+)
+
+func TestWorkspaceClientOutlivesRefresh(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	bot, _ := newTestBot(t, srv)
+
+	/* refreshing the workspace keeps the client and its rate limits */
+	first := bot.forWorkspace(model.Workspace{TeamID: "T1", BotToken: "xoxb-1"})
+	refreshed := bot.forWorkspace(model.Workspace{TeamID: "T1", BotToken: "xoxb-1"})
+	if first.client != refreshed.client {
+		t.Fatal("expect the client reused by the refresh")
+	}
+
+	reinstalled := bot.forWorkspace(model.Workspace{TeamID: "T1", BotToken: "xoxb-2"})
+	if reinstalled.client == first.client {
+		t.Fatal("expect a new client of the new token")
+	}
+	if other := bot.forWorkspace(model.Workspace{TeamID: "T2", BotToken: "xoxb-2"}); other.client == reinstalled.client {
+		t.Fatal("expect workspaces don't share clients")
+	}
+}
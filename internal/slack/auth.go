@@ -0,0 +1,29 @@
+package slack
+
+import (
+	"context"
+	"net/url"
+)
+
+const (
+	MethodAuthTest = "auth.test"
+)
+
+type AuthTestResponse struct {
+	Response
+	URL    string `json:"url"`
+	Team   string `json:"team"`
+	User   string `json:"user"`
+	TeamID string `json:"team_id"`
+	UserID string `json:"user_id"`
+	BotID  string `json:"bot_id"`
+}
+
+// AuthTest checks the token and returns the identity of it.
+func (c *Client) AuthTest(ctx context.Context) (AuthTestResponse, error) {
+	res := AuthTestResponse{}
+	if err := c.postForm(ctx, MethodAuthTest, url.Values{}, &res); err != nil {
+		return AuthTestResponse{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,150 @@
+package blocks
+
+// Block is a layout block, e.g. section, context, actions, input, header and divider.
+type Block interface {
+	block()
+}
+
+// ContextElement is an element of context blocks, which is text or image.
+type ContextElement interface {
+	contextElement()
+}
+
+type Section struct {
+	BlockID   string  `json:"block_id,omitempty"`
+	Text      *Text   `json:"text,omitempty"`
+	Fields    []*Text `json:"fields,omitempty"`
+	Accessory Element `json:"accessory,omitempty"`
+}
+
+func (*Section) block() {}
+
+func NewSection(text *Text) *Section {
+	return &Section{Text: text}
+}
+
+func (s *Section) WithBlockID(id string) *Section {
+	s.BlockID = id
+	return s
+}
+
+func (s *Section) WithFields(fields ...*Text) *Section {
+	s.Fields = append(s.Fields, fields...)
+	return s
+}
+
+func (s *Section) WithAccessory(e Element) *Section {
+	s.Accessory = e
+	return s
+}
+
+func (s Section) MarshalJSON() ([]byte, error) {
+	type alias Section
+	return marshalWithType("section", alias(s))
+}
+
+type Context struct {
+	BlockID  string           `json:"block_id,omitempty"`
+	Elements []ContextElement `json:"elements"`
+}
+
+func (*Context) block() {}
+
+func NewContext(elements ...ContextElement) *Context {
+	return &Context{Elements: elements}
+}
+
+func (c Context) MarshalJSON() ([]byte, error) {
+	type alias Context
+	return marshalWithType("context", alias(c))
+}
+
+type Actions struct {
+	BlockID  string    `json:"block_id,omitempty"`
+	Elements []Element `json:"elements"`
+}
+
+func (*Actions) block() {}
+
+func NewActions(elements ...Element) *Actions {
+	return &Actions{Elements: elements}
+}
+
+func (a *Actions) WithBlockID(id string) *Actions {
+	a.BlockID = id
+	return a
+}
+
+func (a Actions) MarshalJSON() ([]byte, error) {
+	type alias Actions
+	return marshalWithType("actions", alias(a))
+}
+
+type Input struct {
+	BlockID        string  `json:"block_id,omitempty"`
+	Label          *Text   `json:"label"`
+	Element        Element `json:"element"`
+	Hint           *Text   `json:"hint,omitempty"`
+	Optional       bool    `json:"optional,omitempty"`
+	DispatchAction bool    `json:"dispatch_action,omitempty"`
+}
+
+func (*Input) block() {}
+
+func NewInput(label string, element Element) *Input {
+	return &Input{
+		Label:   PlainText(label),
+		Element: element,
+	}
+}
+
+func (i *Input) WithBlockID(id string) *Input {
+	i.BlockID = id
+	return i
+}
+
+func (i *Input) WithHint(hint string) *Input {
+	i.Hint = PlainText(hint)
+	return i
+}
+
+func (i *Input) WithOptional(optional bool) *Input {
+	i.Optional = optional
+	return i
+}
+
+func (i Input) MarshalJSON() ([]byte, error) {
+	type alias Input
+	return marshalWithType("input", alias(i))
+}
+
+type Header struct {
+	BlockID string `json:"block_id,omitempty"`
+	Text    *Text  `json:"text"`
+}
+
+func (*Header) block() {}
+
+func NewHeader(text string) *Header {
+	return &Header{Text: PlainText(text)}
+}
+
+func (h Header) MarshalJSON() ([]byte, error) {
+	type alias Header
+	return marshalWithType("header", alias(h))
+}
+
+type Divider struct {
+	BlockID string `json:"block_id,omitempty"`
+}
+
+func (*Divider) block() {}
+
+func NewDivider() *Divider {
+	return &Divider{}
+}
+
+func (d Divider) MarshalJSON() ([]byte, error) {
+	type alias Divider
+	return marshalWithType("divider", alias(d))
+}
@@ -0,0 +1,75 @@
+package blocks
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var _update = flag.Bool("update", false, "update the golden files in testdata")
+
+/*
+assertGolden compares the indented JSON of v with testdata/<name>.json, run 'go test -update' to rewrite it.
+*/
+func assertGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal %s: %+v", name, err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".json")
+	if *_update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write %s: %+v", path, err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %+v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestDirectMessage(t *testing.T) {
+	deleteButton := NewButton("mention.delete", "Delete", "signed-delete").WithStyle(StyleDanger)
+	assertGolden(t, "direct_message", []Block{
+		NewSection(Markdown("New mention <https://fake.slack.com/archives/C1/p1700000000000001|message> from <@U1> in <#C1>")),
+		NewContext(PlainText("the deploy is stuck, \"prod\" <@U2>\nplease help")),
+		NewActions(
+			NewButton("mention.resend", "Resend", "signed-resend").WithStyle(StylePrimary),
+			deleteButton,
+			NewButton("mention.delete_and_reply", "Done", "signed-done"),
+		).WithBlockID("mention.actions"),
+	})
+}
+
+func TestHomeView(t *testing.T) {
+	clearButton := NewButton("clear.older", "Clear older", "signed-clear").
+		WithConfirm(NewConfirm("Clear older", "Clear notifications older than 7 days?", "Clear", "Cancel").WithStyle(StyleDanger))
+	assertGolden(t, "home", NewHomeView(
+		NewSection(Markdown("*On duty*\n<@U1> until 2024.01.08")),
+		NewContext(Markdown("Next: <@U2>")),
+		NewDivider(),
+		NewActions(NewButton("set", "Setting", "signed-set").WithStyle(StylePrimary), clearButton),
+	))
+}
+
+func TestForm(t *testing.T) {
+	assertGolden(t, "form", NewModal("Setting", "Save", "Cancel",
+		NewHeader("Roster"),
+		NewDivider(),
+		NewInput("Members", NewMultiUsersSelect("multi_users_select-action", "Select users").WithInitialUsers("U1", "U2")),
+		NewInput("Start date", NewDatePicker("datepicker-action", "Select a date").WithInitialDate("2024-01-01")),
+		NewInput("Reply", NewPlainTextInput("plain_text_input-action").WithMultiline(true).WithInitialValue("{{.Duty}} is on duty")),
+		NewContext(PlainText("Changes apply to the next mention")),
+	).WithPrivateMetadata("signed-state").WithCallbackID("bot.setting"))
+}
@@ -0,0 +1,146 @@
+package blocks
+
+const (
+	StyleDefault = ""
+	StylePrimary = "primary"
+	StyleDanger  = "danger"
+)
+
+// Element is an interactive element of actions, input blocks or section accessories.
+type Element interface {
+	element()
+}
+
+type Button struct {
+	Text     *Text    `json:"text"`
+	ActionID string   `json:"action_id,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Style    string   `json:"style,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Confirm  *Confirm `json:"confirm,omitempty"`
+}
+
+func (*Button) element() {}
+
+func NewButton(actionID, text, value string) *Button {
+	return &Button{
+		Text:     PlainText(text),
+		ActionID: actionID,
+		Value:    value,
+	}
+}
+
+// WithStyle sets the style, which is StyleDefault, StylePrimary or StyleDanger.
+func (b *Button) WithStyle(style string) *Button {
+	b.Style = style
+	return b
+}
+
+func (b *Button) WithConfirm(c *Confirm) *Button {
+	b.Confirm = c
+	return b
+}
+
+func (b Button) MarshalJSON() ([]byte, error) {
+	type alias Button
+	return marshalWithType("button", alias(b))
+}
+
+type DatePicker struct {
+	ActionID    string `json:"action_id,omitempty"`
+	InitialDate string `json:"initial_date,omitempty"` /* YYYY-MM-DD */
+	Placeholder *Text  `json:"placeholder,omitempty"`
+}
+
+func (*DatePicker) element() {}
+
+func NewDatePicker(actionID, placeholder string) *DatePicker {
+	return &DatePicker{
+		ActionID:    actionID,
+		Placeholder: PlainText(placeholder),
+	}
+}
+
+func (d *DatePicker) WithInitialDate(date string) *DatePicker {
+	d.InitialDate = date
+	return d
+}
+
+func (d DatePicker) MarshalJSON() ([]byte, error) {
+	type alias DatePicker
+	return marshalWithType("datepicker", alias(d))
+}
+
+type UsersSelect struct {
+	ActionID    string `json:"action_id,omitempty"`
+	InitialUser string `json:"initial_user,omitempty"`
+	Placeholder *Text  `json:"placeholder,omitempty"`
+}
+
+func (*UsersSelect) element() {}
+
+func NewUsersSelect(actionID, placeholder string) *UsersSelect {
+	return &UsersSelect{
+		ActionID:    actionID,
+		Placeholder: PlainText(placeholder),
+	}
+}
+
+func (u UsersSelect) MarshalJSON() ([]byte, error) {
+	type alias UsersSelect
+	return marshalWithType("users_select", alias(u))
+}
+
+type MultiUsersSelect struct {
+	ActionID         string   `json:"action_id,omitempty"`
+	InitialUsers     []string `json:"initial_users,omitempty"`
+	Placeholder      *Text    `json:"placeholder,omitempty"`
+	MaxSelectedItems int      `json:"max_selected_items,omitempty"`
+}
+
+func (*MultiUsersSelect) element() {}
+
+func NewMultiUsersSelect(actionID, placeholder string) *MultiUsersSelect {
+	return &MultiUsersSelect{
+		ActionID:    actionID,
+		Placeholder: PlainText(placeholder),
+	}
+}
+
+func (m *MultiUsersSelect) WithInitialUsers(users ...string) *MultiUsersSelect {
+	m.InitialUsers = append(m.InitialUsers, users...)
+	return m
+}
+
+func (m MultiUsersSelect) MarshalJSON() ([]byte, error) {
+	type alias MultiUsersSelect
+	return marshalWithType("multi_users_select", alias(m))
+}
+
+type PlainTextInput struct {
+	ActionID     string `json:"action_id,omitempty"`
+	InitialValue string `json:"initial_value,omitempty"`
+	Multiline    bool   `json:"multiline,omitempty"`
+	Placeholder  *Text  `json:"placeholder,omitempty"`
+}
+
+func (*PlainTextInput) element() {}
+
+func NewPlainTextInput(actionID string) *PlainTextInput {
+	return &PlainTextInput{ActionID: actionID}
+}
+
+func (p *PlainTextInput) WithInitialValue(value string) *PlainTextInput {
+	p.InitialValue = value
+	return p
+}
+
+func (p *PlainTextInput) WithMultiline(multiline bool) *PlainTextInput {
+	p.Multiline = multiline
+	return p
+}
+
+func (p PlainTextInput) MarshalJSON() ([]byte, error) {
+	type alias PlainTextInput
+	return marshalWithType("plain_text_input", alias(p))
+}
@@ -0,0 +1,30 @@
+package blocks
+
+import "encoding/json"
+
+/*
+marshalWithType marshals v with the "type" field, so the type is always right
+whether the struct is created by constructors or by literals.
+*/
+func marshalWithType(typ string, v interface{}) ([]byte, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	typeField, err := json.Marshal(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(buf)+len(typeField)+9)
+	out = append(out, `{"type":`...)
+	out = append(out, typeField...)
+	if len(buf) > 2 {
+		out = append(out, ',')
+		out = append(out, buf[1:]...)
+		return out, nil
+	}
+	out = append(out, '}')
+	return out, nil
+}
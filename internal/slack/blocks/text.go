@@ -0,0 +1,76 @@
+/*
+Package blocks builds Slack Block Kit payloads with typed structs.
+
+	view := blocks.NewHomeView(
+		blocks.NewSection(blocks.Markdown("*On duty*\n<@U123>")),
+		blocks.NewDivider(),
+	)
+
+Every text is escaped by encoding/json, so quotes or newlines in user input never break the payload.
+*/
+package blocks
+
+const (
+	TextPlain    = "plain_text"
+	TextMarkdown = "mrkdwn"
+)
+
+type Text struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+func (*Text) contextElement() {}
+
+// PlainText creates a plain_text object with emoji enabled.
+func PlainText(text string) *Text {
+	return &Text{
+		Type:  TextPlain,
+		Text:  text,
+		Emoji: true,
+	}
+}
+
+func Markdown(text string) *Text {
+	return &Text{
+		Type: TextMarkdown,
+		Text: text,
+	}
+}
+
+type Image struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+func (*Image) contextElement() {}
+
+func (i Image) MarshalJSON() ([]byte, error) {
+	type alias Image
+	return marshalWithType("image", alias(i))
+}
+
+// Confirm is the confirmation dialog of interactive elements.
+type Confirm struct {
+	Title   *Text  `json:"title"`
+	Text    *Text  `json:"text"`
+	Confirm *Text  `json:"confirm"`
+	Deny    *Text  `json:"deny"`
+	Style   string `json:"style,omitempty"`
+}
+
+func NewConfirm(title, text, confirm, deny string) *Confirm {
+	return &Confirm{
+		Title:   PlainText(title),
+		Text:    Markdown(text),
+		Confirm: PlainText(confirm),
+		Deny:    PlainText(deny),
+	}
+}
+
+func (c *Confirm) WithStyle(style string) *Confirm {
+	c.Style = style
+	return c
+}
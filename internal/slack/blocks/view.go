@@ -0,0 +1,54 @@
+package blocks
+
+const (
+	ViewModal = "modal"
+	ViewHome  = "home"
+)
+
+type View struct {
+	Type            string  `json:"type"`
+	Title           *Text   `json:"title,omitempty"`
+	Submit          *Text   `json:"submit,omitempty"`
+	Close           *Text   `json:"close,omitempty"`
+	Blocks          []Block `json:"blocks"`
+	PrivateMetadata string  `json:"private_metadata,omitempty"`
+	CallbackID      string  `json:"callback_id,omitempty"`
+	ExternalID      string  `json:"external_id,omitempty"`
+	ClearOnClose    bool    `json:"clear_on_close,omitempty"`
+	NotifyOnClose   bool    `json:"notify_on_close,omitempty"`
+}
+
+func NewModal(title, submit, close string, blocks ...Block) *View {
+	v := &View{
+		Type:   ViewModal,
+		Title:  PlainText(title),
+		Close:  PlainText(close),
+		Blocks: blocks,
+	}
+	if len(submit) != 0 {
+		v.Submit = PlainText(submit)
+	}
+	return v
+}
+
+func NewHomeView(blocks ...Block) *View {
+	return &View{
+		Type:   ViewHome,
+		Blocks: blocks,
+	}
+}
+
+func (v *View) WithPrivateMetadata(data string) *View {
+	v.PrivateMetadata = data
+	return v
+}
+
+func (v *View) WithCallbackID(id string) *View {
+	v.CallbackID = id
+	return v
+}
+
+func (v *View) Add(blocks ...Block) *View {
+	v.Blocks = append(v.Blocks, blocks...)
+	return v
+}
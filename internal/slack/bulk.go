@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/*
+BulkError collects failures of a bulk operation keyed by the target, e.g. user ID or message ts,
+so a bulk operation keeps going and reports partial failures instead of stopping at the first one.
+*/
+type BulkError struct {
+	mu        sync.Mutex
+	Operation string
+	Total     int
+	Failures  map[string]error
+}
+
+func NewBulkError(operation string, total int) *BulkError {
+	return &BulkError{
+		Operation: operation,
+		Total:     total,
+		Failures:  map[string]error{},
+	}
+}
+
+func (e *BulkError) Add(key string, err error) {
+	if err == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Failures[key] = err
+}
+
+func (e *BulkError) Failed() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.Failures)
+}
+
+// Err returns nil when there's no failure.
+func (e *BulkError) Err() error {
+	if e.Failed() == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *BulkError) Error() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]string, 0, len(e.Failures))
+	for k := range e.Failures {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s := fmt.Sprintf("%s: %d of %d failed", e.Operation, len(e.Failures), e.Total)
+	if len(keys) != 0 {
+		s += fmt.Sprintf(", first failure %s: %v", keys[0], e.Failures[keys[0]])
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"context"
+	"net/url"
+)
+
+const (
+	MethodChatPostMessage   = "chat.postMessage"
+	MethodChatUpdate        = "chat.update"
+	MethodChatDelete        = "chat.delete"
+	MethodChatGetPermalink  = "chat.getPermalink"
+	MethodChatPostEphemeral = "chat.postEphemeral"
+)
+
+type PostMessageRequest struct {
+	Channel     string           `json:"channel"`
+	Text        string           `json:"text"`
+	ThreadTS    string           `json:"thread_ts,omitempty"`
+	Blocks      interface{}      `json:"blocks,omitempty"`
+	Attachments []interface{}    `json:"attachments,omitempty"`
+	UnfurlLinks bool             `json:"unfurl_links"`
+	UnfurlMedia bool             `json:"unfurl_media"`
+	Metadata    *MessageMetadata `json:"metadata,omitempty"`
+}
+
+type PostMessageResponse struct {
+	Response
+	Channel string  `json:"channel"`
+	TS      string  `json:"ts"`
+	Message Message `json:"message"`
+}
+
+func (c *Client) PostMessage(ctx context.Context, req PostMessageRequest) (PostMessageResponse, error) {
+	res := PostMessageResponse{}
+	if err := c.postJSON(ctx, MethodChatPostMessage, req, &res); err != nil {
+		return PostMessageResponse{}, err
+	}
+	return res, nil
+}
+
+type PostEphemeralRequest struct {
+	Channel string      `json:"channel"`
+	User    string      `json:"user"`
+	Text    string      `json:"text"`
+	Blocks  interface{} `json:"blocks,omitempty"`
+}
+
+type postEphemeralResponse struct {
+	Response
+	MessageTS string `json:"message_ts"`
+}
+
+// PostEphemeral posts a message only visible to the user, and returns the timestamp of the message.
+func (c *Client) PostEphemeral(ctx context.Context, req PostEphemeralRequest) (string, error) {
+	res := postEphemeralResponse{}
+	if err := c.postJSON(ctx, MethodChatPostEphemeral, req, &res); err != nil {
+		return "", err
+	}
+	return res.MessageTS, nil
+}
+
+type UpdateMessageRequest struct {
+	Channel     string        `json:"channel"`
+	TS          string        `json:"ts"`
+	Text        string        `json:"text"`
+	Blocks      interface{}   `json:"blocks,omitempty"`
+	Attachments []interface{} `json:"attachments,omitempty"`
+}
+
+type UpdateMessageResponse struct {
+	Response
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+	Text    string `json:"text"`
+}
+
+func (c *Client) UpdateMessage(ctx context.Context, req UpdateMessageRequest) (UpdateMessageResponse, error) {
+	res := UpdateMessageResponse{}
+	if err := c.postJSON(ctx, MethodChatUpdate, req, &res); err != nil {
+		return UpdateMessageResponse{}, err
+	}
+	return res, nil
+}
+
+type deleteMessageRequest struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, channel, ts string) error {
+	res := Response{}
+	return c.postJSON(ctx, MethodChatDelete, deleteMessageRequest{Channel: channel, TS: ts}, &res)
+}
+
+type permalinkResponse struct {
+	Response
+	Channel   string `json:"channel"`
+	Permalink string `json:"permalink"`
+}
+
+func (c *Client) GetPermalink(ctx context.Context, channel, ts string) (string, error) {
+	res := permalinkResponse{}
+	if err := c.postForm(ctx, MethodChatGetPermalink, url.Values{
+		"channel":    {channel},
+		"message_ts": {ts},
+	}, &res); err != nil {
+		return "", err
+	}
+	return res.Permalink, nil
+}
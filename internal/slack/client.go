@@ -0,0 +1,223 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultBaseURL = "https://slack.com/api/"
+
+	_defaultMaxRetries  = 3
+	_defaultBackoffBase = 500 * time.Millisecond
+	_defaultBackoffMax  = 10 * time.Second
+	_defaultRetryAfter  = time.Second
+)
+
+/*
+Client calls Slack Web API with the bot token.
+
+	client := slack.New(token, slack.WithBaseURL("http://127.0.0.1:8080/api/"))
+	res, err := client.PostMessage(ctx, slack.PostMessageRequest{Channel: "C123", Text: "hi"})
+*/
+type Client struct {
+	token       string
+	baseURL     string
+	httpClient  *http.Client
+	limiter     *limiter
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	observer    Observer
+}
+
+type Option func(*Client)
+
+// Observer is called after every attempt of calling a method, err is nil when the call succeeded.
+type Observer func(method string, elapsed time.Duration, err error)
+
+// WithBaseURL replaces the Slack Web API base url, it's useful for testing.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if len(baseURL) == 0 {
+			return
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		c.baseURL = baseURL
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithRetry sets the max retries and the backoff of retries, zero max retries disables retrying.
+func WithRetry(maxRetries int, backoffBase, backoffMax time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		if backoffBase > 0 {
+			c.backoffBase = backoffBase
+		}
+		if backoffMax > 0 {
+			c.backoffMax = backoffMax
+		}
+	}
+}
+
+// WithObserver observes every attempt of calls, e.g. for metrics.
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+func New(token string, opts ...Option) *Client {
+	c := &Client{
+		token:       token,
+		baseURL:     DefaultBaseURL,
+		httpClient:  &http.Client{},
+		limiter:     newLimiter(),
+		maxRetries:  _defaultMaxRetries,
+		backoffBase: _defaultBackoffBase,
+		backoffMax:  _defaultBackoffMax,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) Token() string {
+	return c.token
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+/*
+postJSON calls the method with a json body, used by write methods.
+*/
+func (c *Client) postJSON(ctx context.Context, method string, req interface{}, res responder) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s request", method)
+	}
+	return c.do(ctx, method, "application/json; charset=UTF-8", body, res)
+}
+
+/*
+postForm calls the method with an urlencoded body, used by read methods which don't accept json.
+*/
+func (c *Client) postForm(ctx context.Context, method string, values url.Values, res responder) error {
+	return c.do(ctx, method, "application/x-www-form-urlencoded; charset=UTF-8", []byte(values.Encode()), res)
+}
+
+/*
+do calls the method with the rate limit of the method tier.
+
+Requests rejected by rate limit are retried after Retry-After, and idempotent methods
+are also retried with jittered backoff after network errors or server errors.
+*/
+func (c *Client) do(ctx context.Context, method, contentType string, body []byte, res responder) error {
+	if len(c.token) == 0 && !_tokenlessMethods[method] {
+		return errors.New("empty token")
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, method); err != nil {
+			return errors.Wrapf(err, "wait rate limit of %s", method)
+		}
+
+		start := time.Now()
+		err := c.send(ctx, method, contentType, body, res)
+		if c.observer != nil {
+			c.observer(method, time.Since(start), err)
+		}
+		if err == nil || attempt >= c.maxRetries {
+			return err
+		}
+
+		var e *Error
+		if errors.As(err, &e) && e.RateLimited() {
+			retryAfter := e.RetryAfter
+			if retryAfter <= 0 {
+				retryAfter = _defaultRetryAfter
+			}
+			c.limiter.block(method, retryAfter)
+			continue
+		}
+
+		if !_idempotentMethods[method] || !retryable(err) {
+			return err
+		}
+
+		if err := sleep(ctx, backoff(c.backoffBase, c.backoffMax, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, contentType string, body []byte, res responder) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "create %s request", method)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if len(c.token) != 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "call %s", method)
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "read %s response", method)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &Error{
+			Method:     method,
+			Code:       _codeRateLimited,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &Error{Method: method, Code: http.StatusText(resp.StatusCode), StatusCode: resp.StatusCode}
+	}
+
+	if err := json.Unmarshal(buf, res); err != nil {
+		return errors.Wrapf(err, "unmarshal %s response", method)
+	}
+
+	return res.err(method)
+}
+
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || seconds <= 0 {
+		return _defaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
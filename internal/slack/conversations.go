@@ -0,0 +1,110 @@
+package slack
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	MethodConversationsOpen    = "conversations.open"
+	MethodConversationsHistory = "conversations.history"
+	MethodConversationsReplies = "conversations.replies"
+)
+
+type openConversationResponse struct {
+	Response
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+}
+
+// OpenConversation opens a direct message or multi-person direct message, and returns the channel ID.
+func (c *Client) OpenConversation(ctx context.Context, users ...string) (string, error) {
+	res := openConversationResponse{}
+	if err := c.postForm(ctx, MethodConversationsOpen, url.Values{
+		"users": {strings.Join(users, ",")},
+	}, &res); err != nil {
+		return "", err
+	}
+	return res.Channel.ID, nil
+}
+
+type HistoryRequest struct {
+	Channel            string
+	Cursor             string
+	Limit              int
+	Latest             string
+	Oldest             string
+	IncludeAllMetadata bool
+}
+
+func (r HistoryRequest) values() url.Values {
+	v := url.Values{"channel": {r.Channel}}
+	if len(r.Cursor) != 0 {
+		v.Set("cursor", r.Cursor)
+	}
+	if r.Limit > 0 {
+		v.Set("limit", strconv.Itoa(r.Limit))
+	}
+	if len(r.Latest) != 0 {
+		v.Set("latest", r.Latest)
+	}
+	if len(r.Oldest) != 0 {
+		v.Set("oldest", r.Oldest)
+	}
+	if r.IncludeAllMetadata {
+		v.Set("include_all_metadata", "true")
+	}
+	return v
+}
+
+type HistoryResponse struct {
+	Response
+	Messages []Message `json:"messages"`
+	HasMore  bool      `json:"has_more"`
+}
+
+// NextCursor returns the cursor of the next page, empty means there's no more page.
+func (r HistoryResponse) NextCursor() string {
+	return r.ResponseMetadata.NextCursor
+}
+
+func (c *Client) ConversationHistory(ctx context.Context, req HistoryRequest) (HistoryResponse, error) {
+	res := HistoryResponse{}
+	if err := c.postForm(ctx, MethodConversationsHistory, req.values(), &res); err != nil {
+		return HistoryResponse{}, err
+	}
+	return res, nil
+}
+
+type RepliesRequest struct {
+	Channel            string
+	TS                 string
+	Cursor             string
+	Limit              int
+	IncludeAllMetadata bool
+}
+
+func (c *Client) ConversationReplies(ctx context.Context, req RepliesRequest) (HistoryResponse, error) {
+	v := url.Values{
+		"channel": {req.Channel},
+		"ts":      {req.TS},
+	}
+	if len(req.Cursor) != 0 {
+		v.Set("cursor", req.Cursor)
+	}
+	if req.Limit > 0 {
+		v.Set("limit", strconv.Itoa(req.Limit))
+	}
+	if req.IncludeAllMetadata {
+		v.Set("include_all_metadata", "true")
+	}
+
+	res := HistoryResponse{}
+	if err := c.postForm(ctx, MethodConversationsReplies, v, &res); err != nil {
+		return HistoryResponse{}, err
+	}
+	return res, nil
+}
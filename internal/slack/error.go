@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Error is the error returned by Slack, including `ok: false` responses and unexpected http status.
+*/
+type Error struct {
+	Method     string
+	Code       string
+	Messages   []string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+const (
+	_codeRateLimited = "ratelimited"
+)
+
+var (
+	_retryableCodes = map[string]bool{
+		"internal_error":      true,
+		"fatal_error":         true,
+		"service_unavailable": true,
+		"request_timeout":     true,
+	}
+)
+
+func (e *Error) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.Code == _codeRateLimited
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("slack %s: %s", e.Method, e.Code)
+	if len(e.Messages) != 0 {
+		s += " (" + strings.Join(e.Messages, "; ") + ")"
+	}
+	return s
+}
+
+// IsError reports whether err is a slack error with the code, e.g. "channel_not_found".
+func IsError(err error, code string) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.Code == code
+}
+
+// retryable reports whether the error is temporary, network errors are always temporary.
+func retryable(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return true
+	}
+	return e.StatusCode >= http.StatusInternalServerError || _retryableCodes[e.Code]
+}
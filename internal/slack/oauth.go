@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"context"
+	"net/url"
+)
+
+const (
+	MethodOAuthV2Access = "oauth.v2.access"
+
+	DefaultAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+)
+
+type OAuthV2AccessRequest struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+}
+
+func (r OAuthV2AccessRequest) values() url.Values {
+	v := url.Values{}
+	v.Set("client_id", r.ClientID)
+	v.Set("client_secret", r.ClientSecret)
+	v.Set("code", r.Code)
+	if len(r.RedirectURI) != 0 {
+		v.Set("redirect_uri", r.RedirectURI)
+	}
+	return v
+}
+
+type OAuthTeam struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type OAuthUser struct {
+	ID string `json:"id"`
+}
+
+type OAuthV2AccessResponse struct {
+	Response
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Scope       string    `json:"scope"`
+	BotUserID   string    `json:"bot_user_id"`
+	AppID       string    `json:"app_id"`
+	Team        OAuthTeam `json:"team"`
+	AuthedUser  OAuthUser `json:"authed_user"`
+}
+
+/*
+OAuthV2Access exchanges the code of the OAuth redirect for the bot token of the workspace,
+it's authorized by the client credentials, so the client doesn't need a token.
+*/
+func (c *Client) OAuthV2Access(ctx context.Context, req OAuthV2AccessRequest) (OAuthV2AccessResponse, error) {
+	res := OAuthV2AccessResponse{}
+	if err := c.postForm(ctx, MethodOAuthV2Access, req.values(), &res); err != nil {
+		return OAuthV2AccessResponse{}, err
+	}
+	return res, nil
+}
+
+/*
+AuthorizeURL builds the url which asks the user to install the app, Slack redirects to redirectURI with the code and the state.
+
+	slack.AuthorizeURL(slack.DefaultAuthorizeURL, clientID, "app_mentions:read,chat:write", redirectURI, state)
+*/
+func AuthorizeURL(authorizeURL, clientID, scope, redirectURI, state string) string {
+	v := url.Values{}
+	v.Set("client_id", clientID)
+	v.Set("scope", scope)
+	v.Set("state", state)
+	if len(redirectURI) != 0 {
+		v.Set("redirect_uri", redirectURI)
+	}
+	return authorizeURL + "?" + v.Encode()
+}
@@ -0,0 +1,194 @@
+package slack
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+/*
+tier is the rate limit tier of Slack Web API methods.
+
+https://api.slack.com/apis/rate-limits
+*/
+type tier struct {
+	perMinute float64
+	burst     float64
+}
+
+var (
+	_tier1 = tier{perMinute: 1, burst: 1}
+	_tier2 = tier{perMinute: 20, burst: 3}
+	_tier3 = tier{perMinute: 50, burst: 5}
+	_tier4 = tier{perMinute: 100, burst: 10}
+	/* chat.postMessage allows about one message per second per channel */
+	_tierPostMessage = tier{perMinute: 60, burst: 5}
+	/* apps.connections.open is tier 1, the burst allows reconnecting after a few quick drops */
+	_tierConnectionsOpen = tier{perMinute: 1, burst: 3}
+
+	_methodTiers = map[string]tier{
+		MethodChatPostMessage:      _tierPostMessage,
+		MethodChatUpdate:           _tier3,
+		MethodChatDelete:           _tier3,
+		MethodChatGetPermalink:     _tier4,
+		MethodChatPostEphemeral:    _tier4,
+		MethodConversationsOpen:    _tier3,
+		MethodConversationsHistory: _tier3,
+		MethodConversationsReplies: _tier3,
+		MethodViewsOpen:            _tier4,
+		MethodViewsPublish:         _tier4,
+		MethodViewsUpdate:          _tier4,
+		MethodUsersInfo:            _tier4,
+		MethodReactionsAdd:         _tier3,
+		MethodAuthTest:             _tier4,
+		MethodOAuthV2Access:        _tier4,
+		MethodAppsConnectionsOpen:  _tierConnectionsOpen,
+	}
+
+	_defaultTier = _tier3
+)
+
+/*
+idempotent methods can be retried safely after network errors or server errors.
+
+chat.postMessage is not idempotent, it's only retried when Slack rejects it with 429.
+*/
+var _idempotentMethods = map[string]bool{
+	MethodChatUpdate:           true,
+	MethodChatDelete:           true,
+	MethodChatGetPermalink:     true,
+	MethodConversationsOpen:    true,
+	MethodConversationsHistory: true,
+	MethodConversationsReplies: true,
+	MethodViewsPublish:         true,
+	MethodViewsUpdate:          true,
+	MethodUsersInfo:            true,
+	MethodReactionsAdd:         true,
+	MethodAuthTest:             true,
+	MethodAppsConnectionsOpen:  true,
+}
+
+/* tokenless methods are authorized by the params instead of the token */
+var _tokenlessMethods = map[string]bool{
+	MethodOAuthV2Access: true,
+}
+
+type bucket struct {
+	mu           sync.Mutex
+	tokens       float64
+	capacity     float64
+	ratePerSec   float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func newBucket(t tier) *bucket {
+	return &bucket{
+		tokens:     t.burst,
+		capacity:   t.burst,
+		ratePerSec: t.perMinute / 60,
+		last:       time.Now(),
+	}
+}
+
+// take takes a token, and returns how long to wait when there's no token.
+func (b *bucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.ratePerSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.ratePerSec * float64(time.Second))
+}
+
+// block blocks every caller until d passed, it's used when Slack responds Retry-After.
+func (b *bucket) block(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+	b.tokens = 0
+}
+
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (l *limiter) bucket(method string) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[method]
+	if !ok {
+		t, ok := _methodTiers[method]
+		if !ok {
+			t = _defaultTier
+		}
+		b = newBucket(t)
+		l.buckets[method] = b
+	}
+	return b
+}
+
+func (l *limiter) wait(ctx context.Context, method string) error {
+	b := l.bucket(method)
+	for {
+		d := b.take(time.Now())
+		if d <= 0 {
+			return nil
+		}
+
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *limiter) block(method string, d time.Duration) {
+	l.bucket(method).block(d)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns the exponential backoff with full jitter of the attempt.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base << uint(attempt)
+	if d <= 0 || d > max {
+		d = max
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
@@ -0,0 +1,19 @@
+package slack
+
+import "context"
+
+const (
+	MethodReactionsAdd = "reactions.add"
+)
+
+type addReactionRequest struct {
+	Channel   string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+	Name      string `json:"name"`
+}
+
+// AddReaction adds the emoji, e.g. "white_check_mark", to the message.
+func (c *Client) AddReaction(ctx context.Context, channel, ts, name string) error {
+	res := Response{}
+	return c.postJSON(ctx, MethodReactionsAdd, addReactionRequest{Channel: channel, Timestamp: ts, Name: name}, &res)
+}
@@ -0,0 +1,61 @@
+package slack
+
+type responder interface {
+	err(method string) error
+}
+
+/*
+Response is the common part of every Slack Web API response.
+*/
+type Response struct {
+	OK               bool             `json:"ok"`
+	Error            string           `json:"error,omitempty"`
+	Warning          string           `json:"warning,omitempty"`
+	ResponseMetadata ResponseMetadata `json:"response_metadata,omitempty"`
+}
+
+type ResponseMetadata struct {
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Messages   []string `json:"messages,omitempty"`
+}
+
+func (r *Response) err(method string) error {
+	if r.OK {
+		return nil
+	}
+
+	code := r.Error
+	if len(code) == 0 {
+		code = "unknown_error"
+	}
+	return &Error{Method: method, Code: code, Messages: r.ResponseMetadata.Messages}
+}
+
+type Message struct {
+	Type     string           `json:"type"`
+	SubType  string           `json:"subtype,omitempty"`
+	User     string           `json:"user,omitempty"`
+	BotID    string           `json:"bot_id,omitempty"`
+	Text     string           `json:"text"`
+	TS       string           `json:"ts"`
+	ThreadTS string           `json:"thread_ts,omitempty"`
+	Metadata *MessageMetadata `json:"metadata,omitempty"`
+}
+
+/*
+MessageMetadata is the app-defined metadata of the message,
+history only returns it when IncludeAllMetadata is set.
+*/
+type MessageMetadata struct {
+	EventType    string                 `json:"event_type"`
+	EventPayload map[string]interface{} `json:"event_payload"`
+}
+
+// String gets the payload as a string, it returns empty string when the payload isn't a string.
+func (m *MessageMetadata) String(key string) string {
+	if m == nil {
+		return ""
+	}
+	s, _ := m.EventPayload[key].(string)
+	return s
+}
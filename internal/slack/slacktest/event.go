@@ -0,0 +1,31 @@
+package slacktest
+
+import (
+	"bitopi/internal/model"
+	"encoding/json"
+)
+
+// MentionEvent builds the app_mention event callback body which Slack posts to the bot from the workspace of teamID.
+func MentionEvent(teamID, channel, user, text, ts string) []byte {
+	return ThreadMentionEvent(teamID, channel, user, text, ts, "")
+}
+
+// ThreadMentionEvent builds the app_mention event callback body of a mention in the thread of threadTS.
+func ThreadMentionEvent(teamID, channel, user, text, ts, threadTS string) []byte {
+	buf, _ := json.Marshal(model.SlackEventAPI{
+		Token:   "fake-verification-token",
+		TeamID:  teamID,
+		Type:    "event_callback",
+		EventId: "Ev" + ts,
+		Event: model.Event{
+			Type:            "app_mention",
+			User:            user,
+			Text:            text,
+			TimeStamp:       ts,
+			Channel:         channel,
+			EventTimeStamp:  ts,
+			ThreadTimeStamp: threadTS,
+		},
+	})
+	return buf
+}
@@ -0,0 +1,230 @@
+package slacktest
+
+import (
+	"bitopi/internal/slack"
+	"strconv"
+	"strings"
+)
+
+const (
+	_defaultHistoryLimit = 100
+)
+
+func (s *Server) defaultResponse(call Call) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch call.Method {
+	case slack.MethodChatPostMessage:
+		return s.postMessage(call)
+	case slack.MethodChatUpdate:
+		return s.updateMessage(call)
+	case slack.MethodChatDelete:
+		return s.deleteMessage(call)
+	case slack.MethodChatPostEphemeral:
+		if len(call.String("channel")) == 0 || len(call.String("user")) == 0 {
+			return fail("channel_not_found")
+		}
+		return ok(map[string]interface{}{"message_ts": s.nextTS()})
+	case slack.MethodChatGetPermalink:
+		channel, ts := call.String("channel"), call.String("message_ts")
+		return ok(map[string]interface{}{
+			"channel":   channel,
+			"permalink": "https://fake.slack.com/archives/" + channel + "/p" + strings.ReplaceAll(ts, ".", ""),
+		})
+	case slack.MethodConversationsOpen:
+		return ok(map[string]interface{}{
+			"channel": map[string]interface{}{"id": "D" + strings.ReplaceAll(call.String("users"), ",", "")},
+		})
+	case slack.MethodConversationsHistory:
+		return s.history(call)
+	case slack.MethodConversationsReplies:
+		return s.conversationReplies(call)
+	case slack.MethodViewsOpen, slack.MethodViewsPublish, slack.MethodViewsUpdate:
+		s.seq++
+		return ok(map[string]interface{}{
+			"view": map[string]interface{}{"id": "V" + strconv.Itoa(s.seq), "hash": strconv.Itoa(s.seq)},
+		})
+	case slack.MethodUsersInfo:
+		user, exist := s.users[call.String("user")]
+		if !exist {
+			user = slack.User{ID: call.String("user"), Name: call.String("user"), Locale: "en-US"}
+		}
+		return ok(map[string]interface{}{"user": user})
+	case slack.MethodReactionsAdd:
+		return ok(nil)
+	case slack.MethodAuthTest:
+		return ok(map[string]interface{}{
+			"url":     "https://fake.slack.com/",
+			"team":    "fake",
+			"user":    "bitopi",
+			"team_id": "TFAKE",
+			"user_id": BotUserID,
+			"bot_id":  BotID,
+		})
+	case slack.MethodOAuthV2Access:
+		return oauthAccess(call)
+	case slack.MethodAppsConnectionsOpen:
+		return ok(map[string]interface{}{"url": s.socketURL()})
+	default:
+		return fail("unknown_method")
+	}
+}
+
+/*
+oauthAccess exchanges the code issued by the fake authorize page, the token is 'xoxb-' + team ID.
+*/
+func oauthAccess(call Call) interface{} {
+	if len(call.String("client_id")) == 0 || len(call.String("client_secret")) == 0 {
+		return fail("invalid_client_id")
+	}
+
+	team := strings.TrimPrefix(call.String("code"), _codePrefix)
+	if !strings.HasPrefix(call.String("code"), _codePrefix) || len(team) == 0 {
+		return fail("invalid_code")
+	}
+
+	name := TeamName
+	if team != TeamID {
+		name = strings.ToLower(team)
+	}
+
+	return ok(map[string]interface{}{
+		"access_token": _tokenPrefix + team,
+		"token_type":   "bot",
+		"scope":        call.String("scope"),
+		"bot_user_id":  BotUserID,
+		"app_id":       "AFAKE",
+		"team":         map[string]interface{}{"id": team, "name": name},
+		"authed_user":  map[string]interface{}{"id": "UINSTALLER"},
+	})
+}
+
+func (s *Server) postMessage(call Call) interface{} {
+	channel := call.String("channel")
+	if len(channel) == 0 {
+		return fail("channel_not_found")
+	}
+
+	msg := slack.Message{
+		Type:     "message",
+		User:     BotUserID,
+		BotID:    BotID,
+		Text:     call.String("text"),
+		TS:       s.nextTS(),
+		ThreadTS: call.String("thread_ts"),
+		Metadata: metadata(call),
+	}
+	s.messages[channel] = append(s.messages[channel], msg)
+
+	if len(msg.ThreadTS) != 0 {
+		key := channel + "/" + msg.ThreadTS
+		s.replies[key] = append(s.replies[key], msg)
+	}
+
+	return ok(map[string]interface{}{
+		"channel": channel,
+		"ts":      msg.TS,
+		"message": msg,
+	})
+}
+
+func metadata(call Call) *slack.MessageMetadata {
+	m, ok := call.Params["metadata"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	eventType, _ := m["event_type"].(string)
+	payload, _ := m["event_payload"].(map[string]interface{})
+	return &slack.MessageMetadata{EventType: eventType, EventPayload: payload}
+}
+
+/*
+history serves messages without metadata unless include_all_metadata is set, like Slack does.
+*/
+func withoutMetadata(msgs []slack.Message) []slack.Message {
+	stripped := make([]slack.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		msg.Metadata = nil
+		stripped = append(stripped, msg)
+	}
+	return stripped
+}
+
+func (s *Server) updateMessage(call Call) interface{} {
+	channel, ts := call.String("channel"), call.String("ts")
+	for i, msg := range s.messages[channel] {
+		if msg.TS == ts {
+			s.messages[channel][i].Text = call.String("text")
+			return ok(map[string]interface{}{"channel": channel, "ts": ts, "text": call.String("text")})
+		}
+	}
+	return fail("message_not_found")
+}
+
+func (s *Server) deleteMessage(call Call) interface{} {
+	channel, ts := call.String("channel"), call.String("ts")
+	for i, msg := range s.messages[channel] {
+		if msg.TS == ts {
+			s.messages[channel] = append(s.messages[channel][:i], s.messages[channel][i+1:]...)
+			return ok(map[string]interface{}{"channel": channel, "ts": ts})
+		}
+	}
+	return fail("message_not_found")
+}
+
+/*
+history serves messages of the channel from the newest one, the cursor is the offset of the next page.
+*/
+func (s *Server) history(call Call) interface{} {
+	msgs := s.messages[call.String("channel")]
+
+	limit, err := strconv.Atoi(call.String("limit"))
+	if err != nil || limit <= 0 {
+		limit = _defaultHistoryLimit
+	}
+
+	offset, _ := strconv.Atoi(call.String("cursor"))
+	page := []slack.Message{}
+	for i := len(msgs) - 1 - offset; i >= 0 && len(page) < limit; i-- {
+		page = append(page, msgs[i])
+	}
+
+	next := ""
+	if offset+len(page) < len(msgs) {
+		next = strconv.Itoa(offset + len(page))
+	}
+
+	if call.String("include_all_metadata") != "true" {
+		page = withoutMetadata(page)
+	}
+
+	return ok(map[string]interface{}{
+		"messages":          page,
+		"has_more":          len(next) != 0,
+		"response_metadata": map[string]interface{}{"next_cursor": next},
+	})
+}
+
+func (s *Server) conversationReplies(call Call) interface{} {
+	channel, ts := call.String("channel"), call.String("ts")
+
+	thread := []slack.Message{}
+	for _, msg := range s.messages[channel] {
+		if msg.TS == ts {
+			thread = append(thread, msg)
+			break
+		}
+	}
+	thread = append(thread, s.replies[channel+"/"+ts]...)
+
+	if len(thread) == 0 {
+		return fail("thread_not_found")
+	}
+
+	if call.String("include_all_metadata") != "true" {
+		thread = withoutMetadata(thread)
+	}
+	return ok(map[string]interface{}{"messages": thread})
+}
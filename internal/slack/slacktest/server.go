@@ -0,0 +1,314 @@
+/*
+Package slacktest provides an in-process fake Slack Web API server.
+
+	srv := slacktest.NewServer()
+	defer srv.Close()
+
+	client := slack.New("xoxb-test", slack.WithBaseURL(srv.BaseURL()))
+	srv.RateLimitNext(slack.MethodViewsPublish, 1, 1)
+	srv.FailNext(slack.MethodChatPostMessage, "channel_not_found", 1)
+
+Socket Mode clients connect to the stub websocket returned by apps.connections.open,
+and receive envelopes from SendEnvelope.
+*/
+package slacktest
+
+import (
+	"bitopi/internal/slack"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	/* identity of the token returned by auth.test */
+	BotUserID = "UBITOPI"
+	BotID     = "BBITOPI"
+
+	/* workspace of OAuth installations when the authorize url has no team */
+	TeamID   = "TFAKE"
+	TeamName = "fake"
+
+	_authorizePath = "/oauth/v2/authorize"
+	_codePrefix    = "code-"
+	_tokenPrefix   = "xoxb-"
+)
+
+// Call is a recorded request, Params is the json body or the form values.
+type Call struct {
+	Method string
+	Token  string
+	Params map[string]interface{}
+	Body   []byte
+}
+
+// String gets the param as a string, it returns empty string when the param isn't a string.
+func (c Call) String(key string) string {
+	s, _ := c.Params[key].(string)
+	return s
+}
+
+type failure struct {
+	code       string
+	statusCode int
+	retryAfter int
+}
+
+type HandlerFunc func(call Call) interface{}
+
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	calls    []Call
+	messages map[string][]slack.Message /* channel -> messages */
+	replies  map[string][]slack.Message /* channel/ts -> replies of the thread */
+	users    map[string]slack.User
+	failures map[string][]failure
+	handlers map[string]HandlerFunc
+	sockets  []*socketConn
+	acks     []SocketAck
+	seq      int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		messages: map[string][]slack.Message{},
+		replies:  map[string][]slack.Message{},
+		users:    map[string]slack.User{},
+		failures: map[string][]failure{},
+		handlers: map[string]HandlerFunc{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BaseURL returns the url to inject into slack.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.Server.URL + "/api/"
+}
+
+/*
+AuthorizeURL returns the fake OAuth authorize page, which approves the installation right away
+and redirects to redirect_uri with the code of the team in the query, TeamID by default.
+
+	GET {AuthorizeURL}?client_id=...&redirect_uri=...&state=...&team=T123
+*/
+func (s *Server) AuthorizeURL() string {
+	return s.Server.URL + _authorizePath
+}
+
+// Calls returns recorded calls of the method, or every call when method is empty.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := []Call{}
+	for _, c := range s.calls {
+		if len(method) == 0 || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset clears recorded calls, messages, acks and injected failures, custom handlers and connections are kept.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+	s.messages = map[string][]slack.Message{}
+	s.replies = map[string][]slack.Message{}
+	s.failures = map[string][]failure{}
+	s.acks = nil
+}
+
+// Messages returns messages in the channel which are posted and not deleted.
+func (s *Server) Messages(channel string) []slack.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]slack.Message{}, s.messages[channel]...)
+}
+
+// SetHistory replaces the messages of the channel served by conversations.history.
+func (s *Server) SetHistory(channel string, msgs ...slack.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[channel] = append([]slack.Message{}, msgs...)
+}
+
+// SetReplies sets replies of the thread served by conversations.replies, the parent message is served from the channel messages.
+func (s *Server) SetReplies(channel, ts string, msgs ...slack.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[channel+"/"+ts] = append([]slack.Message{}, msgs...)
+}
+
+// SetUser sets the user served by users.info.
+func (s *Server) SetUser(user slack.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+}
+
+// Handle overrides the response of the method.
+func (s *Server) Handle(method string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = fn
+}
+
+// FailNext responds `ok: false` with the error code for the next n calls of the method.
+func (s *Server) FailNext(method, code string, n int) {
+	s.inject(method, failure{code: code, statusCode: http.StatusOK}, n)
+}
+
+// ServerErrorNext responds the http status code for the next n calls of the method.
+func (s *Server) ServerErrorNext(method string, statusCode, n int) {
+	s.inject(method, failure{statusCode: statusCode}, n)
+}
+
+// RateLimitNext responds 429 with Retry-After seconds for the next n calls of the method.
+func (s *Server) RateLimitNext(method string, retryAfter, n int) {
+	s.inject(method, failure{statusCode: http.StatusTooManyRequests, retryAfter: retryAfter}, n)
+}
+
+func (s *Server) inject(method string, f failure, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures[method] = append(s.failures[method], f)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case _authorizePath:
+		s.authorize(w, r)
+		return
+	case _socketPath:
+		s.socket(w, r)
+		return
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	call, err := parseCall(method, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error": "invalid_arguments"})
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+
+	if fs := s.failures[method]; len(fs) != 0 {
+		f := fs[0]
+		s.failures[method] = fs[1:]
+		s.mu.Unlock()
+
+		if f.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(f.retryAfter))
+		}
+		if f.statusCode != http.StatusOK {
+			writeJSON(w, f.statusCode, map[string]interface{}{"ok": false, "error": "ratelimited"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"ok": false, "error": f.code})
+		return
+	}
+
+	handler, ok := s.handlers[method]
+	s.mu.Unlock()
+
+	if ok {
+		writeJSON(w, http.StatusOK, handler(call))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.defaultResponse(call))
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || len(query.Get("client_id")) == 0 || len(redirect.String()) == 0 {
+		http.Error(w, "invalid_arguments", http.StatusBadRequest)
+		return
+	}
+
+	team := query.Get("team")
+	if len(team) == 0 {
+		team = TeamID
+	}
+
+	values := redirect.Query()
+	values.Set("code", _codePrefix+team)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func parseCall(method string, r *http.Request) (Call, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Call{}, err
+	}
+
+	call := Call{
+		Method: method,
+		Token:  strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+		Params: map[string]interface{}{},
+		Body:   body,
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if len(body) != 0 {
+			if err := json.Unmarshal(body, &call.Params); err != nil {
+				return Call{}, err
+			}
+		}
+		return call, nil
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return Call{}, err
+	}
+	for k := range r.URL.Query() {
+		values.Set(k, r.URL.Query().Get(k))
+	}
+	for k := range values {
+		call.Params[k] = values.Get(k)
+	}
+	return call, nil
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) nextTS() string {
+	s.seq++
+	return fmt.Sprintf("1700000000.%06d", s.seq)
+}
+
+func ok(fields map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{"ok": true}
+	for k, v := range fields {
+		res[k] = v
+	}
+	return res
+}
+
+func fail(code string) map[string]interface{} {
+	return map[string]interface{}{"ok": false, "error": code}
+}
@@ -0,0 +1,133 @@
+package slacktest
+
+import (
+	"bitopi/internal/slack"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	_socketPath = "/socket"
+)
+
+var _upgrader = websocket.Upgrader{}
+
+// SocketAck is an ack received from Socket Mode clients.
+type SocketAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+type socketConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *socketConn) write(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (s *Server) socketURL() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http") + _socketPath
+}
+
+/*
+socket serves Socket Mode connections opened by apps.connections.open,
+it says hello on connected and records acks until the connection is closed.
+*/
+func (s *Server) socket(w http.ResponseWriter, r *http.Request) {
+	conn, err := _upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &socketConn{conn: conn}
+	s.mu.Lock()
+	s.sockets = append(s.sockets, c)
+	count := len(s.sockets)
+	s.mu.Unlock()
+
+	defer s.removeSocket(c)
+
+	if err := c.write(map[string]interface{}{"type": slack.SocketTypeHello, "num_connections": count}); err != nil {
+		return
+	}
+
+	for {
+		ack := SocketAck{}
+		if err := conn.ReadJSON(&ack); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.acks = append(s.acks, ack)
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) removeSocket(c *socketConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = c.conn.Close()
+	for i, socket := range s.sockets {
+		if socket == c {
+			s.sockets = append(s.sockets[:i], s.sockets[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Server) socketConns() []*socketConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*socketConn{}, s.sockets...)
+}
+
+// Connections returns the count of open Socket Mode connections.
+func (s *Server) Connections() int {
+	return len(s.socketConns())
+}
+
+// SendEnvelope sends the envelope to every Socket Mode connection, it fails when there's no connection.
+func (s *Server) SendEnvelope(env slack.SocketEnvelope) error {
+	conns := s.socketConns()
+	if len(conns) == 0 {
+		return errors.New("no socket mode connection")
+	}
+
+	for _, c := range conns {
+		if err := c.write(env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Acks returns acks received from Socket Mode clients.
+func (s *Server) Acks() []SocketAck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SocketAck{}, s.acks...)
+}
+
+// Disconnect asks every client to reconnect, like Slack refreshing connections.
+func (s *Server) Disconnect(reason string) {
+	for _, c := range s.socketConns() {
+		_ = c.write(slack.SocketEnvelope{Type: slack.SocketTypeDisconnect, Reason: reason})
+	}
+}
+
+// DropConnections closes every connection without saying goodbye, like network errors.
+func (s *Server) DropConnections() {
+	for _, c := range s.socketConns() {
+		_ = c.conn.Close()
+	}
+}
@@ -0,0 +1,231 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	MethodAppsConnectionsOpen = "apps.connections.open"
+
+	SocketTypeHello         = "hello"
+	SocketTypeDisconnect    = "disconnect"
+	SocketTypeEventsAPI     = "events_api"
+	SocketTypeInteractive   = "interactive"
+	SocketTypeSlashCommands = "slash_commands"
+
+	_defaultSocketBackoffBase = time.Second
+	_defaultSocketBackoffMax  = time.Minute
+	/* Slack pings every few seconds, a silent connection longer than this is considered dead */
+	_socketReadTimeout  = 2 * time.Minute
+	_socketWriteTimeout = 10 * time.Second
+)
+
+type openConnectionResponse struct {
+	Response
+	URL string `json:"url"`
+}
+
+// OpenConnection returns the websocket url of Socket Mode, the client must use the app-level token.
+func (c *Client) OpenConnection(ctx context.Context) (string, error) {
+	res := openConnectionResponse{}
+	if err := c.postForm(ctx, MethodAppsConnectionsOpen, url.Values{}, &res); err != nil {
+		return "", err
+	}
+	return res.URL, nil
+}
+
+/*
+SocketEnvelope is a message of Socket Mode, Payload is the same body as the HTTP request of the type.
+
+https://api.slack.com/apis/socket-mode
+*/
+type SocketEnvelope struct {
+	EnvelopeID             string          `json:"envelope_id,omitempty"`
+	Type                   string          `json:"type"`
+	Payload                json.RawMessage `json:"payload,omitempty"`
+	AcceptsResponsePayload bool            `json:"accepts_response_payload,omitempty"`
+	RetryAttempt           int             `json:"retry_attempt,omitempty"`
+	RetryReason            string          `json:"retry_reason,omitempty"`
+	Reason                 string          `json:"reason,omitempty"` /* disconnect only */
+}
+
+type socketAck struct {
+	EnvelopeID string      `json:"envelope_id"`
+	Payload    interface{} `json:"payload,omitempty"`
+}
+
+/*
+SocketHandler handles the envelope, the returned payload is sent with the ack
+when the envelope accepts response payload, e.g. response_action of view submissions.
+*/
+type SocketHandler func(ctx context.Context, env SocketEnvelope) interface{}
+
+type SocketOption func(*SocketModeClient)
+
+// WithSocketBackoff sets the backoff of reconnecting.
+func WithSocketBackoff(base, max time.Duration) SocketOption {
+	return func(s *SocketModeClient) {
+		if base > 0 {
+			s.backoffBase = base
+		}
+		if max > 0 {
+			s.backoffMax = max
+		}
+	}
+}
+
+// WithSocketErrorHandler receives errors of connections and acks, which are retried or dropped by the client.
+func WithSocketErrorHandler(fn func(error)) SocketOption {
+	return func(s *SocketModeClient) {
+		if fn != nil {
+			s.onError = fn
+		}
+	}
+}
+
+// WithSocketRunner runs the handling of every envelope, e.g. to wait for it on shutdown, it's a plain goroutine by default.
+func WithSocketRunner(fn func(func())) SocketOption {
+	return func(s *SocketModeClient) {
+		if fn != nil {
+			s.run = fn
+		}
+	}
+}
+
+/*
+SocketModeClient receives envelopes over Socket Mode, and reconnects with backoff until the context is done.
+
+	socket := slack.NewSocketModeClient(slack.New(appToken), handler)
+	err := socket.Run(ctx)
+
+Envelopes which don't accept response payload are acknowledged before handling,
+others are acknowledged with the payload returned by the handler.
+Slash commands are always acknowledged after handling, the handler answers or rejects them with the payload.
+*/
+type SocketModeClient struct {
+	client      *Client
+	handler     SocketHandler
+	dialer      *websocket.Dialer
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	onError     func(error)
+	run         func(func())
+}
+
+func NewSocketModeClient(client *Client, handler SocketHandler, opts ...SocketOption) *SocketModeClient {
+	s := &SocketModeClient{
+		client:      client,
+		handler:     handler,
+		dialer:      websocket.DefaultDialer,
+		backoffBase: _defaultSocketBackoffBase,
+		backoffMax:  _defaultSocketBackoffMax,
+		onError:     func(error) {},
+		run:         func(fn func()) { go fn() },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run connects and reconnects until ctx is done, it always returns the error of ctx.
+func (s *SocketModeClient) Run(ctx context.Context) error {
+	for attempt := 0; ; attempt++ {
+		ready, err := s.connect(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err != nil {
+			s.onError(err)
+		}
+
+		/* the backoff restarts once a connection was ready, e.g. refreshed by Slack */
+		if ready {
+			attempt = 0
+		}
+
+		if err := sleep(ctx, backoff(s.backoffBase, s.backoffMax, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+/*
+connect reads the connection until it's closed or Slack asks to disconnect,
+ready is true when the connection received hello.
+*/
+func (s *SocketModeClient) connect(ctx context.Context) (ready bool, err error) {
+	wsURL, err := s.client.OpenConnection(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "open socket mode connection")
+	}
+
+	conn, _, err := s.dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "dial socket mode")
+	}
+	defer conn.Close()
+
+	/* closing the connection unblocks reading when ctx is done */
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	writeMu := &sync.Mutex{}
+	conn.SetPingHandler(func(data string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(_socketReadTimeout))
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(_socketWriteTimeout))
+	})
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(_socketReadTimeout)); err != nil {
+			return ready, err
+		}
+
+		env := SocketEnvelope{}
+		if err := conn.ReadJSON(&env); err != nil {
+			return ready, errors.Wrap(err, "read socket mode envelope")
+		}
+
+		switch env.Type {
+		case SocketTypeHello:
+			ready = true
+		case SocketTypeDisconnect:
+			return ready, nil
+		default:
+			if len(env.EnvelopeID) == 0 {
+				continue
+			}
+			s.run(func() { s.handle(ctx, conn, writeMu, env) })
+		}
+	}
+}
+
+func (s *SocketModeClient) handle(ctx context.Context, conn *websocket.Conn, writeMu *sync.Mutex, env SocketEnvelope) {
+	if !env.AcceptsResponsePayload && env.Type != SocketTypeSlashCommands {
+		s.ack(conn, writeMu, socketAck{EnvelopeID: env.EnvelopeID})
+		s.handler(ctx, env)
+		return
+	}
+
+	s.ack(conn, writeMu, socketAck{EnvelopeID: env.EnvelopeID, Payload: s.handler(ctx, env)})
+}
+
+func (s *SocketModeClient) ack(conn *websocket.Conn, writeMu *sync.Mutex, ack socketAck) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(_socketWriteTimeout))
+	if err := conn.WriteJSON(ack); err != nil {
+		s.onError(errors.Wrapf(err, "ack envelope %s", ack.EnvelopeID))
+	}
+}
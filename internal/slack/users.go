@@ -0,0 +1,37 @@
+package slack
+
+import (
+	"context"
+	"net/url"
+)
+
+const (
+	MethodUsersInfo = "users.info"
+)
+
+type User struct {
+	ID       string `json:"id"`
+	TeamID   string `json:"team_id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	IsBot    bool   `json:"is_bot"`
+	Locale   string `json:"locale"`
+	TZ       string `json:"tz"`
+}
+
+type userInfoResponse struct {
+	Response
+	User User `json:"user"`
+}
+
+// UserInfo gets the user, locale is only returned when include_locale is set.
+func (c *Client) UserInfo(ctx context.Context, userID string) (User, error) {
+	res := userInfoResponse{}
+	if err := c.postForm(ctx, MethodUsersInfo, url.Values{
+		"user":           {userID},
+		"include_locale": {"true"},
+	}, &res); err != nil {
+		return User{}, err
+	}
+	return res.User, nil
+}
@@ -0,0 +1,58 @@
+package slack
+
+import "context"
+
+const (
+	MethodViewsOpen    = "views.open"
+	MethodViewsPublish = "views.publish"
+	MethodViewsUpdate  = "views.update"
+)
+
+type ViewResponse struct {
+	Response
+	View struct {
+		ID   string `json:"id"`
+		Hash string `json:"hash"`
+	} `json:"view"`
+}
+
+type openViewRequest struct {
+	TriggerID string      `json:"trigger_id"`
+	View      interface{} `json:"view"`
+}
+
+func (c *Client) OpenView(ctx context.Context, triggerID string, view interface{}) (ViewResponse, error) {
+	res := ViewResponse{}
+	if err := c.postJSON(ctx, MethodViewsOpen, openViewRequest{TriggerID: triggerID, View: view}, &res); err != nil {
+		return ViewResponse{}, err
+	}
+	return res, nil
+}
+
+type publishViewRequest struct {
+	UserID string      `json:"user_id"`
+	View   interface{} `json:"view"`
+}
+
+func (c *Client) PublishView(ctx context.Context, userID string, view interface{}) (ViewResponse, error) {
+	res := ViewResponse{}
+	if err := c.postJSON(ctx, MethodViewsPublish, publishViewRequest{UserID: userID, View: view}, &res); err != nil {
+		return ViewResponse{}, err
+	}
+	return res, nil
+}
+
+type UpdateViewRequest struct {
+	ViewID     string      `json:"view_id,omitempty"`
+	ExternalID string      `json:"external_id,omitempty"`
+	Hash       string      `json:"hash,omitempty"`
+	View       interface{} `json:"view"`
+}
+
+func (c *Client) UpdateView(ctx context.Context, req UpdateViewRequest) (ViewResponse, error) {
+	res := ViewResponse{}
+	if err := c.postJSON(ctx, MethodViewsUpdate, req, &res); err != nil {
+		return ViewResponse{}, err
+	}
+	return res, nil
+}
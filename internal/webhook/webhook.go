@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	HeaderEvent     = "X-Bitopi-Event"
+	HeaderDelivery  = "X-Bitopi-Delivery"
+	HeaderTimestamp = "X-Bitopi-Timestamp"
+	HeaderSignature = "X-Bitopi-Signature"
+
+	_signaturePrefix = "sha256="
+	/* response body kept in the error of failed attempts */
+	_errorBodyLimit = 512
+)
+
+/*
+Sign returns the signature of the body sent at the unix timestamp, receivers verify it by
+
+	hex(HMAC-SHA256(secret, timestamp + "." + body)) == strings.TrimPrefix(X-Bitopi-Signature, "sha256=")
+*/
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return _signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Request is a delivery of an event, every attempt of it is signed again with the time of the attempt.
+*/
+type Request struct {
+	URL      string
+	Secret   string
+	Event    string
+	Delivery string /* ID of the delivery, shared by every attempt */
+	Body     []byte
+}
+
+/*
+Attempt is the result of an attempt, StatusCode is 0 when the request wasn't answered.
+*/
+type Attempt struct {
+	Number     int
+	StatusCode int
+	Err        error
+	Retry      time.Duration /* delay before the next attempt of the failed attempt, 0 when it shouldn't be retried */
+}
+
+type Option struct {
+	MaxAttempts int           /* attempts of each delivery, 3 when it's zero */
+	Backoff     time.Duration /* delay before the second attempt, doubled by every attempt, 1s when it's zero */
+	MaxBackoff  time.Duration /* 1m when it's zero */
+	Timeout     time.Duration /* timeout of each attempt, 10s when it's zero */
+}
+
+/*
+Sender posts events to webhooks, network errors, 429 and 5xx are retried with exponential backoff,
+other responses out of 2xx fail the delivery at once.
+It makes one attempt at a time, the caller schedules the next attempt, e.g. in a persisted queue.
+*/
+type Sender struct {
+	httpClient *http.Client
+	opt        Option
+}
+
+func NewSender(httpClient *http.Client, opt Option) *Sender {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = 3
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = time.Second
+	}
+	if opt.MaxBackoff <= 0 {
+		opt.MaxBackoff = time.Minute
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	return &Sender{httpClient: httpClient, opt: opt}
+}
+
+/*
+Try makes the nth attempt of the request, the attempt is cancelled when ctx is done.
+Failed attempts are retryable until the max attempts, Retry of the attempt is the backoff then.
+*/
+func (s *Sender) Try(ctx context.Context, req Request, n int) Attempt {
+	statusCode, err := s.post(ctx, req)
+	a := Attempt{Number: n, StatusCode: statusCode, Err: err}
+	if err != nil && retryable(statusCode) && n < s.opt.MaxAttempts {
+		a.Retry = s.backoff(n)
+	}
+	return a
+}
+
+func (s *Sender) post(ctx context.Context, req Request) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opt.Timeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, errors.Wrap(err, "create request")
+	}
+
+	timestamp := time.Now().Unix()
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("User-Agent", "bitopi-webhook")
+	r.Header.Set(HeaderEvent, req.Event)
+	r.Header.Set(HeaderDelivery, req.Delivery)
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := s.httpClient.Do(r)
+	if err != nil {
+		return 0, errors.Wrap(err, "post webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	buf, _ := io.ReadAll(io.LimitReader(resp.Body, _errorBodyLimit))
+	if buf = bytes.TrimSpace(buf); len(buf) == 0 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, buf)
+}
+
+/*
+backoff returns the delay after the nth attempt, it's jittered by up to half of it.
+*/
+func (s *Sender) backoff(n int) time.Duration {
+	d := s.opt.Backoff << (n - 1)
+	if d <= 0 || d > s.opt.MaxBackoff {
+		d = s.opt.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
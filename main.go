@@ -2,6 +2,8 @@ package main
 
 import (
 	"bitopi/internal/app"
+	"fmt"
+	"os"
 
 	"github.com/yanun0323/pkg/config"
 )
@@ -12,5 +14,12 @@ func main() {
 		panic("init config failed")
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.Migrate(os.Args[2:]); err != nil {
+			panic(fmt.Sprintf("migrate failed, err: %+v", err))
+		}
+		return
+	}
+
 	app.Run()
 }